@@ -2,27 +2,159 @@ package midleware
 
 import (
 	"context"
-	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+	"encoding/json"
 	"net/http"
+	"strings"
+
+	"github.com/vsantosalmeida/browser-chat/api/rest/presenter"
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
 )
 
-// AuthMiddleware middleware to validate an AuthenticatedUser and pass through context.
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		token, tok := r.URL.Query()["bearer"]
-		if tok && len(token) == 1 {
-			user, err := auth.ValidateJWTToken(token[0])
+// TokenValidator validates a JWT token and builds the entity.AuthenticatedUser.
+type TokenValidator interface {
+	ValidateJWTToken(token string) (entity.AuthenticatedUser, error)
+}
+
+// RevocationChecker checks if the login that issued a token was revoked.
+type RevocationChecker interface {
+	CheckRevoked(loginID string) error
+}
+
+// AuthConfig token transports accepted by the AuthMiddleware besides the Authorization header
+// and the websocket subprotocol.
+//
+// an empty CookieName disables the cookie, the query string token ends up in the proxy and access logs
+// and may be disabled.
+type AuthConfig struct {
+	CookieName      string
+	AllowQueryToken bool
+}
+
+// AuthMiddleware builds the router middleware to validate an AuthenticatedUser and pass through context.
+// a token issued by a revoked login, e.g. after logout, is rejected.
+func AuthMiddleware(tokens TokenValidator, revocations RevocationChecker, cfg AuthConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := tokenFromRequest(r, cfg)
+			if !ok {
+				unauthorized(w, r, "authentication required")
+				return
+			}
+
+			user, err := tokens.ValidateJWTToken(token)
 			if err != nil {
-				http.Error(w, "forbidden", http.StatusForbidden)
+				unauthorized(w, r, "invalid token")
+				return
+			}
+
+			if err = revocations.CheckRevoked(user.GetLoginID()); err != nil {
+				if errors.Is(err, entity.ErrInvalidToken) {
+					unauthorized(w, r, "token revoked")
+					return
+				}
 
-			} else {
-				ctx := context.WithValue(r.Context(), auth.UserContextKey, user)
-				next.ServeHTTP(w, r.WithContext(ctx))
+				log.WithError(err).Error("could not check token revocation")
+				writeProblem(w, r, http.StatusInternalServerError, "could not check token")
+				return
 			}
 
-		} else {
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte("authentication required"))
+			ctx := context.WithValue(r.Context(), auth.UserContextKey, user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireRole builds the middleware to allow only the users with one of the roles,
+// it must run after the AuthMiddleware.
+func RequireRole(roles ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(auth.UserContextKey).(entity.AuthenticatedUser)
+			if !ok {
+				unauthorized(w, r, "authentication required")
+				return
+			}
+
+			for _, role := range roles {
+				if user.GetRole() == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			log.WithFields(log.Fields{
+				"userID": user.GetId(),
+				"path":   r.URL.Path,
+			}).Warn("user role not allowed")
+			writeProblem(w, r, http.StatusForbidden, "user role not allowed")
+		})
+	}
+}
+
+// unauthorized writes the 401 problem asking for a bearer token.
+func unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="chat-api"`)
+	writeProblem(w, r, http.StatusUnauthorized, detail)
+}
+
+// writeProblem writes the same application/problem+json error response as the REST handlers.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	w.Header().Set("Content-Type", presenter.ProblemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(presenter.NewProblem(status, detail, r.URL.Path))
+}
+
+// tokenFromRequest retrieves the token from the Authorization header, the cookie, the websocket subprotocol
+// or the query string, in this order.
+func tokenFromRequest(r *http.Request, cfg AuthConfig) (string, bool) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return "", false
+		}
+
+		return strings.TrimSpace(token), true
+	}
+
+	if cfg.CookieName != "" {
+		if cookie, err := r.Cookie(cfg.CookieName); err == nil && cookie.Value != "" {
+			return cookie.Value, true
 		}
 	}
+
+	// browsers can't set headers on a websocket handshake, the token is sent as the subprotocol
+	// following the bearer one
+	if token, ok := tokenFromSubprotocols(r); ok {
+		return token, true
+	}
+
+	if cfg.AllowQueryToken {
+		if token, ok := r.URL.Query()["bearer"]; ok && len(token) == 1 && token[0] != "" {
+			return token[0], true
+		}
+	}
+
+	return "", false
+}
+
+// tokenFromSubprotocols retrieves the token from the Sec-WebSocket-Protocol header, e.g. "bearer, {token}".
+func tokenFromSubprotocols(r *http.Request) (string, bool) {
+	var protocols []string
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(header, ",") {
+			protocols = append(protocols, strings.TrimSpace(p))
+		}
+	}
+
+	for i := 0; i < len(protocols)-1; i++ {
+		if protocols[i] == auth.BearerProtocol && protocols[i+1] != "" {
+			return protocols[i+1], true
+		}
+	}
+
+	return "", false
 }
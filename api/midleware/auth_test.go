@@ -0,0 +1,173 @@
+package midleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vsantosalmeida/browser-chat/api/midleware"
+	"github.com/vsantosalmeida/browser-chat/api/rest/presenter"
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// tokens accepts the "valid" token issued by the "login" login.
+type tokens struct {
+	revoked bool
+}
+
+func (t tokens) ValidateJWTToken(token string) (entity.AuthenticatedUser, error) {
+	if token != "valid" {
+		return nil, errors.New("invalid token")
+	}
+
+	return &auth.Claims{ID: 10, Username: "user", LoginID: "login"}, nil
+}
+
+func (t tokens) CheckRevoked(loginID string) error {
+	if t.revoked {
+		return entity.ErrInvalidToken
+	}
+
+	return nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	var tt = []struct {
+		name     string
+		cfg      midleware.AuthConfig
+		revoked  bool
+		request  func(r *http.Request)
+		expected int
+	}{
+		{
+			name:     "When the token is sent in the Authorization header; should authenticate the user",
+			request:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer valid") },
+			expected: http.StatusOK,
+		},
+		{
+			name:     "When the Authorization header isn't a bearer token; should return unauthorized",
+			request:  func(r *http.Request) { r.Header.Set("Authorization", "Basic dXNlcjpwYXNz") },
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "When the token is sent in the cookie; should authenticate the user",
+			cfg:      midleware.AuthConfig{CookieName: "access_token"},
+			request:  func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "access_token", Value: "valid"}) },
+			expected: http.StatusOK,
+		},
+		{
+			name:     "When the cookie is disabled; should return unauthorized",
+			request:  func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "access_token", Value: "valid"}) },
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "When the token follows the bearer websocket subprotocol; should authenticate the user",
+			request:  func(r *http.Request) { r.Header.Set("Sec-WebSocket-Protocol", "bearer, valid") },
+			expected: http.StatusOK,
+		},
+		{
+			name:     "When the token is sent in the query string; should authenticate the user",
+			cfg:      midleware.AuthConfig{AllowQueryToken: true},
+			request:  func(r *http.Request) { r.URL.RawQuery = "bearer=valid" },
+			expected: http.StatusOK,
+		},
+		{
+			name:     "When the query string token is disabled; should return unauthorized",
+			request:  func(r *http.Request) { r.URL.RawQuery = "bearer=valid" },
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "When the token is invalid; should return unauthorized",
+			request:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer invalid") },
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "When the token login was revoked; should return unauthorized",
+			revoked:  true,
+			request:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer valid") },
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var user entity.AuthenticatedUser
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				user, _ = r.Context().Value(auth.UserContextKey).(entity.AuthenticatedUser)
+			})
+
+			v := tokens{revoked: tc.revoked}
+			h := midleware.AuthMiddleware(v, v, tc.cfg)(next)
+
+			r := httptest.NewRequest(http.MethodGet, "/rooms", nil)
+			tc.request(r)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expected, w.Code)
+			if tc.expected == http.StatusOK {
+				assert.Equal(t, 10, user.GetId())
+				return
+			}
+
+			var problem presenter.Problem
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+			assert.Equal(t, presenter.ProblemContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expected, problem.Status)
+			assert.NotEmpty(t, problem.Detail)
+			assert.Equal(t, `Bearer realm="chat-api"`, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	var tt = []struct {
+		name     string
+		user     entity.AuthenticatedUser
+		expected int
+	}{
+		{
+			name:     "When the user has the required role; should call the next handler",
+			user:     &auth.Claims{ID: 10, Role: entity.UserRoleAdmin},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "When the user hasn't the required role; should return forbidden",
+			user:     &auth.Claims{ID: 10, Role: entity.UserRoleUser},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "When the token has no role; should return forbidden",
+			user:     &auth.Claims{ID: 10},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "When the user isn't authenticated; should return unauthorized",
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			h := midleware.RequireRole(entity.UserRoleAdmin)(next)
+
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tc.user != nil {
+				r = r.WithContext(context.WithValue(r.Context(), auth.UserContextKey, tc.user))
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
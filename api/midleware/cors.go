@@ -2,11 +2,12 @@ package midleware
 
 import "net/http"
 
-// Cors adds default headers.
+// Cors adds default headers and answers the preflight requests, the Authorization header is allowed.
+// it must wrap the router, a router middleware doesn't run for the OPTIONS requests without a route.
 func Cors(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type")
 		w.Header().Set("Content-Type", "application/json")
 		if r.Method == "OPTIONS" {
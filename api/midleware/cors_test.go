@@ -0,0 +1,55 @@
+package midleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vsantosalmeida/browser-chat/api/midleware"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCors(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/rooms", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}).Methods(http.MethodGet)
+
+	handler := midleware.Cors(r)
+
+	var tt = []struct {
+		name     string
+		method   string
+		expected int
+	}{
+		{
+			name:     "When a preflight request has no route; should allow the Authorization header",
+			method:   http.MethodOptions,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "When a request has a route; should call the route with the CORS headers",
+			method:   http.MethodGet,
+			expected: http.StatusTeapot,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/rooms", nil)
+			req.Header.Set("Origin", "http://localhost:3000")
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			req.Header.Set("Access-Control-Request-Headers", "authorization")
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expected, rec.Code)
+			assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+			assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+			assert.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), http.MethodGet)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package midleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RemoteIP returns the address of the client, the first X-Forwarded-For address when the proxy headers are trusted,
+// the header is set by any client otherwise.
+func RemoteIP(r *http.Request, trustProxy bool) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); trustProxy && forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
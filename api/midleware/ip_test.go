@@ -0,0 +1,48 @@
+package midleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vsantosalmeida/browser-chat/api/midleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteIP(t *testing.T) {
+	var tt = []struct {
+		name       string
+		forwarded  string
+		trustProxy bool
+		expected   string
+	}{
+		{
+			name:     "When the request has no proxy header; should return the remote address",
+			expected: "10.0.0.1",
+		},
+		{
+			name:      "When the proxy headers aren't trusted; should ignore the forwarded address",
+			forwarded: "1.2.3.4",
+			expected:  "10.0.0.1",
+		},
+		{
+			name:       "When the proxy headers are trusted; should return the first forwarded address",
+			forwarded:  " 1.2.3.4 , 10.0.0.2",
+			trustProxy: true,
+			expected:   "1.2.3.4",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+			req.RemoteAddr = "10.0.0.1:5000"
+			if tc.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+
+			assert.Equal(t, tc.expected, midleware.RemoteIP(req, tc.trustProxy))
+		})
+	}
+}
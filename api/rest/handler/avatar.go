@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/vsantosalmeida/browser-chat/usecase/user"
+
+	"github.com/gorilla/mux"
+)
+
+type AvatarHandler struct {
+	useCase user.UseCase
+}
+
+func NewAvatarHandler(useCase user.UseCase) *AvatarHandler {
+	return &AvatarHandler{
+		useCase: useCase,
+	}
+}
+
+func (h *AvatarHandler) HandleGetAvatar(w http.ResponseWriter, r *http.Request) {
+	avatar, err := h.useCase.GetAvatar(mux.Vars(r)["name"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// each upload gets a new name, the image is never changed
+	w.Header().Set("Content-Type", avatar.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+	http.ServeContent(w, r, avatar.Name, avatar.CreatedAt, bytes.NewReader(avatar.Data))
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/vsantosalmeida/browser-chat/api/rest/presenter"
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+
+	"github.com/apex/log"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// authenticatedUser retrieves the entity.AuthenticatedUser set by the auth middleware.
+func authenticatedUser(r *http.Request) (entity.AuthenticatedUser, bool) {
+	user, ok := r.Context().Value(auth.UserContextKey).(entity.AuthenticatedUser)
+	return user, ok
+}
+
+// intParam retrieves an integer path parameter.
+func intParam(r *http.Request, name string) (int, error) {
+	value, ok := mux.Vars(r)[name]
+	if !ok {
+		return 0, errors.Errorf("empty %s", name)
+	}
+
+	return strconv.Atoi(value)
+}
+
+// errorStatus maps a domain error kind to the HTTP status code.
+func errorStatus(kind entity.ErrorKind) int {
+	switch kind {
+	case entity.KindInvalid:
+		return http.StatusBadRequest
+	case entity.KindUnauthorized:
+		return http.StatusUnauthorized
+	case entity.KindForbidden:
+		return http.StatusForbidden
+	case entity.KindNotFound:
+		return http.StatusNotFound
+	case entity.KindConflict:
+		return http.StatusConflict
+	case entity.KindTooManyRequests:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError writes the problem of a domain error, the internal errors are logged and their message hidden.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	e := entity.AsError(err)
+	if e.Kind == entity.KindInternal {
+		log.WithError(err).WithField("path", r.URL.Path).Error("request failed")
+	}
+
+	writeProblem(w, r, errorStatus(e.Kind), e.Message)
+}
+
+// badRequest writes the problem of a malformed request, e.g. an invalid body or path parameter.
+func badRequest(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, r, http.StatusBadRequest, err.Error())
+}
+
+// writeProblem writes the application/problem+json error response.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	w.Header().Set("Content-Type", presenter.ProblemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(presenter.NewProblem(status, detail, r.URL.Path))
+}
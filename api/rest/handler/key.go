@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+)
+
+type KeyHandler struct {
+	keys *auth.KeySet
+}
+
+func NewKeyHandler(keys *auth.KeySet) *KeyHandler {
+	return &KeyHandler{
+		keys: keys,
+	}
+}
+
+func (h *KeyHandler) HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	b, err := json.Marshal(h.keys.JWKS())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// the verifiers may cache the keys, a rotated key is kept until the tokens it signed expire
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(b)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/api/rest/presenter"
+	"github.com/vsantosalmeida/browser-chat/usecase/moderation"
+)
+
+type ModerationHandler struct {
+	useCase moderation.UseCase
+}
+
+func NewModerationHandler(useCase moderation.UseCase) *ModerationHandler {
+	return &ModerationHandler{
+		useCase: useCase,
+	}
+}
+
+func (h *ModerationHandler) HandleKick(w http.ResponseWriter, r *http.Request) {
+	h.handleSanction(w, r, h.useCase.Kick)
+}
+
+func (h *ModerationHandler) HandleBan(w http.ResponseWriter, r *http.Request) {
+	h.handleSanction(w, r, h.useCase.Ban)
+}
+
+func (h *ModerationHandler) HandleMute(w http.ResponseWriter, r *http.Request) {
+	h.handleSanction(w, r, h.useCase.Mute)
+}
+
+func (h *ModerationHandler) HandleUnban(w http.ResponseWriter, r *http.Request) {
+	h.handleRevoke(w, r, h.useCase.Unban)
+}
+
+func (h *ModerationHandler) HandleUnmute(w http.ResponseWriter, r *http.Request) {
+	h.handleRevoke(w, r, h.useCase.Unmute)
+}
+
+func (h *ModerationHandler) HandleListSanctions(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	sanctions, err := h.useCase.ListSanctions(user.GetId(), roomID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	output := presenter.MapEntityToExternalSanctions(sanctions)
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleSanction decodes the sanction input and applies it to the room with the given use case method.
+func (h *ModerationHandler) handleSanction(w http.ResponseWriter, r *http.Request, apply func(actorID, roomID int, input moderation.SanctionInput) error) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	var input presenter.SanctionInput
+
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err = apply(user.GetId(), roomID, moderation.SanctionInput{
+		UserID:   input.UserID,
+		Reason:   input.Reason,
+		Duration: time.Duration(input.DurationSeconds) * time.Second,
+	}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRevoke revokes the user sanctions in the room with the given use case method.
+func (h *ModerationHandler) handleRevoke(w http.ResponseWriter, r *http.Request, revoke func(actorID, roomID, userID int) error) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	userID, err := intParam(r, "userID")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err = revoke(user.GetId(), roomID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/api/rest/presenter"
+	"github.com/vsantosalmeida/browser-chat/usecase/report"
+)
+
+type ReportHandler struct {
+	useCase report.UseCase
+}
+
+func NewReportHandler(useCase report.UseCase) *ReportHandler {
+	return &ReportHandler{
+		useCase: useCase,
+	}
+}
+
+func (h *ReportHandler) HandleReportMessage(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	messageID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	var input presenter.ReportInput
+
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	id, err := h.useCase.ReportMessage(user.GetId(), messageID, input.Reason)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	output := presenter.CreateReportOutput{
+		ID: id,
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+func (h *ReportHandler) HandleListReports(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	reports, err := h.useCase.ListReports(user.GetId(), roomID, r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	output := presenter.MapEntityToExternalReports(reports)
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+func (h *ReportHandler) HandleGetReport(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	reportID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	detail, err := h.useCase.GetReport(user.GetId(), reportID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	output := presenter.MapReportDetailToExternal(detail)
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+func (h *ReportHandler) HandleResolveReport(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	reportID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	var input presenter.ResolveReportInput
+
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err = h.useCase.ResolveReport(user.GetId(), reportID, report.ResolveInput{
+		Action:   input.Action,
+		Note:     input.Note,
+		Duration: time.Duration(input.DurationSeconds) * time.Second,
+	}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,12 +3,10 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"github.com/vsantosalmeida/browser-chat/api/rest/presenter"
+	"github.com/vsantosalmeida/browser-chat/entity"
 	"github.com/vsantosalmeida/browser-chat/usecase/room"
-
-	"github.com/gorilla/mux"
 )
 
 type RoomHandler struct {
@@ -22,9 +20,15 @@ func NewRoomHandler(useCase room.UseCase) *RoomHandler {
 }
 
 func (h *RoomHandler) HandleListRooms(w http.ResponseWriter, r *http.Request) {
-	rooms, err := h.useCase.ListRooms()
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	rooms, err := h.useCase.ListVisibleRooms(user.GetId())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -32,31 +36,56 @@ func (h *RoomHandler) HandleListRooms(w http.ResponseWriter, r *http.Request) {
 
 	b, err := json.Marshal(output)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Write(b)
 }
 
-func (h *RoomHandler) HandleListMessages(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
+func (h *RoomHandler) HandleGetRoom(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
 
-	value, ok := params["id"]
+	rm, err := h.useCase.FindRoom(roomID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err = h.useCase.CheckAccess(user.GetId(), rm); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.writeRoom(w, r, rm)
+}
+
+func (h *RoomHandler) HandleListMessages(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
 	if !ok {
-		http.Error(w, "empty room id", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
 		return
 	}
 
-	roomID, err := strconv.Atoi(value)
+	roomID, err := intParam(r, "id")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		badRequest(w, r, err)
 		return
 	}
 
-	mgs, err := h.useCase.ListMessages(roomID)
+	mgs, err := h.useCase.ListMessages(user.GetId(), roomID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -64,7 +93,7 @@ func (h *RoomHandler) HandleListMessages(w http.ResponseWriter, r *http.Request)
 
 	b, err := json.Marshal(output)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -72,9 +101,27 @@ func (h *RoomHandler) HandleListMessages(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *RoomHandler) HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
-	id, err := h.useCase.CreateRoom()
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var input presenter.CreateRoomInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	id, err := h.useCase.CreateRoom(user.GetId(), room.CreateInput{
+		Name:        input.Name,
+		Topic:       input.Topic,
+		Description: input.Description,
+		Private:     input.Private,
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -84,7 +131,278 @@ func (h *RoomHandler) HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
 
 	b, err := json.Marshal(output)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+func (h *RoomHandler) HandleUpdateRoom(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	var input presenter.UpdateRoomInput
+
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	rm, err := h.useCase.UpdateRoom(user.GetId(), roomID, room.UpdateInput{
+		Name:             input.Name,
+		Topic:            input.Topic,
+		Description:      input.Description,
+		Private:          input.Private,
+		MaxMessageLength: input.MaxMessageLength,
+		SlowModeSeconds:  input.SlowModeSeconds,
+		BlockedPatterns:  input.BlockedPatterns,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.writeRoom(w, r, rm)
+}
+
+func (h *RoomHandler) HandleArchiveRoom(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	rm, err := h.useCase.ArchiveRoom(user.GetId(), roomID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.writeRoom(w, r, rm)
+}
+
+func (h *RoomHandler) HandleDeleteRoom(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err = h.useCase.DeleteRoom(user.GetId(), roomID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RoomHandler) HandleListMembers(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	rm, err := h.useCase.FindRoom(roomID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err = h.useCase.CheckAccess(user.GetId(), rm); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	members, err := h.useCase.ListMembers(roomID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	output := presenter.MapEntityToExternalMembers(members)
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+func (h *RoomHandler) HandleSetMemberRole(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	memberID, err := intParam(r, "userID")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	var input presenter.SetMemberRoleInput
+
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err = h.useCase.SetMemberRole(user.GetId(), roomID, memberID, input.Role); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RoomHandler) HandleInviteUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	roomID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	var input presenter.CreateInvitationInput
+
+	if err = json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	id, err := h.useCase.InviteUser(user.GetId(), roomID, input.UserID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	output := presenter.CreateInvitationOutput{
+		ID: id,
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+func (h *RoomHandler) HandleListInvitations(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	invitations, err := h.useCase.ListInvitations(user.GetId())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	output := presenter.MapEntityToExternalInvitations(invitations)
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+func (h *RoomHandler) HandleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	invitationID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err = h.useCase.AcceptInvitation(user.GetId(), invitationID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RoomHandler) HandleDeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	invitationID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err = h.useCase.DeclineInvitation(user.GetId(), invitationID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeRoom writes the room as the response body.
+func (h *RoomHandler) writeRoom(w http.ResponseWriter, r *http.Request, rm *entity.Room) {
+	b, err := json.Marshal(presenter.MapEntityToExternalRoom(rm))
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vsantosalmeida/browser-chat/api/rest/presenter"
+	"github.com/vsantosalmeida/browser-chat/usecase/session"
+)
+
+type SessionHandler struct {
+	useCase session.UseCase
+}
+
+func NewSessionHandler(useCase session.UseCase) *SessionHandler {
+	return &SessionHandler{
+		useCase: useCase,
+	}
+}
+
+func (h *SessionHandler) HandleListSessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	sessions, err := h.useCase.ListSessions(user.GetId())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	output := presenter.MapEntityToExternalSessions(sessions)
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+func (h *SessionHandler) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	sessionID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err = h.useCase.Revoke(user.GetId(), sessionID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,19 +2,44 @@ package handler
 
 import (
 	"encoding/json"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/vsantosalmeida/browser-chat/api/midleware"
 	"github.com/vsantosalmeida/browser-chat/api/rest/presenter"
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/token"
 	"github.com/vsantosalmeida/browser-chat/usecase/user"
+
+	"github.com/pkg/errors"
 )
 
+// maxMultipartOverhead bytes allowed for the multipart boundaries and headers of an avatar upload.
+const maxMultipartOverhead = 1 << 10
+
+// TokenCookie HttpOnly cookie carrying the access token to the browser, an empty Name disables it.
+type TokenCookie struct {
+	Name   string
+	Secure bool
+}
+
 type UserHandler struct {
 	useCase user.UseCase
+	tokens  token.UseCase
+	cookie  TokenCookie
+	// the logins are throttled by the X-Forwarded-For client IP only when the proxy headers are trusted
+	trustProxy bool
 }
 
-func NewUserHandler(useCase user.UseCase) *UserHandler {
+func NewUserHandler(useCase user.UseCase, tokens token.UseCase, cookie TokenCookie, trustProxy bool) *UserHandler {
 	return &UserHandler{
-		useCase: useCase,
+		useCase:    useCase,
+		tokens:     tokens,
+		cookie:     cookie,
+		trustProxy: trustProxy,
 	}
 }
 
@@ -23,23 +48,70 @@ func (h *UserHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		badRequest(w, r, err)
+		return
+	}
+
+	tokens, err := h.useCase.Authenticate(input.Username, input.Password, midleware.RemoteIP(r, h.trustProxy))
+	if err != nil {
+		var throttled *user.ThrottleError
+		if errors.As(err, &throttled) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		}
+
+		writeError(w, r, err)
+		return
+	}
+
+	h.writeTokens(w, r, tokens)
+}
+
+func (h *UserHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
+	var input presenter.RefreshInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
 		return
 	}
 
-	token, err := h.useCase.Authenticate(input.Username, input.Password)
+	tokens, err := h.tokens.Refresh(input.RefreshToken)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
+		return
+	}
+
+	h.writeTokens(w, r, tokens)
+}
+
+func (h *UserHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
 		return
 	}
 
+	if err := h.tokens.Logout(user.GetId(), user.GetLoginID()); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.setCookie(w, "", -1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTokens writes the issued tokens, the access token lifetime in seconds, and sets the access token cookie.
+func (h *UserHandler) writeTokens(w http.ResponseWriter, r *http.Request, tokens *token.Tokens) {
+	h.setCookie(w, tokens.AccessToken, int(tokens.ExpiresIn.Seconds()))
+
 	output := presenter.LoginOutput{
-		Token: token,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int(tokens.ExpiresIn.Seconds()),
 	}
 
 	b, err := json.Marshal(output)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -51,13 +123,13 @@ func (h *UserHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		badRequest(w, r, err)
 		return
 	}
 
 	id, err := h.useCase.CreateUser(input.Username, input.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -67,7 +139,7 @@ func (h *UserHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 
 	b, err := json.Marshal(output)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -77,7 +149,7 @@ func (h *UserHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) HandleListUsers(w http.ResponseWriter, r *http.Request) {
 	users, err := h.useCase.ListUsers()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -85,9 +157,256 @@ func (h *UserHandler) HandleListUsers(w http.ResponseWriter, r *http.Request) {
 
 	b, err := json.Marshal(output)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Write(b)
 }
+
+func (h *UserHandler) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var input presenter.ChangePasswordInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err := h.useCase.ChangePassword(user.GetId(), user.GetLoginID(), input.OldPassword, input.NewPassword); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) HandleGetMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	profile, err := h.useCase.GetProfile(user.GetId())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.writeProfile(w, r, profile)
+}
+
+func (h *UserHandler) HandleGetUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	profile, err := h.useCase.GetProfile(userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.writeProfile(w, r, profile)
+}
+
+func (h *UserHandler) HandleUpdateMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var input presenter.UpdateProfileInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	profile, err := h.useCase.UpdateProfile(user.GetId(), entity.ProfileUpdate{
+		DisplayName: input.DisplayName,
+		Bio:         input.Bio,
+		StatusText:  input.StatusText,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.writeProfile(w, r, profile)
+}
+
+func (h *UserHandler) HandleSetStatus(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var input presenter.UpdateStatusInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	profile, err := h.useCase.SetStatus(user.GetId(), entity.UserStatus(input.Status))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.writeProfile(w, r, profile)
+}
+
+func (h *UserHandler) HandleListBlocks(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	blocks, err := h.useCase.ListBlocks(user.GetId())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	output := presenter.MapEntityToExternalBlockedUsers(blocks)
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+func (h *UserHandler) HandleBlockUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	blockedID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err = h.useCase.BlockUser(user.GetId(), blockedID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) HandleUnblockUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	blockedID, err := intParam(r, "id")
+	if err != nil {
+		badRequest(w, r, err)
+		return
+	}
+
+	if err = h.useCase.UnblockUser(user.GetId(), blockedID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) HandleUploadAvatar(w http.ResponseWriter, r *http.Request) {
+	current, ok := authenticatedUser(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	// leaves room for the multipart headers, a larger avatar is read up to one byte over the limit
+	// to be rejected by the use case
+	r.Body = http.MaxBytesReader(w, r.Body, user.MaxAvatarSize+maxMultipartOverhead)
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("avatar")
+		if err != nil {
+			avatarReadError(w, r, err)
+			return
+		}
+		defer file.Close()
+
+		body = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, user.MaxAvatarSize+1))
+	if err != nil {
+		avatarReadError(w, r, err)
+		return
+	}
+
+	profile, err := h.useCase.UpdateAvatar(current.GetId(), data)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.writeProfile(w, r, profile)
+}
+
+// avatarReadError writes the problem of an unreadable avatar upload.
+func avatarReadError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeProblem(w, r, http.StatusRequestEntityTooLarge, entity.ErrInvalidAvatar.Error())
+		return
+	}
+
+	badRequest(w, r, err)
+}
+
+func (h *UserHandler) writeProfile(w http.ResponseWriter, r *http.Request, user *entity.User) {
+	b, err := json.Marshal(presenter.MapEntityToExternalProfile(user))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// setCookie sets the access token cookie, a negative max age deletes it.
+// the cookie isn't sent by cross-site requests.
+func (h *UserHandler) setCookie(w http.ResponseWriter, value string, maxAge int) {
+	if h.cookie.Name == "" {
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.cookie.Name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.cookie.Secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
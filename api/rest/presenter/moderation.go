@@ -0,0 +1,44 @@
+package presenter
+
+import (
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
+
+type SanctionInput struct {
+	UserID          int    `json:"userID"`
+	Reason          string `json:"reason"`
+	DurationSeconds int    `json:"durationSeconds"`
+}
+
+type Sanction struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"userID"`
+	ActorID   int        `json:"actorID"`
+	Type      string     `json:"type"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func MapEntityToExternalSanctions(sanctions []*entity.RoomSanction) []*Sanction {
+	result := make([]*Sanction, 0)
+
+	for _, s := range sanctions {
+		result = append(
+			result,
+			&Sanction{
+				ID:        s.ID,
+				UserID:    s.UserID,
+				ActorID:   s.ActorID,
+				Type:      s.Type,
+				Reason:    s.Reason,
+				ExpiresAt: s.ExpiresAt,
+				CreatedAt: s.CreatedAt,
+			},
+		)
+	}
+
+	return result
+}
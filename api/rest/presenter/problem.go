@@ -0,0 +1,26 @@
+package presenter
+
+import "net/http"
+
+// ProblemContentType media type of the error responses, RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// Problem RFC 7807 error response body.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// NewProblem builds the Problem of the HTTP status, the problem type is the status itself.
+func NewProblem(status int, detail, instance string) *Problem {
+	return &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: instance,
+	}
+}
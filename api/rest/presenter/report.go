@@ -0,0 +1,99 @@
+package presenter
+
+import (
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/report"
+)
+
+type ReportInput struct {
+	Reason string `json:"reason"`
+}
+
+type CreateReportOutput struct {
+	ID int `json:"id"`
+}
+
+type ResolveReportInput struct {
+	Action          string `json:"action"`
+	Note            string `json:"note"`
+	DurationSeconds int    `json:"durationSeconds"`
+}
+
+type Report struct {
+	ID             int        `json:"id"`
+	RoomID         int        `json:"roomID"`
+	MessageID      int        `json:"messageID"`
+	ReportedUserID int        `json:"reportedUserID"`
+	ReporterID     int        `json:"reporterID"`
+	Reason         string     `json:"reason"`
+	Content        string     `json:"content"`
+	Status         string     `json:"status"`
+	Resolution     string     `json:"resolution,omitempty"`
+	ResolverID     int        `json:"resolverID,omitempty"`
+	ResolvedAt     *time.Time `json:"resolvedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type AuditEntry struct {
+	ActorID   int       `json:"actorID"`
+	Action    string    `json:"action"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ReportDetail struct {
+	*Report
+	Context []*Message    `json:"context"`
+	Audit   []*AuditEntry `json:"audit"`
+}
+
+func MapEntityToExternalReport(r *entity.MessageReport) *Report {
+	return &Report{
+		ID:             r.ID,
+		RoomID:         r.RoomID,
+		MessageID:      r.MessageID,
+		ReportedUserID: r.ReportedUserID,
+		ReporterID:     r.ReporterID,
+		Reason:         r.Reason,
+		Content:        r.Content,
+		Status:         r.Status,
+		Resolution:     r.Resolution,
+		ResolverID:     r.ResolverID,
+		ResolvedAt:     r.ResolvedAt,
+		CreatedAt:      r.CreatedAt,
+	}
+}
+
+func MapEntityToExternalReports(reports []*entity.MessageReport) []*Report {
+	result := make([]*Report, 0)
+
+	for _, r := range reports {
+		result = append(result, MapEntityToExternalReport(r))
+	}
+
+	return result
+}
+
+func MapReportDetailToExternal(detail *report.Detail) *ReportDetail {
+	audit := make([]*AuditEntry, 0)
+
+	for _, e := range detail.Audit {
+		audit = append(
+			audit,
+			&AuditEntry{
+				ActorID:   e.ActorID,
+				Action:    e.Action,
+				Note:      e.Note,
+				CreatedAt: e.CreatedAt,
+			},
+		)
+	}
+
+	return &ReportDetail{
+		Report:  MapEntityToExternalReport(detail.Report),
+		Context: MapEntityToExternalMessages(detail.Context),
+		Audit:   audit,
+	}
+}
@@ -4,33 +4,105 @@ import (
 	"time"
 
 	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/markdown"
 )
 
+type CreateRoomInput struct {
+	Name        string `json:"name"`
+	Topic       string `json:"topic"`
+	Description string `json:"description"`
+	Private     bool   `json:"private"`
+}
+
 type CreateRoomOutput struct {
 	ID int `json:"id"`
 }
 
+type UpdateRoomInput struct {
+	Name             *string   `json:"name"`
+	Topic            *string   `json:"topic"`
+	Description      *string   `json:"description"`
+	Private          *bool     `json:"private"`
+	MaxMessageLength *int      `json:"maxMessageLength"`
+	SlowModeSeconds  *int      `json:"slowModeSeconds"`
+	BlockedPatterns  *[]string `json:"blockedPatterns"`
+}
+
 type Room struct {
+	ID               int        `json:"id"`
+	Name             string     `json:"name"`
+	Topic            string     `json:"topic"`
+	Description      string     `json:"description"`
+	CreatorID        int        `json:"creatorID"`
+	Private          bool       `json:"private"`
+	MaxMessageLength int        `json:"maxMessageLength"`
+	SlowModeSeconds  int        `json:"slowModeSeconds"`
+	BlockedPatterns  []string   `json:"blockedPatterns,omitempty"`
+	Archived         bool       `json:"archived"`
+	ArchivedAt       *time.Time `json:"archivedAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+}
+
+type CreateInvitationInput struct {
+	UserID int `json:"userID"`
+}
+
+type CreateInvitationOutput struct {
 	ID int `json:"id"`
 }
 
-type Message struct {
+type Invitation struct {
 	ID        int       `json:"id"`
-	Content   string    `json:"content"`
-	From      string    `json:"from"`
+	RoomID    int       `json:"roomID"`
+	RoomName  string    `json:"roomName"`
+	InviterID int       `json:"inviterID"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Member struct {
+	UserID    int       `json:"userID"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+type SetMemberRoleInput struct {
+	Role string `json:"role"`
+}
+
+type Message struct {
+	ID          int       `json:"id"`
+	Content     string    `json:"content"`
+	ContentHTML string    `json:"contentHtml"`
+	From        string    `json:"from"`
+	UserID      int       `json:"userID"`
+	DisplayName string    `json:"displayName"`
+	AvatarURL   string    `json:"avatarURL,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func MapEntityToExternalRoom(room *entity.Room) *Room {
+	return &Room{
+		ID:               room.ID,
+		Name:             room.Name,
+		Topic:            room.Topic,
+		Description:      room.Description,
+		CreatorID:        room.CreatorID,
+		Private:          room.Private,
+		MaxMessageLength: room.MessagePolicy().MaxLength,
+		SlowModeSeconds:  room.SlowModeSeconds,
+		BlockedPatterns:  room.ContentPatterns(),
+		Archived:         room.IsArchived(),
+		ArchivedAt:       room.ArchivedAt,
+		CreatedAt:        room.CreatedAt,
+	}
+}
+
 func MapEntityToExternalRooms(rooms []*entity.Room) []*Room {
 	result := make([]*Room, 0)
 
 	for _, room := range rooms {
-		result = append(
-			result,
-			&Room{
-				ID: room.ID,
-			},
-		)
+		result = append(result, MapEntityToExternalRoom(room))
 	}
 
 	return result
@@ -40,12 +112,59 @@ func MapEntityToExternalMessages(mgs []*entity.Message) []*Message {
 	result := make([]*Message, 0)
 
 	for _, m := range mgs {
+		// the messages stored before the markdown rendering have no HTML content
+		html := m.ContentHTML
+		if html == "" {
+			html = markdown.Render(m.Content)
+		}
+
 		result = append(
 			result,
 			&Message{
-				ID:        m.ID,
-				Content:   m.Content,
-				From:      m.User.Username,
+				ID:          m.ID,
+				Content:     m.Content,
+				ContentHTML: html,
+				From:        m.User.Username,
+				UserID:      m.UserID,
+				DisplayName: m.User.Name(),
+				AvatarURL:   m.User.AvatarURL(),
+				CreatedAt:   m.CreatedAt,
+			},
+		)
+	}
+
+	return result
+}
+
+func MapEntityToExternalInvitations(invitations []*entity.RoomInvitation) []*Invitation {
+	result := make([]*Invitation, 0)
+
+	for _, i := range invitations {
+		result = append(
+			result,
+			&Invitation{
+				ID:        i.ID,
+				RoomID:    i.RoomID,
+				RoomName:  i.Room.Name,
+				InviterID: i.InviterID,
+				Status:    i.Status,
+				CreatedAt: i.CreatedAt,
+			},
+		)
+	}
+
+	return result
+}
+
+func MapEntityToExternalMembers(members []*entity.RoomMember) []*Member {
+	result := make([]*Member, 0)
+
+	for _, m := range members {
+		result = append(
+			result,
+			&Member{
+				UserID:    m.UserID,
+				Role:      m.Role,
 				CreatedAt: m.CreatedAt,
 			},
 		)
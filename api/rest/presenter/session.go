@@ -0,0 +1,36 @@
+package presenter
+
+import (
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
+
+type Session struct {
+	ID          int       `json:"id"`
+	DeviceLabel string    `json:"deviceLabel"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"userAgent"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	LastSeenAt  time.Time `json:"lastSeenAt"`
+}
+
+func MapEntityToExternalSessions(sessions []*entity.UserSession) []*Session {
+	result := make([]*Session, 0)
+
+	for _, s := range sessions {
+		result = append(
+			result,
+			&Session{
+				ID:          s.ID,
+				DeviceLabel: s.DeviceLabel,
+				IP:          s.IP,
+				UserAgent:   s.UserAgent,
+				ConnectedAt: s.ConnectedAt,
+				LastSeenAt:  s.LastSeenAt,
+			},
+		)
+	}
+
+	return result
+}
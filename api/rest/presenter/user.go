@@ -1,6 +1,10 @@
 package presenter
 
-import "github.com/vsantosalmeida/browser-chat/entity"
+import (
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
 
 type LoginInput struct {
 	Username string `json:"username"`
@@ -8,7 +12,13 @@ type LoginInput struct {
 }
 
 type LoginOutput struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refreshToken"`
 }
 
 type CreateUserInput struct {
@@ -16,6 +26,11 @@ type CreateUserInput struct {
 	Password string `json:"password"`
 }
 
+type ChangePasswordInput struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
 type CreateUserOutput struct {
 	ID int `json:"id"`
 }
@@ -23,6 +38,40 @@ type CreateUserOutput struct {
 type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type Profile struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	AvatarURL   string `json:"avatarURL,omitempty"`
+	Bio         string `json:"bio"`
+	StatusText  string `json:"statusText"`
+	Status      string `json:"status,omitempty"`
+}
+
+// UpdateProfileInput a missing field is kept, an empty one cleared.
+type UpdateProfileInput struct {
+	DisplayName *string `json:"displayName"`
+	Bio         *string `json:"bio"`
+	StatusText  *string `json:"statusText"`
+}
+
+type UpdateStatusInput struct {
+	Status string `json:"status"`
+}
+
+func MapEntityToExternalProfile(user *entity.User) *Profile {
+	return &Profile{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.Name(),
+		AvatarURL:   user.AvatarURL(),
+		Bio:         user.Bio,
+		StatusText:  user.StatusText,
+		Status:      string(user.Status),
+	}
 }
 
 func MapEntityToExternalUsers(users []*entity.User) []*User {
@@ -34,6 +83,34 @@ func MapEntityToExternalUsers(users []*entity.User) []*User {
 			&User{
 				ID:       user.ID,
 				Username: user.Username,
+				Role:     user.Role,
+			},
+		)
+	}
+
+	return result
+}
+
+type BlockedUser struct {
+	ID          int       `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"displayName"`
+	AvatarURL   string    `json:"avatarURL,omitempty"`
+	BlockedAt   time.Time `json:"blockedAt"`
+}
+
+func MapEntityToExternalBlockedUsers(blocks []*entity.UserBlock) []*BlockedUser {
+	result := make([]*BlockedUser, 0)
+
+	for _, b := range blocks {
+		result = append(
+			result,
+			&BlockedUser{
+				ID:          b.BlockedID,
+				Username:    b.Blocked.Username,
+				DisplayName: b.Blocked.Name(),
+				AvatarURL:   b.Blocked.AvatarURL(),
+				BlockedAt:   b.CreatedAt,
 			},
 		)
 	}
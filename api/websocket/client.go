@@ -2,10 +2,15 @@ package websocket
 
 import (
 	"encoding/json"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/vsantosalmeida/browser-chat/entity"
+
 	"github.com/apex/log"
 	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -13,37 +18,181 @@ const (
 	pongWait = 10 * time.Second
 	// pingPeriod usually the ping period is less than a pong timeout, it uses 90% of pong timeout .
 	pingPeriod = (pongWait * 9) / 10
-	// readLimit max message bytes
-	readLimit = 1000
+	// writeWait time allowed to write the shutdown or revoked session event and the close frame.
+	writeWait = 5 * time.Second
+	// readLimit max message bytes, long enough to let the room message policy reject a message
+	// without closing the connection. A character is up to 6 bytes escaped in JSON, plus the event envelope.
+	readLimit = entity.MaxMessageLength*6 + 1024
 )
 
 // Client represents a connected client in the chat Server.
 type Client struct {
-	conn     *websocket.Conn
-	server   *Server
-	event    chan Event
-	ID       int
-	Username string
-	RoomID   int
+	conn       *websocket.Conn
+	server     *Server
+	event      chan Event
+	limiter    *tokenBucket
+	quit       chan struct{}
+	closed     chan struct{}
+	revoked    chan struct{}
+	revokeOnce sync.Once
+	ID         int
+	Username   string
+	SessionID  int
+	LoginID    string
+	// guards the joined chat room and the profile, changed by the Server event listeners
+	mu     sync.RWMutex
+	RoomID int
+	// the profile sent with the Client messages, kept up to date by the profile events
+	DisplayName string
+	AvatarURL   string
+	// the status set by the user, empty when the status follows the user activity
+	Status entity.UserStatus
+	// last event time in unix nanoseconds, the pong responses aren't user activity
+	lastActive atomic.Int64
+	// the users blocked by the user, their messages aren't sent to the Client
+	blockedMu sync.RWMutex
+	blocked   map[int]bool
+	// why the event being routed was rejected, logged by the Logging middleware.
+	// only used by the goroutine reading the Client events
+	rejected *rejection
+}
+
+// rejection the ErrorEvent code and the error of a rejected event.
+type rejection struct {
+	code string
+	err  error
 }
 
 // NewClient Client builder.
-func NewClient(conn *websocket.Conn, server *Server, username string, id int) *Client {
-	return &Client{
-		conn:     conn,
-		server:   server,
-		event:    make(chan Event),
-		ID:       id,
-		Username: username,
+// the session ID identifies the Client among the other sessions of the same user,
+// the login ID the login that issued the user token.
+func NewClient(conn *websocket.Conn, server *Server, user entity.AuthenticatedUser, sessionID int) *Client {
+	c := &Client{
+		conn:        conn,
+		server:      server,
+		event:       make(chan Event),
+		limiter:     newTokenBucket(server.limits),
+		quit:        make(chan struct{}),
+		closed:      make(chan struct{}),
+		revoked:     make(chan struct{}),
+		ID:          user.GetId(),
+		Username:    user.GetUsername(),
+		SessionID:   sessionID,
+		LoginID:     user.GetLoginID(),
+		DisplayName: user.GetUsername(),
 	}
+	c.touch(time.Now())
+
+	return c
+}
+
+// room returns the chat room joined by the Client, zero if the Client didn't join a chat room.
+func (c *Client) room() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.RoomID
+}
+
+// setRoom changes the chat room joined by the Client.
+func (c *Client) setRoom(roomID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.RoomID = roomID
+}
+
+// leaveRoom removes the Client from the chat room, returns false if the Client already joined another chat room.
+func (c *Client) leaveRoom(roomID int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.RoomID != roomID {
+		return false
+	}
+
+	c.RoomID = 0
+
+	return true
+}
+
+// setProfile updates the display name and avatar sent with the Client messages and the status set by the user.
+func (c *Client) setProfile(profile *entity.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.DisplayName = profile.Name()
+	c.AvatarURL = profile.AvatarURL()
+	c.Status = profile.Status
+}
+
+// profile returns the display name and avatar sent with the Client messages.
+func (c *Client) profile() (displayName, avatarURL string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.DisplayName, c.AvatarURL
+}
+
+// status returns the status set by the user, empty when the status follows the user activity.
+func (c *Client) status() entity.UserStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.Status
+}
+
+// setBlocked replaces the users blocked by the user.
+func (c *Client) setBlocked(blocks []*entity.UserBlock) {
+	blocked := make(map[int]bool, len(blocks))
+	for _, b := range blocks {
+		blocked[b.BlockedID] = true
+	}
+
+	c.blockedMu.Lock()
+	defer c.blockedMu.Unlock()
+
+	c.blocked = blocked
+}
+
+// isBlocking checks if the user blocked the given user.
+func (c *Client) isBlocking(userID int) bool {
+	c.blockedMu.RLock()
+	defer c.blockedMu.RUnlock()
+
+	return c.blocked[userID]
+}
+
+// touch records the time of the last event sent by the Client.
+func (c *Client) touch(now time.Time) {
+	c.lastActive.Store(now.UnixNano())
+}
+
+// lastActivity returns the time of the last event sent by the Client.
+func (c *Client) lastActivity() time.Time {
+	return time.Unix(0, c.lastActive.Load())
+}
+
+// send queues the event to the Client, it doesn't block after the Client stopped writing.
+// the status changes are sent by the Start loop, which also disconnects the stopped Clients.
+func (c *Client) send(event Event) {
+	select {
+	case c.event <- event:
+	case <-c.closed:
+	}
+}
+
+// revoke asks the Client to close its revoked session, it's safe to call it more than once.
+func (c *Client) revoke() {
+	c.revokeOnce.Do(func() {
+		close(c.revoked)
+	})
 }
 
 // readMessages handle all events sent by a client and process it.
 // keeps a heartbeat connection to receive pong responses.
 func (c *Client) readMessages() {
-	defer func() {
-		c.server.leave <- c
-	}()
+	defer c.server.unregister(c)
 
 	logger := log.WithFields(log.Fields{
 		"UserID": c.ID,
@@ -69,22 +218,23 @@ func (c *Client) readMessages() {
 			continue
 		}
 
+		c.server.touchPresence(c, time.Now())
+
+		// the processed events and the errors are logged by the Logging middleware or by routeEvent
 		if err = c.server.routeEvent(event, c); err != nil {
-			logger.WithError(err).Error("failed to process event")
 			return
 		}
-
-		logger.WithField("event", event.Action).Info("event processed")
 	}
 }
 
 // writeMessages handle the events to send to client.
-// keeps a heartbeat connection to send ping requests.
+// keeps a heartbeat connection to send ping requests until the Server shutdown.
 func (c *Client) writeMessages() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
 		ticker.Stop()
-		c.server.leave <- c
+		close(c.closed)
+		c.server.unregister(c)
 	}()
 
 	logger := log.WithFields(log.Fields{
@@ -115,11 +265,144 @@ func (c *Client) writeMessages() {
 				return
 			}
 
+		case <-c.quit:
+			// the Client may reconnect to another chat-api instance
+			c.writeClose(ServerShutdownAction, websocket.CloseGoingAway, ErrShuttingDown)
+			return
+
+		case <-c.revoked:
+			c.writeClose(SessionRevokedAction, websocket.ClosePolicyViolation, ErrSessionRevoked)
+			return
 		}
 
 	}
 }
 
+// writeClose sends the event with the given action and the close frame with the given code and reason.
+func (c *Client) writeClose(action string, code int, reason error) {
+	logger := log.WithFields(log.Fields{
+		"UserID": c.ID,
+		"Action": action,
+	})
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+	b, err := json.Marshal(Event{
+		Action:  action,
+		Payload: json.RawMessage(`{}`),
+	})
+	if err != nil {
+		logger.WithError(err).Error("failed to encode event body")
+		return
+	}
+
+	if err = c.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+		logger.WithError(err).Error("failed to write close event")
+		return
+	}
+
+	msg := websocket.FormatCloseMessage(code, reason.Error())
+	if err = c.conn.WriteMessage(websocket.CloseMessage, msg); err != nil {
+		logger.WithError(err).Error("failed to write close frame")
+	}
+}
+
 func (c *Client) handlePong(_ string) error {
 	return c.conn.SetReadDeadline(time.Now().Add(pongWait))
 }
+
+// sendError sends an ErrorEvent to the Client reporting why the event was rejected.
+func (c *Client) sendError(action, code string, err error) error {
+	return c.sendErrorEvent(ErrorEvent{
+		Action:  action,
+		Code:    code,
+		Message: err.Error(),
+	}, err)
+}
+
+// sendRateLimited sends an ErrorEvent with the time to wait before the Client may retry the event.
+func (c *Client) sendRateLimited(action string, err error, retryAfter time.Duration) error {
+	return c.sendErrorEvent(ErrorEvent{
+		Action:       action,
+		Code:         RateLimitedCode,
+		Message:      err.Error(),
+		RetryAfterMs: retryAfter.Milliseconds(),
+	}, err)
+}
+
+func (c *Client) sendErrorEvent(e ErrorEvent, err error) error {
+	data, mErr := json.Marshal(e)
+	if mErr != nil {
+		return errors.Errorf("could not encode event payload: %v", mErr)
+	}
+
+	c.rejected = &rejection{code: e.Code, err: err}
+
+	c.send(Event{
+		Action:  ErrorAction,
+		Payload: data,
+	})
+
+	return nil
+}
+
+// reject sends an ErrorEvent to the Client for an event rejected by a business rule,
+// e.g. a user not allowed to access the chat room.
+// any other error, e.g. a DB error, is returned to the caller.
+func (c *Client) reject(action string, err error) error {
+	switch {
+	case errors.Is(err, entity.ErrForbidden):
+		return c.sendError(action, ForbiddenCode, entity.ErrForbidden)
+	case errors.Is(err, entity.ErrUserBanned):
+		return c.sendError(action, BannedCode, err)
+	case errors.Is(err, entity.ErrUserMuted):
+		return c.sendError(action, MutedCode, err)
+	case errors.Is(err, entity.ErrInvalidEntity),
+		errors.Is(err, entity.ErrMessageNotFound):
+		return c.sendError(action, InvalidRequestCode, err)
+	default:
+		return err
+	}
+}
+
+// checkCanPost rejects the event of a banned or muted user or to an archived chat room,
+// an ErrorEvent is sent back to the Client.
+//
+// returns false if the event was rejected.
+func (c *Client) checkCanPost(action string, room *entity.Room) (bool, error) {
+	if err := c.server.moderation.CheckCanPost(c.ID, room); err != nil {
+		return false, c.reject(action, err)
+	}
+
+	if room.IsArchived() {
+		return false, c.sendError(action, RoomArchivedCode, entity.ErrRoomArchived)
+	}
+
+	return true, nil
+}
+
+// claimSlowMode records the Client user message in the chat room slow mode, the event sent before the
+// interval elapsed is rejected with an ErrorEvent. The room owner and moderators don't wait.
+//
+// it's called right before the message is sent, returns false if the event was rejected.
+func (c *Client) claimSlowMode(action string, room *entity.Room) (bool, error) {
+	interval := room.MessagePolicy().SlowMode
+	if interval <= 0 {
+		return true, nil
+	}
+
+	wait := c.server.slowMode.tryRecord(room.ID, c.ID, interval, time.Now())
+	if wait == 0 {
+		return true, nil
+	}
+
+	err := c.server.roomUseCase.Authorize(c.ID, room, entity.PermissionBypassSlowMode)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, entity.ErrForbidden):
+		return false, c.sendRateLimited(action, entity.ErrSlowMode, wait)
+	default:
+		return false, err
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/moderation"
+
 	"github.com/apex/log"
 	"github.com/pkg/errors"
 )
@@ -28,33 +31,168 @@ const (
 	MessageReceivedAction    = "messageReceived"
 	JoinRoomAction           = "joinRoom"
 	SendChatbotCommandAction = "chatbotCommand"
+	// KickUserAction action to kick a user from the chat room joined by the moderator.
+	KickUserAction = "kickUser"
+	// BanUserAction action to ban a user from the chat room joined by the moderator.
+	BanUserAction = "banUser"
+	// MuteUserAction action to mute a user in the chat room joined by the moderator.
+	MuteUserAction = "muteUser"
+	// ReportMessageAction action to report a message to the chat room moderators.
+	ReportMessageAction = "reportMessage"
+	// MessageReportedAction action to confirm a message report to the reporter.
+	MessageReportedAction = "messageReported"
+	// ServerShutdownAction action to notify the Clients the Server is shutting down, a close frame follows it.
+	ServerShutdownAction = "serverShutdown"
+	// SessionRevokedAction action to notify the Client its session was revoked, a close frame follows it.
+	SessionRevokedAction = "sessionRevoked"
+	// ProfileUpdatedAction action to notify the chat room Clients a user updated its profile.
+	ProfileUpdatedAction = "profileUpdated"
+	// StatusUpdatedAction action to notify the chat room Clients the status of a user changed.
+	StatusUpdatedAction = "statusUpdated"
+	// MentionedAction action to notify the Client its user was mentioned in a chat room message.
+	MentionedAction = "mentioned"
+	// ErrorAction action to report a rejected event to a Client.
+	ErrorAction = "error"
+)
+
+const (
+	// InvalidMessageCode error code for a message rejected by the room message policy.
+	InvalidMessageCode = "invalidMessage"
+	// MessageNotSavedCode error code for a message that couldn't be queued to be persisted.
+	MessageNotSavedCode = "messageNotSaved"
+	// RoomArchivedCode error code for a message sent to an archived room.
+	RoomArchivedCode = "roomArchived"
+	// ForbiddenCode error code for an event the user role in the chat room doesn't allow.
+	ForbiddenCode = "forbidden"
+	// BannedCode error code for an event from a user banned from the chat room.
+	BannedCode = "banned"
+	// MutedCode error code for a message from a user muted in the chat room.
+	MutedCode = "muted"
+	// InvalidRequestCode error code for an event with an invalid payload, e.g. a negative sanction duration.
+	InvalidRequestCode = "invalidRequest"
+	// NotInRoomCode error code for an event that requires to join a chat room first.
+	NotInRoomCode = "notInRoom"
+	// MessageRejectedCode error code for a message rejected by a content filter.
+	MessageRejectedCode = "messageRejected"
+	// InternalErrorCode error code for an event that failed unexpectedly, e.g. a panic in its EventHandler.
+	InternalErrorCode = "internalError"
+	// RateLimitedCode error code for an event over the Client rate limit or a message sent during the room slow mode.
+	RateLimitedCode = "rateLimited"
 )
 
+// ErrorEvent represents an event rejected by the Server.
+//
+// RetryAfterMs is the time to wait before retrying a rate limited event.
+type ErrorEvent struct {
+	Action       string `json:"action"`
+	Code         string `json:"code"`
+	Message      string `json:"message"`
+	RetryAfterMs int64  `json:"retryAfterMs,omitempty"`
+}
+
 // MessageEvent represents a message sent or received by a user.
+//
+// Message holds the raw Markdown content and HTML its sanitized rendered form.
+//
+// the sender UserID, DisplayName and AvatarURL are set by the Server, the chatbot messages have no UserID.
 type MessageEvent struct {
-	Message string    `json:"message"`
-	From    string    `json:"from"`
-	Sent    time.Time `json:"sent"`
+	Message     string    `json:"message"`
+	HTML        string    `json:"html"`
+	From        string    `json:"from"`
+	UserID      int       `json:"userID,omitempty"`
+	DisplayName string    `json:"displayName"`
+	AvatarURL   string    `json:"avatarURL,omitempty"`
+	Sent        time.Time `json:"sent"`
+}
+
+// ProfileEvent represents the profile of a user updated, sent to the chat rooms the user is in.
+type ProfileEvent struct {
+	UserID      int    `json:"userID"`
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	AvatarURL   string `json:"avatarURL,omitempty"`
+	StatusText  string `json:"statusText,omitempty"`
+}
+
+// ModerationEvent represents a user kicked, banned, muted, unbanned or unmuted in a chat room.
+type ModerationEvent struct {
+	RoomID    int        `json:"roomID"`
+	UserID    int        `json:"userID"`
+	ActorID   int        `json:"actorID"`
+	Reason    string     `json:"reason,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// RoomEvent represents a chat room created, updated or deleted.
+// only the ID is sent for a deleted chat room.
+type RoomEvent struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Topic    string `json:"topic,omitempty"`
+	Archived bool   `json:"archived,omitempty"`
 }
 
 // SendMessageHandler handles the client message and send it to all client in the chat room.
 //
 // if the chat room doesn't exist the event will not be executed.
 //
-// stores the user message in the DB for the respective chat room.
+// the joined chat room and the user permission to post are checked by the event middlewares.
+//
+// if the user is banned or muted, sends before the slow mode interval elapsed, the chat room is archived,
+// the message is rejected by a content filter or doesn't follow the chat room message policy
+// an ErrorEvent is sent back to the Client.
+//
+// queues the user message to be stored in the DB for the respective chat room,
+// the users mentioned in the message are notified unless they are in dnd.
 func SendMessageHandler(event Event, c *Client) error {
-	if !c.server.isValidRoom(c.RoomID) {
+	room, ok := c.server.getRoom(c.room())
+	if !ok {
 		return ErrInvalidRoomID
 	}
 
+	if ok, err := c.checkCanPost(event.Action, room); !ok {
+		return err
+	}
+
+	policy := room.MessagePolicy()
+
 	var input MessageEvent
 	if err := json.Unmarshal(event.Payload, &input); err != nil {
 		return errors.Errorf("could not decode event payload: %v", err)
 	}
-	input.Sent = time.Now()
 
-	// error ignored to avoid disconnect a Client
-	go c.server.roomUseCase.CreateMessage(c.ID, c.RoomID, input.Message)
+	// the content filters may mask the message, the filtered content is persisted and broadcast
+	content, err := c.server.filters.Apply(room, input.Message)
+	if err != nil {
+		if errors.Is(err, entity.ErrMessageRejected) {
+			return c.sendError(event.Action, MessageRejectedCode, err)
+		}
+		return err
+	}
+	input.Message = content
+
+	msg, err := entity.NewMessage(c.ID, room.ID, input.Message, policy)
+	if err != nil {
+		return c.sendError(event.Action, InvalidMessageCode, err)
+	}
+
+	if ok, err := c.claimSlowMode(event.Action, room); !ok {
+		return err
+	}
+
+	// a message that can't be persisted isn't broadcast nor counted by the slow mode
+	if err = c.server.messages.Enqueue(msg); err != nil {
+		if policy.SlowMode > 0 {
+			c.server.slowMode.forget(room.ID, c.ID)
+		}
+		return c.sendError(event.Action, MessageNotSavedCode, err)
+	}
+
+	input.HTML = msg.ContentHTML
+	input.From = c.Username
+	input.UserID = c.ID
+	input.DisplayName, input.AvatarURL = c.profile()
+	input.Sent = time.Now()
 
 	data, err := json.Marshal(input)
 	if err != nil {
@@ -66,13 +204,15 @@ func SendMessageHandler(event Event, c *Client) error {
 		Payload: data,
 	}
 
-	// broadcast event to all clients in the same chat room
-	for client := range c.server.clients {
-		if client.RoomID == c.RoomID {
-			client.event <- output
+	// broadcast event to all clients in the same chat room, except the users who blocked the sender
+	for _, client := range c.server.roomClients(room.ID) {
+		if !client.isBlocking(c.ID) {
+			client.send(output)
 		}
 	}
 
+	c.server.notifyMentions(c, room, input)
+
 	return nil
 }
 
@@ -81,23 +221,37 @@ type JoinRoomEvent struct {
 }
 
 // ChatRoomHandler if the rooms exist will allow the user to join the chat room.
+//
+// a private chat room is only joined by its members and a banned user can't join the chat room,
+// an ErrorEvent is sent back to any other Client.
 func ChatRoomHandler(event Event, c *Client) error {
 	var joinRoomEvent JoinRoomEvent
 	if err := json.Unmarshal(event.Payload, &joinRoomEvent); err != nil {
 		return errors.Errorf("could not decode event payload: %v", err)
 	}
 
-	if !c.server.isValidRoom(joinRoomEvent.RoomID) {
+	room, ok := c.server.getRoom(joinRoomEvent.RoomID)
+	if !ok {
 		return ErrInvalidRoomID
 	}
 
-	c.RoomID = joinRoomEvent.RoomID
+	if err := c.server.roomUseCase.CheckAccess(c.ID, room); err != nil {
+		return c.reject(event.Action, err)
+	}
+
+	if err := c.server.moderation.CheckCanJoin(c.ID, room); err != nil {
+		return c.reject(event.Action, err)
+	}
+
+	c.setRoom(room.ID)
 
 	log.WithFields(log.Fields{
 		"UserID": c.ID,
-		"RoomID": c.RoomID,
+		"RoomID": room.ID,
 	}).Info("user joined room")
 
+	c.server.announcePresence(c)
+
 	return nil
 }
 
@@ -109,6 +263,14 @@ type ChatbotCommandEvent struct {
 	Command     string `json:"command"`
 }
 
+// ChatbotCommandHandler sends the command to the chatbot.
+//
+// the command is only accepted for the chat room joined by the Client, the chatbot answer
+// must not reach a private chat room the user isn't a member of.
+//
+// the chatbot answer is posted to the chat room, the command is rejected like a message
+// of a banned or muted user, sent before the slow mode interval elapsed, to an archived chat room
+// or rejected by a content filter.
 func ChatbotCommandHandler(event Event, c *Client) error {
 	var chatbotEvent ChatbotCommandEvent
 	// decode the event payload to validate the schema
@@ -116,8 +278,125 @@ func ChatbotCommandHandler(event Event, c *Client) error {
 		return errors.Errorf("could not decode event payload: %v", err)
 	}
 
+	if chatbotEvent.RoomID != c.room() {
+		return c.sendError(event.Action, ForbiddenCode, entity.ErrForbidden)
+	}
+
+	room, ok := c.server.getRoom(chatbotEvent.RoomID)
+	if !ok {
+		return ErrInvalidRoomID
+	}
+
+	if ok, err := c.checkCanPost(event.Action, room); !ok {
+		return err
+	}
+
+	command, err := c.server.filters.Apply(room, chatbotEvent.Command)
+	if err != nil {
+		if errors.Is(err, entity.ErrMessageRejected) {
+			return c.sendError(event.Action, MessageRejectedCode, err)
+		}
+		return err
+	}
+	chatbotEvent.Command = command
+
+	payload, err := json.Marshal(chatbotEvent)
+	if err != nil {
+		return errors.Errorf("could not encode event payload: %v", err)
+	}
+
+	if ok, err := c.claimSlowMode(event.Action, room); !ok {
+		return err
+	}
+
 	// error ignored to avoid disconnect a Client
-	go c.server.broker.WriteMessage(context.Background(), event.Payload)
+	go c.server.broker.WriteMessage(context.Background(), payload)
+
+	return nil
+}
+
+// ModerationActionEvent moderation action received from a Client, the zero DurationSeconds
+// ban or mute never expires.
+type ModerationActionEvent struct {
+	UserID          int    `json:"userID"`
+	Reason          string `json:"reason"`
+	DurationSeconds int    `json:"durationSeconds"`
+}
+
+// ModerationHandler kicks, bans or mutes a user in the chat room joined by the moderator.
+//
+// the moderation action is announced to the chat room by the moderation event sent to every chat-api instance.
+func ModerationHandler(event Event, c *Client) error {
+	var input ModerationActionEvent
+	if err := json.Unmarshal(event.Payload, &input); err != nil {
+		return errors.Errorf("could not decode event payload: %v", err)
+	}
+
+	sanction := moderation.SanctionInput{
+		UserID:   input.UserID,
+		Reason:   input.Reason,
+		Duration: time.Duration(input.DurationSeconds) * time.Second,
+	}
+
+	var err error
+	switch event.Action {
+	case KickUserAction:
+		err = c.server.moderation.Kick(c.ID, c.room(), sanction)
+	case BanUserAction:
+		err = c.server.moderation.Ban(c.ID, c.room(), sanction)
+	case MuteUserAction:
+		err = c.server.moderation.Mute(c.ID, c.room(), sanction)
+	default:
+		return ErrInvalidEventAction
+	}
+
+	if err != nil {
+		return c.reject(event.Action, err)
+	}
+
+	return nil
+}
+
+// ReportMessageEvent message report received from a Client.
+type ReportMessageEvent struct {
+	MessageID int    `json:"messageID"`
+	Reason    string `json:"reason"`
+}
+
+// MessageReportedEvent confirms a message report to the reporter.
+type MessageReportedEvent struct {
+	ID        int `json:"id"`
+	MessageID int `json:"messageID"`
+}
+
+// ReportMessageHandler reports a message to the chat room moderators, the report is confirmed to the Client only.
+//
+// the message may be in any chat room the user has access to, not only the joined one.
+// a message broadcast with the messageReceived action has no ID until it's stored, only the messages listed
+// by the chat-api can be reported.
+func ReportMessageHandler(event Event, c *Client) error {
+	var input ReportMessageEvent
+	if err := json.Unmarshal(event.Payload, &input); err != nil {
+		return errors.Errorf("could not decode event payload: %v", err)
+	}
+
+	id, err := c.server.reports.ReportMessage(c.ID, input.MessageID, input.Reason)
+	if err != nil {
+		return c.reject(event.Action, err)
+	}
+
+	data, err := json.Marshal(MessageReportedEvent{
+		ID:        id,
+		MessageID: input.MessageID,
+	})
+	if err != nil {
+		return errors.Errorf("could not encode event payload: %v", err)
+	}
+
+	c.send(Event{
+		Action:  MessageReportedAction,
+		Payload: data,
+	})
 
 	return nil
 }
@@ -2,15 +2,22 @@ package websocket
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
-	brokerMock "github.com/vsantosalmeida/browser-chat/api/websocket/mocks"
+	wsMock "github.com/vsantosalmeida/browser-chat/api/websocket/mocks"
 	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/filter"
+	"github.com/vsantosalmeida/browser-chat/usecase/moderation"
+	moderationMock "github.com/vsantosalmeida/browser-chat/usecase/moderation/mocks"
+	"github.com/vsantosalmeida/browser-chat/usecase/report"
+	reportMock "github.com/vsantosalmeida/browser-chat/usecase/report/mocks"
 	"github.com/vsantosalmeida/browser-chat/usecase/room"
 	roomMock "github.com/vsantosalmeida/browser-chat/usecase/room/mocks"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/undefinedlabs/go-mpatch"
 )
 
@@ -24,17 +31,19 @@ var (
 
 func TestSendMessageHandler(t *testing.T) {
 	var (
-		eventInputRaw  = `{"message":"hello world!","from":"user"}`
-		eventOutputRaw = `{"message":"hello world!","from":"user","sent":"2020-01-01T00:00:00Z"}`
+		// the sender is set by the Server, not by the Client payload
+		eventInputRaw  = `{"message":"hello world!","from":"someone else"}`
+		eventOutputRaw = `{"message":"hello world!","html":"hello world!","from":"user","userID":10,"displayName":"User","avatarURL":"/avatars/10-a.png","sent":"2020-01-01T00:00:00Z"}`
 		event          = Event{
 			Action:  SendMessageAction,
 			Payload: []byte(eventInputRaw),
 		}
 
 		msg = &entity.Message{
-			UserID:  10,
-			RoomID:  1,
-			Content: "hello world!",
+			UserID:      10,
+			RoomID:      1,
+			Content:     "hello world!",
+			ContentHTML: "hello world!",
 		}
 
 		expected = Event{
@@ -44,21 +53,26 @@ func TestSendMessageHandler(t *testing.T) {
 	)
 
 	eventCH := make(chan Event, 1)
-	roomRepo := roomMock.NewRepository(t)
-	roomUseCase := room.NewService(roomRepo)
+	messages := wsMock.NewMessageQueue(t)
 
 	s := &Server{
 		handlers:    initEventHandlers(),
-		rooms:       rooms,
-		roomUseCase: roomUseCase,
+		rooms:       newRoomCache(nil, rooms...),
+		roomUseCase: room.NewService(nil, nil),
+		moderation:  newModerationService(t),
+		messages:    messages,
+		filters:     filter.NewService(),
 		clients:     make(map[*Client]bool),
 	}
 
 	c := &Client{
-		server: s,
-		event:  eventCH,
-		ID:     10,
-		RoomID: 1,
+		server:      s,
+		event:       eventCH,
+		ID:          10,
+		Username:    "user",
+		DisplayName: "User",
+		AvatarURL:   "/avatars/10-a.png",
+		RoomID:      1,
 	}
 
 	s.joinClient(c)
@@ -70,10 +84,10 @@ func TestSendMessageHandler(t *testing.T) {
 	assert.NoError(t, err)
 	defer timePatch.Unpatch()
 
-	roomRepo.
-		On("CreateMessage", msg).
+	messages.
+		On("Enqueue", msg).
 		Return(nil).
-		Maybe()
+		Once()
 
 	err = SendMessageHandler(event, c)
 	assert.NoError(t, err)
@@ -82,6 +96,291 @@ func TestSendMessageHandler(t *testing.T) {
 	assert.Equal(t, expected, got)
 }
 
+func TestSendMessageHandlerInvalidMessage(t *testing.T) {
+	var tt = []struct {
+		name           string
+		eventInputRaw  string
+		eventOutputRaw string
+		roomID         int
+	}{
+		{
+			name:           "When message is empty; should send an error event",
+			eventInputRaw:  `{"message":"  ","from":"user"}`,
+			eventOutputRaw: `{"action":"sendMessage","code":"invalidMessage","message":"empty message"}`,
+		},
+		{
+			name:           "When message has control characters; should send an error event",
+			eventInputRaw:  `{"message":"hello\u0007","from":"user"}`,
+			eventOutputRaw: `{"action":"sendMessage","code":"invalidMessage","message":"invalid message content"}`,
+		},
+		{
+			name:           "When room is archived; should send an error event",
+			eventInputRaw:  `{"message":"hi","from":"user"}`,
+			eventOutputRaw: `{"action":"sendMessage","code":"roomArchived","message":"room archived"}`,
+			roomID:         2,
+		},
+		{
+			name:           "When the user isn't a member of the private room; should send an error event",
+			eventInputRaw:  `{"message":"hi","from":"user"}`,
+			eventOutputRaw: `{"action":"sendMessage","code":"forbidden","message":"forbidden"}`,
+			roomID:         3,
+		},
+		{
+			name:           "When message exceeds the room limit; should send an error event",
+			eventInputRaw:  `{"message":"hello world!","from":"user"}`,
+			eventOutputRaw: `{"action":"sendMessage","code":"invalidMessage","message":"message too long"}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				event = Event{
+					Action:  SendMessageAction,
+					Payload: []byte(tc.eventInputRaw),
+				}
+
+				expected = Event{
+					Action:  ErrorAction,
+					Payload: []byte(tc.eventOutputRaw),
+				}
+			)
+
+			eventCH := make(chan Event, 1)
+			roomRepo := roomMock.NewRepository(t)
+
+			s := &Server{
+				handlers: initEventHandlers(),
+				rooms: newRoomCache(
+					nil,
+					&entity.Room{
+						ID:               1,
+						MaxMessageLength: 5,
+					},
+					&entity.Room{
+						ID:         2,
+						ArchivedAt: &time.Time{},
+					},
+					&entity.Room{
+						ID:      3,
+						Private: true,
+					},
+				),
+				roomUseCase: room.NewService(roomRepo, nil),
+				moderation:  newModerationService(t),
+				filters:     filter.NewService(),
+				clients:     make(map[*Client]bool),
+			}
+
+			roomID := tc.roomID
+			if roomID == 0 {
+				roomID = 1
+			}
+
+			if roomID == 3 {
+				roomRepo.
+					On("FindMember", 3, 10).
+					Return(nil, entity.ErrMemberNotFound).
+					Once()
+			}
+
+			c := &Client{
+				server: s,
+				event:  eventCH,
+				ID:     10,
+				RoomID: roomID,
+			}
+
+			s.joinClient(c)
+
+			// the private room membership is checked by the action middlewares
+			err := s.handlers[SendMessageAction](event, c)
+			assert.NoError(t, err)
+
+			got := <-eventCH
+			assert.Equal(t, expected, got)
+		})
+	}
+}
+
+func TestSendMessageHandlerQueueFull(t *testing.T) {
+	var (
+		eventInputRaw  = `{"message":"hello world!","from":"user"}`
+		eventOutputRaw = `{"action":"sendMessage","code":"messageNotSaved","message":"message queue is full"}`
+		event          = Event{
+			Action:  SendMessageAction,
+			Payload: []byte(eventInputRaw),
+		}
+
+		msg = &entity.Message{
+			UserID:      10,
+			RoomID:      1,
+			Content:     "hello world!",
+			ContentHTML: "hello world!",
+		}
+
+		expected = Event{
+			Action:  ErrorAction,
+			Payload: []byte(eventOutputRaw),
+		}
+	)
+
+	eventCH := make(chan Event, 1)
+	messages := wsMock.NewMessageQueue(t)
+
+	s := &Server{
+		handlers:    initEventHandlers(),
+		rooms:       newRoomCache(nil, rooms...),
+		roomUseCase: room.NewService(nil, nil),
+		moderation:  newModerationService(t),
+		messages:    messages,
+		filters:     filter.NewService(),
+		clients:     make(map[*Client]bool),
+	}
+
+	c := &Client{
+		server: s,
+		event:  eventCH,
+		ID:     10,
+		RoomID: 1,
+	}
+
+	s.joinClient(c)
+
+	messages.
+		On("Enqueue", msg).
+		Return(room.ErrQueueFull).
+		Once()
+
+	err := SendMessageHandler(event, c)
+	assert.NoError(t, err)
+
+	got := <-eventCH
+	assert.Equal(t, expected, got)
+}
+
+func TestSendMessageHandlerBlocked(t *testing.T) {
+	var (
+		eventInputRaw = `{"message":"hello world!"}`
+		event         = Event{
+			Action:  SendMessageAction,
+			Payload: []byte(eventInputRaw),
+		}
+
+		msg = &entity.Message{
+			UserID:      10,
+			RoomID:      1,
+			Content:     "hello world!",
+			ContentHTML: "hello world!",
+		}
+	)
+
+	messages := wsMock.NewMessageQueue(t)
+
+	s := &Server{
+		handlers:    initEventHandlers(),
+		rooms:       newRoomCache(nil, rooms...),
+		roomUseCase: room.NewService(nil, nil),
+		moderation:  newModerationService(t),
+		messages:    messages,
+		filters:     filter.NewService(),
+		clients:     make(map[*Client]bool),
+	}
+
+	sender := &Client{server: s, event: make(chan Event, 1), ID: 10, Username: "user", RoomID: 1}
+	blocking := &Client{server: s, event: make(chan Event, 1), ID: 11, RoomID: 1}
+	other := &Client{server: s, event: make(chan Event, 1), ID: 12, RoomID: 1}
+
+	blocking.setBlocked([]*entity.UserBlock{{UserID: 11, BlockedID: 10}})
+
+	for _, c := range []*Client{sender, blocking, other} {
+		s.joinClient(c)
+	}
+
+	messages.
+		On("Enqueue", msg).
+		Return(nil).
+		Once()
+
+	err := SendMessageHandler(event, sender)
+	assert.NoError(t, err)
+
+	assert.Equal(t, MessageReceivedAction, (<-sender.event).Action)
+	assert.Equal(t, MessageReceivedAction, (<-other.event).Action)
+	// the message of a blocked user isn't sent to the user
+	assert.Empty(t, blocking.event)
+}
+
+func TestSendMessageHandlerMentions(t *testing.T) {
+	var (
+		event = Event{
+			Action:  SendMessageAction,
+			Payload: []byte(`{"message":"hi @Mentioned, @dnd and @blocking"}`),
+		}
+
+		msg = &entity.Message{
+			UserID:      10,
+			RoomID:      1,
+			Content:     "hi @Mentioned, @dnd and @blocking",
+			ContentHTML: "hi @Mentioned, @dnd and @blocking",
+		}
+	)
+
+	messages := wsMock.NewMessageQueue(t)
+
+	s := &Server{
+		handlers:    initEventHandlers(),
+		rooms:       newRoomCache(nil, rooms...),
+		roomUseCase: room.NewService(nil, nil),
+		moderation:  newModerationService(t),
+		messages:    messages,
+		filters:     filter.NewService(),
+		clients:     make(map[*Client]bool),
+	}
+
+	sender := &Client{server: s, event: make(chan Event, 1), ID: 10, Username: "user", RoomID: 1}
+	// the mentioned user is notified on every session, in any chat room
+	mentioned := &Client{server: s, event: make(chan Event, 1), ID: 11, Username: "mentioned", RoomID: 2}
+	dnd := &Client{server: s, event: make(chan Event, 2), ID: 12, Username: "dnd", RoomID: 1, Status: entity.UserStatusDND}
+	blocking := &Client{server: s, event: make(chan Event, 1), ID: 13, Username: "blocking", RoomID: 2}
+
+	blocking.setBlocked([]*entity.UserBlock{{UserID: 13, BlockedID: 10}})
+
+	for _, c := range []*Client{sender, mentioned, dnd, blocking} {
+		s.joinClient(c)
+	}
+
+	messages.
+		On("Enqueue", msg).
+		Return(nil).
+		Once()
+
+	err := SendMessageHandler(event, sender)
+	assert.NoError(t, err)
+
+	assert.Equal(t, MessageReceivedAction, (<-sender.event).Action)
+	assert.Equal(t, MessageReceivedAction, (<-dnd.event).Action)
+
+	notification := <-mentioned.event
+	assert.Equal(t, MentionedAction, notification.Action)
+
+	var mention MentionEvent
+	assert.NoError(t, json.Unmarshal(notification.Payload, &mention))
+	assert.Equal(t, 1, mention.RoomID)
+	assert.Equal(t, "hi @Mentioned, @dnd and @blocking", mention.Message.Message)
+	assert.Equal(t, 10, mention.Message.UserID)
+
+	// a user in dnd or blocking the sender isn't notified
+	assert.Empty(t, dnd.event)
+	assert.Empty(t, blocking.event)
+	assert.Empty(t, sender.event)
+}
+
+func TestMentions(t *testing.T) {
+	assert.Equal(t, map[string]bool{"user": true, "other.user": true}, mentions("@User hi, @other.user! mail@example.com @"))
+	assert.Empty(t, mentions("no mentions"))
+}
+
 func TestChatRoomHandler(t *testing.T) {
 	var (
 		eventInputRaw = `{"roomID":1}`
@@ -92,21 +391,72 @@ func TestChatRoomHandler(t *testing.T) {
 	)
 
 	s := &Server{
-		handlers: initEventHandlers(),
-		rooms:    rooms,
-		clients:  make(map[*Client]bool),
+		handlers:    initEventHandlers(),
+		rooms:       newRoomCache(nil, rooms...),
+		roomUseCase: room.NewService(nil, nil),
+		moderation:  newModerationService(t),
+		clients:     make(map[*Client]bool),
+	}
+
+	c := &Client{
+		server: s,
+		ID:     10,
+		RoomID: 1,
+	}
+
+	s.joinClient(c)
+
+	err := ChatRoomHandler(event, c)
+	assert.NoError(t, err)
+}
+
+func TestChatRoomHandlerPrivateRoom(t *testing.T) {
+	var (
+		eventInputRaw  = `{"roomID":3}`
+		eventOutputRaw = `{"action":"joinRoom","code":"forbidden","message":"forbidden"}`
+		event          = Event{
+			Action:  JoinRoomAction,
+			Payload: []byte(eventInputRaw),
+		}
+
+		expected = Event{
+			Action:  ErrorAction,
+			Payload: []byte(eventOutputRaw),
+		}
+	)
+
+	eventCH := make(chan Event, 1)
+	roomRepo := roomMock.NewRepository(t)
+
+	s := &Server{
+		handlers:    initEventHandlers(),
+		rooms:       newRoomCache(nil, &entity.Room{ID: 3, Private: true}),
+		roomUseCase: room.NewService(roomRepo, nil),
+		moderation:  newModerationService(t),
+		clients:     make(map[*Client]bool),
 	}
 
 	c := &Client{
 		server: s,
+		event:  eventCH,
 		ID:     10,
 		RoomID: 1,
 	}
 
 	s.joinClient(c)
 
+	roomRepo.
+		On("FindMember", 3, 10).
+		Return(nil, entity.ErrMemberNotFound).
+		Once()
+
 	err := ChatRoomHandler(event, c)
 	assert.NoError(t, err)
+
+	got := <-eventCH
+	assert.Equal(t, expected, got)
+	// the user stays in the previous chat room
+	assert.Equal(t, 1, c.RoomID)
 }
 
 func TestChatbotCommandHandler(t *testing.T) {
@@ -118,13 +468,15 @@ func TestChatbotCommandHandler(t *testing.T) {
 		}
 	)
 
-	broker := brokerMock.NewBroker(t)
+	broker := wsMock.NewBroker(t)
 
 	s := &Server{
-		handlers: initEventHandlers(),
-		rooms:    rooms,
-		clients:  make(map[*Client]bool),
-		broker:   broker,
+		handlers:   initEventHandlers(),
+		rooms:      newRoomCache(nil, rooms...),
+		moderation: newModerationService(t),
+		filters:    filter.NewService(),
+		clients:    make(map[*Client]bool),
+		broker:     broker,
 	}
 
 	c := &Client{
@@ -143,3 +495,469 @@ func TestChatbotCommandHandler(t *testing.T) {
 	err := ChatbotCommandHandler(event, c)
 	assert.NoError(t, err)
 }
+
+func TestChatbotCommandHandlerArchivedRoom(t *testing.T) {
+	var (
+		eventOutputRaw = `{"action":"chatbotCommand","code":"roomArchived","message":"room archived"}`
+		event          = Event{
+			Action:  SendChatbotCommandAction,
+			Payload: []byte(`{"roomID":2,"from":"user","commandName":"stock","command":"amzn.us"}`),
+		}
+
+		expected = Event{
+			Action:  ErrorAction,
+			Payload: []byte(eventOutputRaw),
+		}
+	)
+
+	eventCH := make(chan Event, 1)
+
+	// the broker mock fails the test if the command is sent to the chatbot
+	s := &Server{
+		handlers:   initEventHandlers(),
+		rooms:      newRoomCache(nil, &entity.Room{ID: 2, ArchivedAt: &time.Time{}}),
+		moderation: newModerationService(t),
+		filters:    filter.NewService(),
+		clients:    make(map[*Client]bool),
+		broker:     wsMock.NewBroker(t),
+	}
+
+	c := &Client{
+		server: s,
+		event:  eventCH,
+		ID:     10,
+		RoomID: 2,
+	}
+
+	s.joinClient(c)
+
+	err := ChatbotCommandHandler(event, c)
+	assert.NoError(t, err)
+
+	assert.Equal(t, expected, <-eventCH)
+}
+
+// newModerationService moderation service without any active sanction.
+func newModerationService(t *testing.T) *moderation.Service {
+	moderationRepo := moderationMock.NewRepository(t)
+
+	moderationRepo.
+		On("ListActiveSanctions", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).
+		Return(nil, nil).
+		Maybe()
+
+	return moderation.NewService(moderationRepo, nil, nil)
+}
+
+func TestSendMessageHandlerMuted(t *testing.T) {
+	var (
+		expiresAt      = time.Date(2020, 1, 1, 0, 10, 0, 0, time.UTC)
+		eventInputRaw  = `{"message":"hello world!","from":"user"}`
+		eventOutputRaw = `{"action":"sendMessage","code":"muted","message":"could not post message until 2020-01-01T00:10:00Z: user muted in the room"}`
+		event          = Event{
+			Action:  SendMessageAction,
+			Payload: []byte(eventInputRaw),
+		}
+
+		expected = Event{
+			Action:  ErrorAction,
+			Payload: []byte(eventOutputRaw),
+		}
+	)
+
+	eventCH := make(chan Event, 1)
+	moderationRepo := moderationMock.NewRepository(t)
+
+	s := &Server{
+		handlers:    initEventHandlers(),
+		rooms:       newRoomCache(nil, rooms...),
+		roomUseCase: room.NewService(nil, nil),
+		moderation:  moderation.NewService(moderationRepo, nil, nil),
+		clients:     make(map[*Client]bool),
+	}
+
+	c := &Client{
+		server: s,
+		event:  eventCH,
+		ID:     10,
+		RoomID: 1,
+	}
+
+	s.joinClient(c)
+
+	moderationRepo.
+		On("ListActiveSanctions", 1, 10, mock.AnythingOfType("time.Time")).
+		Return([]*entity.RoomSanction{
+			{
+				RoomID:    1,
+				UserID:    10,
+				Type:      entity.SanctionMute,
+				ExpiresAt: &expiresAt,
+			},
+		}, nil).
+		Once()
+
+	err := SendMessageHandler(event, c)
+	assert.NoError(t, err)
+
+	got := <-eventCH
+	assert.Equal(t, expected, got)
+}
+
+func TestSendMessageHandlerNotInRoom(t *testing.T) {
+	var (
+		eventOutputRaw = `{"action":"sendMessage","code":"notInRoom","message":"invalid room id"}`
+		event          = Event{
+			Action:  SendMessageAction,
+			Payload: []byte(`{"message":"hello world!","from":"user"}`),
+		}
+
+		expected = Event{
+			Action:  ErrorAction,
+			Payload: []byte(eventOutputRaw),
+		}
+	)
+
+	eventCH := make(chan Event, 1)
+
+	s := &Server{
+		handlers: initEventHandlers(),
+		rooms:    newRoomCache(nil, rooms...),
+		clients:  make(map[*Client]bool),
+	}
+
+	// the Client was kicked from the chat room
+	c := &Client{
+		server: s,
+		event:  eventCH,
+		ID:     10,
+	}
+
+	s.joinClient(c)
+
+	// the joined room is checked by the action middlewares
+	err := s.handlers[SendMessageAction](event, c)
+	assert.NoError(t, err)
+
+	got := <-eventCH
+	assert.Equal(t, expected, got)
+}
+
+func TestChatRoomHandlerBanned(t *testing.T) {
+	var (
+		eventOutputRaw = `{"action":"joinRoom","code":"banned","message":"could not join room: user banned from the room"}`
+		event          = Event{
+			Action:  JoinRoomAction,
+			Payload: []byte(`{"roomID":1}`),
+		}
+
+		expected = Event{
+			Action:  ErrorAction,
+			Payload: []byte(eventOutputRaw),
+		}
+	)
+
+	eventCH := make(chan Event, 1)
+	moderationRepo := moderationMock.NewRepository(t)
+
+	s := &Server{
+		handlers:    initEventHandlers(),
+		rooms:       newRoomCache(nil, rooms...),
+		roomUseCase: room.NewService(nil, nil),
+		moderation:  moderation.NewService(moderationRepo, nil, nil),
+		clients:     make(map[*Client]bool),
+	}
+
+	c := &Client{
+		server: s,
+		event:  eventCH,
+		ID:     10,
+	}
+
+	s.joinClient(c)
+
+	moderationRepo.
+		On("ListActiveSanctions", 1, 10, mock.AnythingOfType("time.Time")).
+		Return([]*entity.RoomSanction{
+			{
+				RoomID: 1,
+				UserID: 10,
+				Type:   entity.SanctionBan,
+			},
+		}, nil).
+		Once()
+
+	err := ChatRoomHandler(event, c)
+	assert.NoError(t, err)
+
+	got := <-eventCH
+	assert.Equal(t, expected, got)
+	assert.Zero(t, c.RoomID)
+}
+
+func TestModerationHandlerForbidden(t *testing.T) {
+	var (
+		eventOutputRaw = `{"action":"kickUser","code":"forbidden","message":"forbidden"}`
+		event          = Event{
+			Action:  KickUserAction,
+			Payload: []byte(`{"userID":11,"reason":"spam"}`),
+		}
+
+		expected = Event{
+			Action:  ErrorAction,
+			Payload: []byte(eventOutputRaw),
+		}
+	)
+
+	eventCH := make(chan Event, 1)
+	roomRepo := roomMock.NewRepository(t)
+	roomSvc := room.NewService(roomRepo, nil)
+
+	s := &Server{
+		handlers:    initEventHandlers(),
+		rooms:       newRoomCache(nil, rooms...),
+		roomUseCase: roomSvc,
+		moderation:  moderation.NewService(moderationMock.NewRepository(t), roomSvc, nil),
+		clients:     make(map[*Client]bool),
+	}
+
+	c := &Client{
+		server: s,
+		event:  eventCH,
+		ID:     10,
+		RoomID: 1,
+	}
+
+	s.joinClient(c)
+
+	roomRepo.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, CreatorID: 2}, nil).
+		Once()
+
+	// a member isn't allowed to kick
+	roomRepo.
+		On("FindMember", 1, 10).
+		Return(nil, entity.ErrMemberNotFound).
+		Once()
+
+	err := s.routeEvent(event, c)
+	assert.NoError(t, err)
+
+	got := <-eventCH
+	assert.Equal(t, expected, got)
+}
+
+func TestSendMessageHandlerSlowMode(t *testing.T) {
+	var (
+		eventOutputRaw = `{"action":"sendMessage","code":"rateLimited","message":"slow mode is enabled, wait before sending another message","retryAfterMs":20000}`
+		event          = Event{
+			Action:  SendMessageAction,
+			Payload: []byte(`{"message":"hello world!","from":"user"}`),
+		}
+
+		msg = &entity.Message{
+			UserID:      10,
+			RoomID:      1,
+			Content:     "hello world!",
+			ContentHTML: "hello world!",
+		}
+
+		expected = Event{
+			Action:  ErrorAction,
+			Payload: []byte(eventOutputRaw),
+		}
+	)
+
+	eventCH := make(chan Event, 1)
+	messages := wsMock.NewMessageQueue(t)
+	roomRepo := roomMock.NewRepository(t)
+
+	s := &Server{
+		handlers: initEventHandlers(),
+		rooms: newRoomCache(nil, &entity.Room{
+			ID:              1,
+			CreatorID:       1,
+			SlowModeSeconds: 30,
+		}),
+		roomUseCase: room.NewService(roomRepo, nil),
+		moderation:  newModerationService(t),
+		messages:    messages,
+		filters:     filter.NewService(),
+		slowMode:    newSlowModeTracker(),
+		clients:     make(map[*Client]bool),
+	}
+
+	c := &Client{
+		server: s,
+		event:  eventCH,
+		ID:     10,
+		RoomID: 1,
+	}
+
+	s.joinClient(c)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+		return now
+	})
+	assert.NoError(t, err)
+	defer timePatch.Unpatch()
+
+	messages.
+		On("Enqueue", msg).
+		Return(nil).
+		Once()
+
+	// a member can't bypass the slow mode
+	roomRepo.
+		On("FindMember", 1, 10).
+		Return(&entity.RoomMember{RoomID: 1, UserID: 10, Role: entity.RoleMember}, nil).
+		Once()
+
+	err = SendMessageHandler(event, c)
+	assert.NoError(t, err)
+	assert.Equal(t, MessageReceivedAction, (<-eventCH).Action)
+
+	now = now.Add(10 * time.Second)
+
+	err = SendMessageHandler(event, c)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, <-eventCH)
+}
+
+func TestSendMessageHandlerFiltered(t *testing.T) {
+	var tt = []struct {
+		name          string
+		eventInputRaw string
+		mockMsg       *entity.Message
+		expected      Event
+	}{
+		{
+			name:          "When message has a profanity; should broadcast the masked message",
+			eventInputRaw: `{"message":"oh heck","from":"user"}`,
+			mockMsg: &entity.Message{
+				UserID:      10,
+				RoomID:      1,
+				Content:     "oh ****",
+				ContentHTML: "oh ****",
+			},
+			expected: Event{
+				Action:  MessageReceivedAction,
+				Payload: []byte(`{"message":"oh ****","html":"oh ****","from":"user","userID":10,"displayName":"user","sent":"2020-01-01T00:00:00Z"}`),
+			},
+		},
+		{
+			name:          "When message has a denied link; should send an error event",
+			eventInputRaw: `{"message":"see https://spam.com","from":"user"}`,
+			expected: Event{
+				Action:  ErrorAction,
+				Payload: []byte(`{"action":"sendMessage","code":"messageRejected","message":"link to spam.com is not allowed: message rejected by content filter"}`),
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			eventCH := make(chan Event, 1)
+			messages := wsMock.NewMessageQueue(t)
+
+			s := &Server{
+				handlers:    initEventHandlers(),
+				rooms:       newRoomCache(nil, rooms...),
+				roomUseCase: room.NewService(nil, nil),
+				moderation:  newModerationService(t),
+				messages:    messages,
+				filters: filter.NewService(
+					filter.NewLinkFilter(nil, []string{"spam.com"}),
+					filter.NewProfanityFilter([]string{"heck"}),
+				),
+				clients: make(map[*Client]bool),
+			}
+
+			c := &Client{
+				server:      s,
+				event:       eventCH,
+				ID:          10,
+				Username:    "user",
+				DisplayName: "user",
+				RoomID:      1,
+			}
+
+			s.joinClient(c)
+
+			// bypass time.Now function to set a static date for sent time
+			timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+				return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			})
+			assert.NoError(t, err)
+			defer timePatch.Unpatch()
+
+			if tc.mockMsg != nil {
+				messages.
+					On("Enqueue", tc.mockMsg).
+					Return(nil).
+					Once()
+			}
+
+			err = SendMessageHandler(Event{Action: SendMessageAction, Payload: []byte(tc.eventInputRaw)}, c)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expected, <-eventCH)
+		})
+	}
+}
+
+func TestReportMessageHandler(t *testing.T) {
+	var expected = Event{
+		Action:  MessageReportedAction,
+		Payload: []byte(`{"id":5,"messageID":7}`),
+	}
+
+	reportRepo := reportMock.NewRepository(t)
+	roomRepo := roomMock.NewRepository(t)
+	roomSvc := room.NewService(roomRepo, nil)
+
+	s := &Server{
+		handlers:    initEventHandlers(),
+		rooms:       newRoomCache(nil, rooms...),
+		roomUseCase: roomSvc,
+		reports:     report.NewService(reportRepo, roomSvc, nil),
+		clients:     make(map[*Client]bool),
+	}
+
+	c := &Client{
+		server: s,
+		event:  make(chan Event, 1),
+		ID:     10,
+	}
+
+	s.joinClient(c)
+
+	reportRepo.
+		On("FindMessage", 7).
+		Return(&entity.Message{ID: 7, UserID: 11, RoomID: 1}, nil).
+		Once()
+
+	roomRepo.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1}, nil).
+		Once()
+
+	reportRepo.
+		On("FindOpenReport", 7, 10).
+		Return(nil, entity.ErrReportNotFound).
+		Once()
+
+	reportRepo.
+		On("CreateReport", mock.AnythingOfType("*entity.MessageReport")).
+		Return(5, nil).
+		Once()
+
+	err := ReportMessageHandler(Event{
+		Action:  ReportMessageAction,
+		Payload: []byte(`{"messageID":7,"reason":"spam"}`),
+	}, c)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, <-c.event)
+}
@@ -1,6 +1,10 @@
 package websocket
 
-import "context"
+import (
+	"context"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
 
 // Reader interface to read messages from a queue.
 type Reader interface {
@@ -17,3 +21,8 @@ type Broker interface {
 	Reader
 	Writer
 }
+
+// MessageQueue queues the messages to be persisted asynchronously.
+type MessageQueue interface {
+	Enqueue(msg *entity.Message) error
+}
@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"github.com/apex/log"
+)
+
+// maxMentions max users notified by a message, the other mentions are ignored.
+const maxMentions = 10
+
+// mentionPattern a username mentioned in a message, e.g. "hi @user".
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([^\s@]+)`)
+
+// MentionEvent represents a user mentioned in a chat room message, sent to every session of the mentioned user.
+type MentionEvent struct {
+	RoomID  int          `json:"roomID"`
+	Message MessageEvent `json:"message"`
+}
+
+// mentions returns the lowercase usernames mentioned in the message content.
+func mentions(content string) map[string]bool {
+	usernames := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		username := strings.TrimRight(match[1], ".,;:!?)")
+		if username == "" {
+			continue
+		}
+
+		usernames[strings.ToLower(username)] = true
+		if len(usernames) == maxMentions {
+			break
+		}
+	}
+
+	return usernames
+}
+
+// notifyMentions sends a MentionEvent to every session of the users mentioned in the message.
+//
+// a user in dnd, blocking the sender or not allowed to access the private chat room isn't notified.
+func (s *Server) notifyMentions(sender *Client, room *entity.Room, message MessageEvent) {
+	usernames := mentions(message.Message)
+	if len(usernames) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(MentionEvent{
+		RoomID:  room.ID,
+		Message: message,
+	})
+	if err != nil {
+		log.WithError(err).Error("could not encode mention event")
+		return
+	}
+
+	event := Event{
+		Action:  MentionedAction,
+		Payload: payload,
+	}
+
+	allowed := make(map[int]bool)
+	for _, client := range s.connectedClients() {
+		if client.ID == sender.ID || !usernames[strings.ToLower(client.Username)] {
+			continue
+		}
+
+		if client.status() == entity.UserStatusDND || client.isBlocking(sender.ID) {
+			continue
+		}
+
+		if room.Private {
+			ok, checked := allowed[client.ID]
+			if !checked {
+				ok = s.roomUseCase.CheckAccess(client.ID, room) == nil
+				allowed[client.ID] = ok
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		client.send(event)
+	}
+}
@@ -0,0 +1,183 @@
+package websocket
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// ErrInternal unexpected failure processing an event
+var ErrInternal = errors.New("internal error")
+
+// EventMiddleware wraps an EventHandler to run before or after it, e.g. to reject an event or measure it.
+type EventMiddleware func(next EventHandler) EventHandler
+
+// chain wraps the handler with the middlewares, the first middleware is the outermost one.
+func chain(handler EventHandler, middlewares ...EventMiddleware) EventHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// Recovery recovers from a panic in the next handlers, the event is rejected and the Client stays connected.
+func Recovery() EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(event Event, c *Client) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.WithFields(log.Fields{
+						"UserID": c.ID,
+						"Action": event.Action,
+						"Panic":  r,
+						"Stack":  string(debug.Stack()),
+					}).Error("event handler panic")
+
+					err = c.sendError(event.Action, InternalErrorCode, ErrInternal)
+				}
+			}()
+
+			return next(event, c)
+		}
+	}
+}
+
+// Logging logs every processed event with its duration, an event rejected with an ErrorEvent is logged
+// as a warning and an event failing with an error as an error.
+func Logging() EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(event Event, c *Client) error {
+			c.rejected = nil
+
+			start := time.Now()
+			err := next(event, c)
+
+			logger := log.WithFields(log.Fields{
+				"UserID":   c.ID,
+				"RoomID":   c.room(),
+				"Action":   event.Action,
+				"Duration": time.Since(start).String(),
+			})
+
+			switch {
+			case err != nil:
+				logger.WithError(err).Error("failed to process event")
+			case c.rejected != nil:
+				logger.WithField("Code", c.rejected.code).WithError(c.rejected.err).Warn("event rejected")
+			default:
+				logger.Info("event processed")
+			}
+
+			return err
+		}
+	}
+}
+
+// Timing records the count, errors and duration of the events by action in the EventStats.
+func Timing(stats *EventStats) EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(event Event, c *Client) error {
+			start := time.Now()
+			err := next(event, c)
+			stats.record(event.Action, time.Since(start), err)
+
+			return err
+		}
+	}
+}
+
+// RequireRoom rejects the event if the Client didn't join a chat room or left it, e.g. kicked by a moderator.
+func RequireRoom() EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(event Event, c *Client) error {
+			if c.room() == 0 {
+				return c.sendError(event.Action, NotInRoomCode, ErrInvalidRoomID)
+			}
+
+			return next(event, c)
+		}
+	}
+}
+
+// RequirePermission rejects the event if the user role in the joined chat room doesn't grant the permission,
+// it must run after RequireRoom.
+//
+// the membership or role may change after the user joined the chat room, it's checked on every event.
+func RequirePermission(p entity.Permission) EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(event Event, c *Client) error {
+			room, ok := c.server.getRoom(c.room())
+			if !ok {
+				return ErrInvalidRoomID
+			}
+
+			if err := c.server.roomUseCase.Authorize(c.ID, room, p); err != nil {
+				return c.reject(event.Action, err)
+			}
+
+			return next(event, c)
+		}
+	}
+}
+
+// ActionStats metrics of the events of an action.
+type ActionStats struct {
+	Count           int64 `json:"count"`
+	Errors          int64 `json:"errors"`
+	TotalDurationMs int64 `json:"totalDurationMs"`
+	MaxDurationMs   int64 `json:"maxDurationMs"`
+}
+
+// EventStats metrics of the events processed by the Server, by action.
+type EventStats struct {
+	mu      sync.Mutex
+	actions map[string]*ActionStats
+}
+
+// NewEventStats EventStats builder.
+func NewEventStats() *EventStats {
+	return &EventStats{
+		actions: make(map[string]*ActionStats),
+	}
+}
+
+func (s *EventStats) record(action string, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.actions[action]
+	if !ok {
+		stats = &ActionStats{}
+		s.actions[action] = stats
+	}
+
+	ms := duration.Milliseconds()
+
+	stats.Count++
+	stats.TotalDurationMs += ms
+	if ms > stats.MaxDurationMs {
+		stats.MaxDurationMs = ms
+	}
+	if err != nil {
+		stats.Errors++
+	}
+}
+
+// Snapshot returns a copy of the metrics by action.
+func (s *EventStats) Snapshot() map[string]ActionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]ActionStats, len(s.actions))
+	for action, stats := range s.actions {
+		snapshot[action] = *stats
+	}
+
+	return snapshot
+}
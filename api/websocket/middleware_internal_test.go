@@ -0,0 +1,145 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+
+	trace := func(name string) EventMiddleware {
+		return func(next EventHandler) EventHandler {
+			return func(event Event, c *Client) error {
+				calls = append(calls, name)
+				return next(event, c)
+			}
+		}
+	}
+
+	handler := chain(func(event Event, c *Client) error {
+		calls = append(calls, "handler")
+		return nil
+	}, trace("first"), trace("second"))
+
+	err := handler(Event{}, &Client{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func TestRecovery(t *testing.T) {
+	var expected = Event{
+		Action:  ErrorAction,
+		Payload: []byte(`{"action":"sendMessage","code":"internalError","message":"internal error"}`),
+	}
+
+	c := &Client{event: make(chan Event, 1), ID: 10}
+
+	handler := chain(func(event Event, c *Client) error {
+		panic("unexpected")
+	}, Recovery())
+
+	// the Client isn't disconnected
+	err := handler(Event{Action: SendMessageAction}, c)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, <-c.event)
+}
+
+func TestLogging(t *testing.T) {
+	errHandler := errors.New("handler error")
+
+	tests := []struct {
+		name     string
+		handler  EventHandler
+		expected log.Level
+		message  string
+	}{
+		{
+			name: "When the event is processed; should log it as info",
+			handler: func(event Event, c *Client) error {
+				return nil
+			},
+			expected: log.InfoLevel,
+			message:  "event processed",
+		},
+		{
+			name: "When the event is rejected; should log it once as a warning",
+			handler: func(event Event, c *Client) error {
+				return c.sendError(event.Action, NotInRoomCode, ErrInvalidRoomID)
+			},
+			expected: log.WarnLevel,
+			message:  "event rejected",
+		},
+		{
+			name: "When the event fails; should log it once as an error",
+			handler: func(event Event, c *Client) error {
+				return errHandler
+			},
+			expected: log.ErrorLevel,
+			message:  "failed to process event",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := memory.New()
+			log.SetHandler(handler)
+
+			c := &Client{event: make(chan Event, 1), ID: 10}
+			// a previous event rejection isn't logged again
+			c.rejected = &rejection{code: NotInRoomCode, err: ErrInvalidRoomID}
+
+			_ = chain(tt.handler, Logging())(Event{Action: SendMessageAction}, c)
+
+			if assert.Len(t, handler.Entries, 1) {
+				assert.Equal(t, tt.expected, handler.Entries[0].Level)
+				assert.Equal(t, tt.message, handler.Entries[0].Message)
+			}
+		})
+	}
+}
+
+func TestTiming(t *testing.T) {
+	stats := NewEventStats()
+	errHandler := errors.New("handler error")
+
+	handler := chain(func(event Event, c *Client) error {
+		if event.Action == BanUserAction {
+			return errHandler
+		}
+		return nil
+	}, Timing(stats))
+
+	c := &Client{}
+	assert.NoError(t, handler(Event{Action: SendMessageAction}, c))
+	assert.NoError(t, handler(Event{Action: SendMessageAction}, c))
+	assert.ErrorIs(t, handler(Event{Action: BanUserAction}, c), errHandler)
+
+	got := stats.Snapshot()
+	assert.Equal(t, int64(2), got[SendMessageAction].Count)
+	assert.Zero(t, got[SendMessageAction].Errors)
+	assert.Equal(t, int64(1), got[BanUserAction].Count)
+	assert.Equal(t, int64(1), got[BanUserAction].Errors)
+}
+
+func TestRequireRoom(t *testing.T) {
+	var expected = Event{
+		Action:  ErrorAction,
+		Payload: []byte(`{"action":"kickUser","code":"notInRoom","message":"invalid room id"}`),
+	}
+
+	c := &Client{event: make(chan Event, 1), ID: 10}
+
+	handler := chain(func(event Event, c *Client) error {
+		t.Fatal("the handler must not be called")
+		return nil
+	}, RequireRoom())
+
+	err := handler(Event{Action: KickUserAction}, c)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, <-c.event)
+}
@@ -0,0 +1,42 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	entity "github.com/vsantosalmeida/browser-chat/entity"
+)
+
+// MessageQueue is an autogenerated mock type for the MessageQueue type
+type MessageQueue struct {
+	mock.Mock
+}
+
+// Enqueue provides a mock function with given fields: msg
+func (_m *MessageQueue) Enqueue(msg *entity.Message) error {
+	ret := _m.Called(msg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*entity.Message) error); ok {
+		r0 = rf(msg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMessageQueue interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMessageQueue creates a new instance of MessageQueue. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMessageQueue(t mockConstructorTestingTNewMessageQueue) *MessageQueue {
+	mock := &MessageQueue{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,579 @@
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"github.com/apex/log"
+)
+
+const (
+	// DefaultIdleAfter time without events before a user is idle.
+	DefaultIdleAfter = 5 * time.Minute
+	// DefaultAwayAfter time without events before a user is away.
+	DefaultAwayAfter = 15 * time.Minute
+
+	// PresenceUpdatedEvent the status of the users connected to a chat-api instance changed.
+	PresenceUpdatedEvent = "presenceUpdated"
+
+	// presenceInterval interval to check the idle and away users and publish the presence of every connected user.
+	presenceInterval = 30 * time.Second
+	// presenceExpiry time without the presence of another chat-api instance before its users are offline,
+	// e.g. after the instance crashed.
+	presenceExpiry = 3 * presenceInterval
+)
+
+// presenceRank the status of a user connected to several chat-api instances is its highest ranked status,
+// e.g. a user away in an instance and online in another one is online.
+var presenceRank = map[entity.UserStatus]int{
+	entity.UserStatusOffline: 0,
+	entity.UserStatusAway:    1,
+	entity.UserStatusIdle:    2,
+	entity.UserStatusOnline:  3,
+	entity.UserStatusDND:     4,
+}
+
+// PresenceConfig times without events of a user before its status changes, a zero IdleAfter disables the user status.
+type PresenceConfig struct {
+	IdleAfter time.Duration
+	AwayAfter time.Duration
+}
+
+// StatusEvent represents the status of a user changed, sent to the chat rooms the user is in.
+type StatusEvent struct {
+	UserID int               `json:"userID"`
+	Status entity.UserStatus `json:"status"`
+}
+
+// PresenceEvent the status of the users connected to a chat-api instance and the chat rooms they joined,
+// published to every chat-api instance on each change and every presenceInterval with every connected user.
+type PresenceEvent struct {
+	Type     string `json:"type"`
+	Instance string `json:"instance"`
+	// Full is true when the Users are every user connected to the instance
+	Full  bool           `json:"full,omitempty"`
+	Users []UserPresence `json:"users"`
+}
+
+// UserPresence the status of a user in a chat-api instance, offline once its last Client disconnected.
+type UserPresence struct {
+	UserID int               `json:"userID"`
+	Status entity.UserStatus `json:"status"`
+	Rooms  []int             `json:"rooms,omitempty"`
+}
+
+// presenceChange the chat rooms of a user whose presence changed in another chat-api instance.
+type presenceChange struct {
+	// the chat rooms joined before or after the change
+	rooms []int
+	// the chat rooms joined by the change
+	joined []int
+}
+
+// instancePresence the users connected to another chat-api instance.
+type instancePresence struct {
+	seen  time.Time
+	users map[int]UserPresence
+}
+
+// presenceTracker derives the status of the users connected to the Server, merges it with the status
+// of the users connected to the other chat-api instances and keeps the last status sent.
+//
+// the tracker is only changed by the Start loop, the mutex guards the statuses read by the Client handlers.
+type presenceTracker struct {
+	mu        sync.RWMutex
+	idleAfter time.Duration
+	awayAfter time.Duration
+	local     map[int]UserPresence
+	remote    map[string]*instancePresence
+	statuses  map[int]entity.UserStatus
+}
+
+// newPresenceTracker presenceTracker builder, returns nil if the user status is disabled.
+func newPresenceTracker(cfg PresenceConfig) *presenceTracker {
+	if cfg.IdleAfter <= 0 {
+		return nil
+	}
+
+	awayAfter := cfg.AwayAfter
+	if awayAfter < cfg.IdleAfter {
+		awayAfter = cfg.IdleAfter
+	}
+
+	return &presenceTracker{
+		idleAfter: cfg.IdleAfter,
+		awayAfter: awayAfter,
+		local:     make(map[int]UserPresence),
+		remote:    make(map[string]*instancePresence),
+		statuses:  make(map[int]entity.UserStatus),
+	}
+}
+
+// newInstanceID returns a random ID of the chat-api instance, labeling the presence it publishes.
+func newInstanceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		log.WithError(err).Fatal("failed to generate instance id")
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// status derives the user status from its connected Clients, the status set by the user
+// wins over the activity of its most recent active Client.
+func (p *presenceTracker) status(clients []*Client, now time.Time) entity.UserStatus {
+	if len(clients) == 0 {
+		return entity.UserStatusOffline
+	}
+
+	var last time.Time
+	for _, client := range clients {
+		if status := client.status(); status != "" {
+			return status
+		}
+
+		if active := client.lastActivity(); active.After(last) {
+			last = active
+		}
+	}
+
+	switch inactive := now.Sub(last); {
+	case inactive >= p.awayAfter:
+		return entity.UserStatusAway
+	case inactive >= p.idleAfter:
+		return entity.UserStatusIdle
+	default:
+		return entity.UserStatusOnline
+	}
+}
+
+// get returns the last status sent of the user, offline when the user isn't connected.
+func (p *presenceTracker) get(userID int) entity.UserStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if status, ok := p.statuses[userID]; ok {
+		return status
+	}
+
+	return entity.UserStatusOffline
+}
+
+// set stores the user status sent, returns false if the status didn't change.
+func (p *presenceTracker) set(userID int, status entity.UserStatus) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous, ok := p.statuses[userID]
+	if !ok {
+		previous = entity.UserStatusOffline
+	}
+
+	if status == previous {
+		return false
+	}
+
+	if status == entity.UserStatusOffline {
+		delete(p.statuses, userID)
+	} else {
+		p.statuses[userID] = status
+	}
+
+	return true
+}
+
+// localStatus returns the user status derived from the Clients connected to the Server.
+func (p *presenceTracker) localStatus(userID int) entity.UserStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if u, ok := p.local[userID]; ok {
+		return u.Status
+	}
+
+	return entity.UserStatusOffline
+}
+
+// setLocal stores the user presence in the Server, returns false if neither the status nor the chat rooms changed.
+func (p *presenceTracker) setLocal(u UserPresence) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous, ok := p.local[u.UserID]
+	if u.Status == entity.UserStatusOffline {
+		delete(p.local, u.UserID)
+		return ok
+	}
+
+	p.local[u.UserID] = u
+
+	return !ok || previous.Status != u.Status || !sameRooms(previous.Rooms, u.Rooms)
+}
+
+// locals returns the presence of every user connected to the Server.
+func (p *presenceTracker) locals() []UserPresence {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	users := make([]UserPresence, 0, len(p.local))
+	for _, u := range p.local {
+		users = append(users, u)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].UserID < users[j].UserID
+	})
+
+	return users
+}
+
+// merged returns the highest ranked status of the user among every chat-api instance.
+func (p *presenceTracker) merged(userID int) entity.UserStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := entity.UserStatusOffline
+	if u, ok := p.local[userID]; ok {
+		status = u.Status
+	}
+
+	for _, instance := range p.remote {
+		if u, ok := instance.users[userID]; ok && presenceRank[u.Status] > presenceRank[status] {
+			status = u.Status
+		}
+	}
+
+	return status
+}
+
+// remoteRooms returns the chat rooms joined by the user in the other chat-api instances.
+func (p *presenceTracker) remoteRooms(userID int) []int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var rooms []int
+	for _, instance := range p.remote {
+		rooms = append(rooms, instance.users[userID].Rooms...)
+	}
+
+	return rooms
+}
+
+// setRemote stores the presence of the users connected to another chat-api instance, a full presence
+// replaces every user of the instance.
+//
+// returns the users whose status or chat rooms changed.
+func (p *presenceTracker) setRemote(e PresenceEvent, now time.Time) map[int]presenceChange {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	instance, ok := p.remote[e.Instance]
+	if !ok {
+		instance = &instancePresence{users: make(map[int]UserPresence)}
+		p.remote[e.Instance] = instance
+	}
+	instance.seen = now
+
+	changes := make(map[int]presenceChange)
+
+	if e.Full {
+		present := make(map[int]bool, len(e.Users))
+		for _, u := range e.Users {
+			present[u.UserID] = true
+		}
+
+		for userID, u := range instance.users {
+			if !present[userID] {
+				delete(instance.users, userID)
+				changes[userID] = presenceChange{rooms: u.Rooms}
+			}
+		}
+	}
+
+	for _, u := range e.Users {
+		previous, ok := instance.users[u.UserID]
+		if !ok {
+			previous.Status = entity.UserStatusOffline
+		}
+
+		if previous.Status == u.Status && sameRooms(previous.Rooms, u.Rooms) {
+			continue
+		}
+
+		if u.Status == entity.UserStatusOffline {
+			delete(instance.users, u.UserID)
+		} else {
+			instance.users[u.UserID] = u
+		}
+
+		changes[u.UserID] = presenceChange{
+			rooms:  append(previous.Rooms, u.Rooms...),
+			joined: joinedRooms(previous.Rooms, u.Rooms),
+		}
+	}
+
+	return changes
+}
+
+// expire drops the chat-api instances without presence since the given time, their users are offline.
+//
+// returns the users of the dropped instances.
+func (p *presenceTracker) expire(since time.Time) map[int]presenceChange {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changes := make(map[int]presenceChange)
+	for id, instance := range p.remote {
+		if !instance.seen.Before(since) {
+			continue
+		}
+
+		delete(p.remote, id)
+
+		for userID, u := range instance.users {
+			change := changes[userID]
+			change.rooms = append(change.rooms, u.Rooms...)
+			changes[userID] = change
+		}
+	}
+
+	return changes
+}
+
+// hasRoom checks if the chat room is in the given chat rooms.
+func hasRoom(rooms []int, roomID int) bool {
+	for _, r := range rooms {
+		if r == roomID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// joinedRooms returns the chat rooms in current that aren't in previous.
+func joinedRooms(previous, current []int) []int {
+	var joined []int
+	for _, roomID := range current {
+		if !hasRoom(previous, roomID) {
+			joined = append(joined, roomID)
+		}
+	}
+
+	return joined
+}
+
+// sameRooms checks if both have the same chat rooms.
+func sameRooms(a, b []int) bool {
+	return len(joinedRooms(a, b)) == 0 && len(joinedRooms(b, a)) == 0
+}
+
+// clientRooms returns the chat rooms joined by the Clients.
+func clientRooms(clients []*Client) []int {
+	var rooms []int
+	for _, client := range clients {
+		if roomID := client.room(); roomID != 0 && !hasRoom(rooms, roomID) {
+			rooms = append(rooms, roomID)
+		}
+	}
+
+	return rooms
+}
+
+// inPresenceLoop runs the presence update on the Start loop, which owns the presence of the users,
+// it doesn't block after the Server stopped.
+func (s *Server) inPresenceLoop(update func()) {
+	select {
+	case s.presenceUpdates <- update:
+	case <-s.done:
+	}
+}
+
+// updatePresence derives the user status in the Server, a change of the status or of the chat rooms joined by the user
+// is published to the other chat-api instances, the Clients that just left give the rooms of an offline user.
+//
+// returns false if the user status among every chat-api instance didn't change.
+func (s *Server) updatePresence(userID int, left ...*Client) bool {
+	if s.presence == nil {
+		return false
+	}
+
+	clients := s.userClients(userID)
+
+	local := UserPresence{
+		UserID: userID,
+		Status: s.presence.status(clients, time.Now()),
+		Rooms:  clientRooms(clients),
+	}
+
+	if s.presence.setLocal(local) {
+		s.publishPresence(PresenceEvent{Users: []UserPresence{local}})
+	}
+
+	return s.sendPresence(userID, clientRooms(append(clients, left...)))
+}
+
+// sendPresence merges the user status among every chat-api instance and sends a change to every session of the user
+// and to the Clients in the given chat rooms or in the chat rooms joined by the user in another chat-api instance.
+//
+// returns false if the status didn't change.
+func (s *Server) sendPresence(userID int, rooms []int) bool {
+	status := s.presence.merged(userID)
+	if !s.presence.set(userID, status) {
+		return false
+	}
+
+	log.WithFields(log.Fields{
+		"UserID": userID,
+		"Status": status,
+	}).Info("user status changed")
+
+	event, err := newStatusEvent(userID, status)
+	if err != nil {
+		log.WithError(err).Error("could not encode status event")
+		return true
+	}
+
+	s.sendToUserRooms(userID, append(rooms, s.presence.remoteRooms(userID)...), event)
+
+	return true
+}
+
+// announceStatus sends the current user status to the other Clients in the chat rooms just joined by the user.
+func (s *Server) announceStatus(userID int, rooms []int) {
+	event, err := newStatusEvent(userID, s.presence.get(userID))
+	if err != nil {
+		log.WithError(err).Error("could not encode status event")
+		return
+	}
+
+	for _, roomID := range rooms {
+		for _, client := range s.roomClients(roomID) {
+			if client.ID != userID {
+				client.send(event)
+			}
+		}
+	}
+}
+
+// refreshPresence updates the status of every connected user, the users without recent events become idle or away.
+//
+// the presence of every connected user is published again and the users of the chat-api instances
+// that stopped publishing their presence are offline.
+func (s *Server) refreshPresence() {
+	if s.presence == nil {
+		return
+	}
+
+	users := make(map[int]bool)
+	for _, client := range s.connectedClients() {
+		users[client.ID] = true
+	}
+
+	for userID := range users {
+		s.updatePresence(userID)
+	}
+
+	s.publishPresence(PresenceEvent{
+		Full:  true,
+		Users: s.presence.locals(),
+	})
+
+	for userID, change := range s.presence.expire(time.Now().Add(-presenceExpiry)) {
+		s.sendPresence(userID, append(change.rooms, clientRooms(s.userClients(userID))...))
+	}
+}
+
+// handlePresenceEvent merges the presence of the users connected to another chat-api instance,
+// the Clients in a chat room just joined by a user in the other instance receive the user status.
+func (s *Server) handlePresenceEvent(e PresenceEvent) {
+	if s.presence == nil || e.Instance == s.instance {
+		return
+	}
+
+	s.inPresenceLoop(func() {
+		for userID, change := range s.presence.setRemote(e, time.Now()) {
+			if !s.sendPresence(userID, append(change.rooms, clientRooms(s.userClients(userID))...)) {
+				s.announceStatus(userID, change.joined)
+			}
+		}
+	})
+}
+
+// touchPresence records an event of the Client, an idle or away user is online again.
+func (s *Server) touchPresence(c *Client, now time.Time) {
+	c.touch(now)
+
+	if s.presence == nil {
+		return
+	}
+
+	if status := s.presence.localStatus(c.ID); status == entity.UserStatusIdle || status == entity.UserStatusAway {
+		s.inPresenceLoop(func() {
+			s.updatePresence(c.ID)
+		})
+	}
+}
+
+// announcePresence sends the user status to the other Clients in the chat room joined by the Client,
+// a changed status is already sent to the chat room.
+func (s *Server) announcePresence(c *Client) {
+	if s.presence == nil {
+		return
+	}
+
+	s.inPresenceLoop(func() {
+		if !s.updatePresence(c.ID) {
+			s.announceStatus(c.ID, []int{c.room()})
+		}
+	})
+}
+
+// leavePresence publishes the Server has no connected users, the other chat-api instances don't wait
+// for the presence to expire.
+func (s *Server) leavePresence() {
+	if s.presence == nil {
+		return
+	}
+
+	s.publishPresence(PresenceEvent{Full: true})
+}
+
+// publishPresence publishes the presence of the users connected to the Server to the other chat-api instances.
+// errors are only logged, the presence of every connected user is published again after the presenceInterval.
+func (s *Server) publishPresence(e PresenceEvent) {
+	e.Type = PresenceUpdatedEvent
+	e.Instance = s.instance
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		log.WithError(err).Error("could not encode presence event")
+		return
+	}
+
+	if err = s.events.WriteMessage(context.Background(), payload); err != nil {
+		log.WithError(err).Error("could not publish presence event")
+	}
+}
+
+func newStatusEvent(userID int, status entity.UserStatus) (Event, error) {
+	payload, err := json.Marshal(StatusEvent{
+		UserID: userID,
+		Status: status,
+	})
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Action:  StatusUpdatedAction,
+		Payload: payload,
+	}, nil
+}
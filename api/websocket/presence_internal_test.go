@@ -0,0 +1,308 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	wsMock "github.com/vsantosalmeida/browser-chat/api/websocket/mocks"
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/user"
+	userMock "github.com/vsantosalmeida/browser-chat/usecase/user/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPresenceTracker_Status(t *testing.T) {
+	var now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newClient := func(inactive time.Duration, status entity.UserStatus) *Client {
+		c := &Client{ID: 10, Status: status}
+		c.touch(now.Add(-inactive))
+		return c
+	}
+
+	var tt = []struct {
+		name     string
+		clients  []*Client
+		expected entity.UserStatus
+	}{
+		{
+			name:     "When the user has no Clients; should be offline",
+			expected: entity.UserStatusOffline,
+		},
+		{
+			name:     "When the user sent an event recently; should be online",
+			clients:  []*Client{newClient(30*time.Second, "")},
+			expected: entity.UserStatusOnline,
+		},
+		{
+			name:     "When the user didn't send events after the idle time; should be idle",
+			clients:  []*Client{newClient(90*time.Second, "")},
+			expected: entity.UserStatusIdle,
+		},
+		{
+			name:     "When the user didn't send events after the away time; should be away",
+			clients:  []*Client{newClient(3*time.Minute, "")},
+			expected: entity.UserStatusAway,
+		},
+		{
+			name:     "When another session of the user sent an event recently; should be online",
+			clients:  []*Client{newClient(3*time.Minute, ""), newClient(time.Second, "")},
+			expected: entity.UserStatusOnline,
+		},
+		{
+			name:     "When the user set the dnd status; should be dnd regardless of the activity",
+			clients:  []*Client{newClient(time.Second, entity.UserStatusDND)},
+			expected: entity.UserStatusDND,
+		},
+	}
+
+	p := newPresenceTracker(PresenceConfig{IdleAfter: time.Minute, AwayAfter: 2 * time.Minute})
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, p.status(tc.clients, now))
+		})
+	}
+}
+
+func TestNewPresenceTrackerDisabled(t *testing.T) {
+	assert.Nil(t, newPresenceTracker(PresenceConfig{}))
+}
+
+func TestPresenceTrackerRemote(t *testing.T) {
+	var now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	p := newPresenceTracker(PresenceConfig{IdleAfter: time.Minute, AwayAfter: 2 * time.Minute})
+
+	p.setLocal(UserPresence{UserID: 10, Status: entity.UserStatusAway, Rooms: []int{1}})
+
+	changes := p.setRemote(PresenceEvent{
+		Instance: "other",
+		Users:    []UserPresence{{UserID: 10, Status: entity.UserStatusOnline, Rooms: []int{2}}},
+	}, now)
+	assert.Equal(t, map[int]presenceChange{10: {rooms: []int{2}, joined: []int{2}}}, changes)
+
+	// the most present status among the instances wins
+	assert.Equal(t, entity.UserStatusOnline, p.merged(10))
+	assert.Equal(t, []int{2}, p.remoteRooms(10))
+
+	// an unchanged presence isn't a change
+	assert.Empty(t, p.setRemote(PresenceEvent{
+		Instance: "other",
+		Full:     true,
+		Users:    []UserPresence{{UserID: 10, Status: entity.UserStatusOnline, Rooms: []int{2}}},
+	}, now))
+
+	// a full presence drops the users no longer connected to the instance
+	changes = p.setRemote(PresenceEvent{Instance: "other", Full: true}, now)
+	assert.Equal(t, map[int]presenceChange{10: {rooms: []int{2}}}, changes)
+	assert.Equal(t, entity.UserStatusAway, p.merged(10))
+
+	// the users of an instance without presence are offline
+	p.setRemote(PresenceEvent{
+		Instance: "crashed",
+		Users:    []UserPresence{{UserID: 11, Status: entity.UserStatusOnline, Rooms: []int{3}}},
+	}, now)
+	assert.Empty(t, p.expire(now))
+	assert.Equal(t, map[int]presenceChange{11: {rooms: []int{3}}}, p.expire(now.Add(time.Second)))
+	assert.Equal(t, entity.UserStatusOffline, p.merged(11))
+}
+
+// startPresenceLoop runs the presence updates of the Server as its Start loop does.
+func startPresenceLoop(t *testing.T, s *Server) {
+	s.presenceUpdates = make(chan func())
+	s.done = make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case update := <-s.presenceUpdates:
+				update()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		close(s.done)
+	})
+}
+
+// newPresenceBroker events broker accepting the presence published by the Server.
+func newPresenceBroker(t *testing.T) *wsMock.Broker {
+	events := wsMock.NewBroker(t)
+
+	events.
+		On("WriteMessage", mock.Anything, mock.Anything).
+		Return(nil).
+		Maybe()
+
+	return events
+}
+
+func TestServerUpdatePresence(t *testing.T) {
+	statusEvent := func(status string) Event {
+		return Event{
+			Action:  StatusUpdatedAction,
+			Payload: []byte(`{"userID":10,"status":"` + status + `"}`),
+		}
+	}
+
+	userRepo := userMock.NewRepository(t)
+
+	userRepo.
+		On("FindByID", 10).
+		Return(&entity.User{ID: 10, Username: "user", Status: entity.UserStatusDND}, nil).
+		Once()
+
+	s := &Server{
+		clients:  make(map[*Client]bool),
+		users:    newUserService(userRepo),
+		events:   newPresenceBroker(t),
+		presence: newPresenceTracker(PresenceConfig{IdleAfter: time.Minute, AwayAfter: 2 * time.Minute}),
+	}
+	startPresenceLoop(t, s)
+
+	c := &Client{server: s, event: make(chan Event, 1), ID: 10, RoomID: 1}
+	sameRoom := &Client{server: s, event: make(chan Event, 1), ID: 11, RoomID: 1}
+	otherRoom := &Client{server: s, event: make(chan Event, 1), ID: 12, RoomID: 2}
+
+	for _, client := range []*Client{c, sameRoom, otherRoom} {
+		client.touch(time.Now())
+		s.clients[client] = true
+	}
+
+	// the other users are already online
+	s.presence.set(11, entity.UserStatusOnline)
+	s.presence.set(12, entity.UserStatusOnline)
+
+	assert.True(t, s.updatePresence(10))
+	assert.Equal(t, statusEvent("online"), <-c.event)
+	assert.Equal(t, statusEvent("online"), <-sameRoom.event)
+
+	// an unchanged status isn't sent again
+	assert.False(t, s.updatePresence(10))
+
+	c.touch(time.Now().Add(-90 * time.Second))
+	s.refreshPresence()
+	assert.Equal(t, statusEvent("idle"), <-c.event)
+	assert.Equal(t, statusEvent("idle"), <-sameRoom.event)
+
+	// an event of an idle user sets it online
+	s.touchPresence(c, time.Now())
+	assert.Equal(t, statusEvent("online"), <-c.event)
+	assert.Equal(t, statusEvent("online"), <-sameRoom.event)
+
+	// the status set by the user overrides its activity
+	s.handleUserEvent(user.Event{Type: user.EventStatusUpdated, UserID: 10})
+	assert.Equal(t, statusEvent("dnd"), <-c.event)
+	assert.Equal(t, statusEvent("dnd"), <-sameRoom.event)
+
+	// the users in the chat room joined by the Client receive its current status
+	otherRoom.setRoom(1)
+	s.announcePresence(c)
+	assert.Equal(t, statusEvent("dnd"), <-sameRoom.event)
+	assert.Equal(t, statusEvent("dnd"), <-otherRoom.event)
+	otherRoom.setRoom(2)
+
+	// the chat room of the last Client disconnected receives the offline status
+	s.clientsMu.Lock()
+	delete(s.clients, c)
+	s.clientsMu.Unlock()
+	s.updatePresence(10, c)
+	assert.Equal(t, statusEvent("offline"), <-sameRoom.event)
+
+	assert.Empty(t, c.event)
+	assert.Empty(t, sameRoom.event)
+	assert.Empty(t, otherRoom.event)
+}
+
+func TestServerUpdatePresencePublish(t *testing.T) {
+	events := wsMock.NewBroker(t)
+
+	s := &Server{
+		clients:  make(map[*Client]bool),
+		events:   events,
+		instance: "local",
+		presence: newPresenceTracker(PresenceConfig{IdleAfter: time.Minute, AwayAfter: 2 * time.Minute}),
+	}
+
+	c := &Client{server: s, event: make(chan Event, 1), ID: 10, RoomID: 1}
+	c.touch(time.Now())
+	s.clients[c] = true
+
+	events.
+		On("WriteMessage", mock.Anything, []byte(`{"type":"presenceUpdated","instance":"local","users":[{"userID":10,"status":"online","rooms":[1]}]}`)).
+		Return(nil).
+		Once()
+
+	assert.True(t, s.updatePresence(10))
+
+	// the other chat-api instances drop the users of a stopped Server
+	events.
+		On("WriteMessage", mock.Anything, []byte(`{"type":"presenceUpdated","instance":"local","full":true,"users":null}`)).
+		Return(nil).
+		Once()
+
+	s.leavePresence()
+}
+
+func TestServerHandlePresenceEvent(t *testing.T) {
+	statusEvent := func(status string) Event {
+		return Event{
+			Action:  StatusUpdatedAction,
+			Payload: []byte(`{"userID":20,"status":"` + status + `"}`),
+		}
+	}
+
+	s := &Server{
+		clients:  make(map[*Client]bool),
+		events:   newPresenceBroker(t),
+		instance: "local",
+		presence: newPresenceTracker(PresenceConfig{IdleAfter: time.Minute, AwayAfter: 2 * time.Minute}),
+	}
+	startPresenceLoop(t, s)
+
+	sameRoom := &Client{server: s, event: make(chan Event, 1), ID: 11, RoomID: 1}
+	otherRoom := &Client{server: s, event: make(chan Event, 1), ID: 12, RoomID: 2}
+	s.clients[sameRoom] = true
+	s.clients[otherRoom] = true
+
+	// the user connected to another instance is online in the chat room
+	s.handlePresenceEvent(PresenceEvent{
+		Type:     PresenceUpdatedEvent,
+		Instance: "other",
+		Users:    []UserPresence{{UserID: 20, Status: entity.UserStatusOnline, Rooms: []int{1}}},
+	})
+	assert.Equal(t, statusEvent("online"), <-sameRoom.event)
+
+	// the Clients in the chat room joined by the user receive its current status
+	s.handlePresenceEvent(PresenceEvent{
+		Type:     PresenceUpdatedEvent,
+		Instance: "other",
+		Users:    []UserPresence{{UserID: 20, Status: entity.UserStatusOnline, Rooms: []int{1, 2}}},
+	})
+	assert.Equal(t, statusEvent("online"), <-otherRoom.event)
+
+	// the presence published by the Server itself is ignored
+	s.handlePresenceEvent(PresenceEvent{
+		Type:     PresenceUpdatedEvent,
+		Instance: "local",
+		Full:     true,
+	})
+
+	// the user is offline once the other instance stopped
+	s.handlePresenceEvent(PresenceEvent{
+		Type:     PresenceUpdatedEvent,
+		Instance: "other",
+		Full:     true,
+	})
+	assert.Equal(t, statusEvent("offline"), <-sameRoom.event)
+	assert.Equal(t, statusEvent("offline"), <-otherRoom.event)
+
+	assert.Empty(t, sameRoom.event)
+	assert.Empty(t, otherRoom.event)
+}
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEventsPerSecond events refilled per second in a Client token bucket.
+	DefaultEventsPerSecond = 5
+	// DefaultEventsBurst max events a Client can send at once.
+	DefaultEventsBurst = 10
+
+	// maxSlowModeEntries max tracked messages, the expired entries are removed when it's reached.
+	maxSlowModeEntries = 10000
+	// slowModeEntryTTL time to keep the last message of a user, longer than the max room slow mode.
+	slowModeEntryTTL = 6 * time.Hour
+)
+
+// RateLimitConfig limits the events sent by every Client, a zero EventsPerSecond disables the limit.
+type RateLimitConfig struct {
+	EventsPerSecond int
+	Burst           int
+}
+
+// tokenBucket limits the events of a Client, each event takes a token and the tokens
+// are refilled at a constant rate up to the burst size.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket tokenBucket builder, returns nil if the limit is disabled.
+func newTokenBucket(cfg RateLimitConfig) *tokenBucket {
+	if cfg.EventsPerSecond <= 0 {
+		return nil
+	}
+
+	burst := cfg.Burst
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		rate:   float64(cfg.EventsPerSecond),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// allow takes a token if available, otherwise returns the time to wait for the next token.
+func (b *tokenBucket) allow(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+
+	return false, wait
+}
+
+type slowModeKey struct {
+	roomID int
+	userID int
+}
+
+// slowModeTracker keeps the last message time of every user in a chat room,
+// shared by the sessions of the same user connected to this chat-api instance.
+//
+// the times aren't shared between instances, a user connected to two instances may send a message
+// to each of them during the same interval.
+type slowModeTracker struct {
+	mu   sync.Mutex
+	last map[slowModeKey]time.Time
+}
+
+func newSlowModeTracker() *slowModeTracker {
+	return &slowModeTracker{
+		last: make(map[slowModeKey]time.Time),
+	}
+}
+
+// tryRecord stores the time of the user message sent to the chat room if the interval elapsed since
+// the last one, otherwise returns the time the user must wait. The check and the record are atomic,
+// the concurrent messages of the user sessions are only recorded once per interval.
+func (t *slowModeTracker) tryRecord(roomID, userID int, interval time.Duration, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := slowModeKey{roomID: roomID, userID: userID}
+
+	if last, ok := t.last[key]; ok {
+		if elapsed := now.Sub(last); elapsed < interval {
+			return interval - elapsed
+		}
+	}
+
+	if len(t.last) >= maxSlowModeEntries {
+		for k, last := range t.last {
+			if now.Sub(last) > slowModeEntryTTL {
+				delete(t.last, k)
+			}
+		}
+	}
+
+	t.last[key] = now
+
+	return 0
+}
+
+// forget removes the user message recorded in the chat room, e.g. the message couldn't be sent.
+func (t *slowModeTracker) forget(roomID, userID int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.last, slowModeKey{roomID: roomID, userID: userID})
+}
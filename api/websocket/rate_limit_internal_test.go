@@ -0,0 +1,78 @@
+package websocket
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket_Allow(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	b := newTokenBucket(RateLimitConfig{EventsPerSecond: 2, Burst: 2})
+	b.last = now
+
+	for i := 0; i < 2; i++ {
+		ok, wait := b.allow(now)
+		assert.True(t, ok)
+		assert.Zero(t, wait)
+	}
+
+	ok, wait := b.allow(now)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// a token is refilled after half a second
+	ok, _ = b.allow(now.Add(500 * time.Millisecond))
+	assert.True(t, ok)
+}
+
+func TestNewTokenBucketDisabled(t *testing.T) {
+	assert.Nil(t, newTokenBucket(RateLimitConfig{}))
+}
+
+func TestSlowModeTracker_TryRecord(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tracker := newSlowModeTracker()
+	assert.Zero(t, tracker.tryRecord(1, 10, 30*time.Second, now))
+
+	// a rejected message isn't recorded, the wait is counted from the first message
+	assert.Equal(t, 20*time.Second, tracker.tryRecord(1, 10, 30*time.Second, now.Add(10*time.Second)))
+	assert.Equal(t, 15*time.Second, tracker.tryRecord(1, 10, 30*time.Second, now.Add(15*time.Second)))
+	assert.Zero(t, tracker.tryRecord(1, 10, 30*time.Second, now.Add(30*time.Second)))
+	// the interval is tracked by room and user
+	assert.Zero(t, tracker.tryRecord(2, 10, 30*time.Second, now))
+	assert.Zero(t, tracker.tryRecord(1, 11, 30*time.Second, now))
+
+	// a forgotten message doesn't count
+	tracker.forget(1, 11)
+	assert.Zero(t, tracker.tryRecord(1, 11, 30*time.Second, now.Add(time.Second)))
+}
+
+func TestSlowModeTracker_TryRecordConcurrent(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tracker := newSlowModeTracker()
+
+	// the sessions of the same user send a message at the same time, only one is allowed
+	var (
+		wg      sync.WaitGroup
+		allowed int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tracker.tryRecord(1, 10, 30*time.Second, now) == 0 {
+				atomic.AddInt32(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), allowed)
+}
@@ -0,0 +1,126 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/room"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// roomNotFoundTTL time to keep a not found room ID in the cache.
+	roomNotFoundTTL = 30 * time.Second
+	// maxNotFoundRooms max not found room IDs in the cache, avoids unbounded memory growth
+	// when a Client sends random room IDs.
+	maxNotFoundRooms = 10000
+)
+
+// roomCache keeps the chat rooms in memory, the not found room IDs are also cached for a while
+// to avoid hitting the DB on every unknown room ID.
+//
+// each invalidation bumps the room generation, a room loaded before an invalidation isn't cached.
+type roomCache struct {
+	useCase     room.UseCase
+	mu          sync.RWMutex
+	rooms       map[int]*entity.Room
+	notFound    map[int]time.Time
+	generations map[int]uint64
+}
+
+// newRoomCache roomCache builder.
+func newRoomCache(useCase room.UseCase, rooms ...*entity.Room) *roomCache {
+	rc := &roomCache{
+		useCase:     useCase,
+		rooms:       make(map[int]*entity.Room),
+		notFound:    make(map[int]time.Time),
+		generations: make(map[int]uint64),
+	}
+
+	for _, r := range rooms {
+		rc.rooms[r.ID] = r
+	}
+
+	return rc
+}
+
+// get retrieves the chat room from memory or loads it from DB.
+func (rc *roomCache) get(id int) (*entity.Room, bool) {
+	rc.mu.RLock()
+	r, ok := rc.rooms[id]
+	expiresAt, notFound := rc.notFound[id]
+	generation := rc.generations[id]
+	rc.mu.RUnlock()
+
+	if ok {
+		return r, true
+	}
+
+	if notFound && time.Now().Before(expiresAt) {
+		return nil, false
+	}
+
+	r, err := rc.useCase.FindRoom(id)
+	if err != nil {
+		// only a not found room is cached, a DB error must not hide an existing room
+		if errors.Is(err, entity.ErrRoomNotFound) {
+			rc.setNotFound(id, generation)
+			log.WithField("RoomID", id).Warn("room not found")
+		}
+		return nil, false
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	// the room changed while it was loaded, the next get loads it again
+	if rc.generations[id] != generation {
+		return r, true
+	}
+
+	rc.rooms[id] = r
+	delete(rc.notFound, id)
+
+	return r, true
+}
+
+// invalidate removes the chat room from memory, the next get will load it from DB.
+// a get loading the room at the same time doesn't cache it.
+func (rc *roomCache) invalidate(id int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	delete(rc.rooms, id)
+	delete(rc.notFound, id)
+	rc.generations[id]++
+}
+
+// setNotFound caches the room ID as not found, unless it was invalidated since the given generation.
+func (rc *roomCache) setNotFound(id int, generation uint64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.generations[id] != generation {
+		return
+	}
+
+	now := time.Now()
+
+	if len(rc.notFound) >= maxNotFoundRooms {
+		for k, expiresAt := range rc.notFound {
+			if now.After(expiresAt) {
+				delete(rc.notFound, k)
+			}
+		}
+
+		// still full of valid entries, starts over instead of growing
+		if len(rc.notFound) >= maxNotFoundRooms {
+			rc.notFound = make(map[int]time.Time)
+		}
+	}
+
+	rc.notFound[id] = now.Add(roomNotFoundTTL)
+}
@@ -0,0 +1,168 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	wsMock "github.com/vsantosalmeida/browser-chat/api/websocket/mocks"
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/room"
+	roomMock "github.com/vsantosalmeida/browser-chat/usecase/room/mocks"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRoomCache_Get(t *testing.T) {
+	var expected = &entity.Room{
+		ID: 2,
+	}
+
+	roomRepo := roomMock.NewRepository(t)
+	rc := newRoomCache(room.NewService(roomRepo, nil), rooms...)
+
+	// loaded only once, the next lookups are served from memory
+	roomRepo.
+		On("FindRoom", 2).
+		Return(&entity.Room{ID: 2}, nil).
+		Once()
+
+	for i := 0; i < 2; i++ {
+		r, ok := rc.get(2)
+		assert.True(t, ok)
+		assert.Equal(t, expected, r)
+	}
+}
+
+func TestRoomCache_GetNotFound(t *testing.T) {
+	roomRepo := roomMock.NewRepository(t)
+	rc := newRoomCache(room.NewService(roomRepo, nil), rooms...)
+
+	// a not found room is cached, the DB is hit only once
+	roomRepo.
+		On("FindRoom", 2).
+		Return(nil, entity.ErrRoomNotFound).
+		Once()
+
+	for i := 0; i < 2; i++ {
+		r, ok := rc.get(2)
+		assert.False(t, ok)
+		assert.Nil(t, r)
+	}
+}
+
+func TestRoomCache_GetDBError(t *testing.T) {
+	roomRepo := roomMock.NewRepository(t)
+	rc := newRoomCache(room.NewService(roomRepo, nil), rooms...)
+
+	// a DB error isn't cached, the DB is hit on every lookup
+	roomRepo.
+		On("FindRoom", 2).
+		Return(nil, errors.New("db error")).
+		Twice()
+
+	for i := 0; i < 2; i++ {
+		r, ok := rc.get(2)
+		assert.False(t, ok)
+		assert.Nil(t, r)
+	}
+}
+
+func TestRoomCache_GetInvalidated(t *testing.T) {
+	var tt = []struct {
+		name     string
+		stale    *entity.Room
+		staleErr error
+	}{
+		{
+			name:  "When the room is updated while it's loaded; should not cache the stale room",
+			stale: &entity.Room{ID: 2, Name: "old"},
+		},
+		{
+			name:     "When the room is created while it's loaded; should not cache it as not found",
+			staleErr: entity.ErrRoomNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var expected = &entity.Room{ID: 2, Name: "new"}
+
+			roomRepo := roomMock.NewRepository(t)
+			rc := newRoomCache(room.NewService(roomRepo, nil), rooms...)
+
+			// the room event is handled between the DB read and the cache write
+			roomRepo.
+				On("FindRoom", 2).
+				Return(tc.stale, tc.staleErr).
+				Run(func(args mock.Arguments) {
+					rc.invalidate(2)
+				}).
+				Once()
+
+			roomRepo.
+				On("FindRoom", 2).
+				Return(expected, nil).
+				Once()
+
+			rc.get(2)
+
+			r, ok := rc.get(2)
+			assert.True(t, ok)
+			assert.Equal(t, expected, r)
+		})
+	}
+}
+
+func TestServerListenRoomEvents(t *testing.T) {
+	var (
+		msgRaw   = `{"type":"roomCreated","roomID":2}`
+		expected = &entity.Room{
+			ID: 2,
+		}
+	)
+
+	roomRepo := roomMock.NewRepository(t)
+	events := wsMock.NewBroker(t)
+
+	s := &Server{
+		handlers: initEventHandlers(),
+		rooms:    newRoomCache(room.NewService(roomRepo, nil), rooms...),
+		clients:  make(map[*Client]bool),
+		events:   events,
+	}
+
+	ctx := context.Background()
+
+	roomRepo.
+		On("FindRoom", 2).
+		Return(nil, entity.ErrRoomNotFound).
+		Once()
+
+	_, ok := s.getRoom(2)
+	assert.False(t, ok)
+
+	events.
+		On("ReadMessage", ctx, mock.AnythingOfType(mockAnythingOfTypeChanByte)).
+		Return().
+		Run(func(args mock.Arguments) {
+			ch := args.Get(1).(chan<- []byte)
+			ch <- []byte(msgRaw)
+			// closes the channel to stop waiting for messages
+			close(ch)
+		}).
+		Once()
+
+	// the created room is loaded from DB instead of the cached not found entry
+	roomRepo.
+		On("FindRoom", 2).
+		Return(&entity.Room{ID: 2}, nil).
+		Once()
+
+	s.listenRoomEvents(ctx)
+
+	r, ok := s.getRoom(2)
+	assert.True(t, ok)
+	assert.Equal(t, expected, r)
+}
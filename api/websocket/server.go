@@ -5,11 +5,20 @@ import (
 	"encoding/json"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/vsantosalmeida/browser-chat/api/midleware"
 	"github.com/vsantosalmeida/browser-chat/entity"
 	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+	"github.com/vsantosalmeida/browser-chat/pkg/markdown"
+	"github.com/vsantosalmeida/browser-chat/usecase/filter"
+	"github.com/vsantosalmeida/browser-chat/usecase/moderation"
+	"github.com/vsantosalmeida/browser-chat/usecase/report"
 	"github.com/vsantosalmeida/browser-chat/usecase/room"
+	"github.com/vsantosalmeida/browser-chat/usecase/session"
+	"github.com/vsantosalmeida/browser-chat/usecase/token"
+	"github.com/vsantosalmeida/browser-chat/usecase/user"
 
 	"github.com/apex/log"
 	"github.com/gorilla/websocket"
@@ -20,6 +29,8 @@ var (
 	upgrader = websocket.Upgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
+		// the token carried as subprotocol is never echoed back to the client
+		Subprotocols: []string{auth.BearerProtocol},
 		CheckOrigin: func(r *http.Request) bool { // bypass origin check
 			return true
 		},
@@ -27,6 +38,15 @@ var (
 
 	// ErrInvalidEventAction invalid event action
 	ErrInvalidEventAction = errors.New("invalid event action")
+
+	// ErrShuttingDown connection refused during the Server shutdown
+	ErrShuttingDown = errors.New("server is shutting down")
+
+	// ErrRateLimited too many events sent by a Client
+	ErrRateLimited = errors.New("too many events, slow down")
+
+	// ErrSessionRevoked connection closed by the user revoking its session
+	ErrSessionRevoked = errors.New("session revoked")
 )
 
 // ClientList holds the current connected Clients with the Server.
@@ -34,14 +54,40 @@ type ClientList map[*Client]bool
 
 // Server handle the websocket connection between Clients and events.
 type Server struct {
-	clients     ClientList
-	join        chan *Client
-	leave       chan *Client
+	// the clients list is changed by the Start loop and read by the event listeners and handlers
+	clientsMu sync.RWMutex
+	clients   ClientList
+	join      chan *Client
+	leave     chan *Client
+	// the event handlers and middlewares may be registered while the Clients route events
+	handlersMu  sync.RWMutex
 	handlers    map[string]EventHandler
-	rooms       []*entity.Room
+	rooms       *roomCache
 	roomUseCase room.UseCase
+	moderation  moderation.UseCase
+	reports     report.UseCase
+	sessions    session.UseCase
+	users       user.UseCase
 	broker      Broker
-	mu          sync.RWMutex
+	events      Broker
+	messages    MessageQueue
+	filters     filter.UseCase
+	limits      RateLimitConfig
+	trustProxy  bool
+	slowMode    *slowModeTracker
+	presence    *presenceTracker
+	// the presence updates run by the Start loop and the ID labeling the presence published by the Server
+	presenceUpdates chan func()
+	instance        string
+	middlewares     []EventMiddleware
+	stats           *EventStats
+	closing         atomic.Bool
+	// the Client readers still routing events, no reader starts once the Server is closing
+	readersMu sync.Mutex
+	readers   sync.WaitGroup
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
 }
 
 // CommandOutput result of executed command from chatbot.
@@ -52,48 +98,185 @@ type CommandOutput struct {
 }
 
 // NewServer Server builder.
-func NewServer(roomUseCase room.UseCase, broker Broker) *Server {
+//
+// the broker handles the chatbot commands and the events broker receives the room and moderation events
+// published by every chat-api instance.
+//
+// the filters are applied to every message sent by a Client and the limits to the events of every Client.
+//
+// every connection starts a user session, the session events are received by the events broker as well.
+// the session IP is read from the X-Forwarded-For header only if trustProxy is set.
+//
+// the messages are sent with the user profile, the profile and status events are received by the events broker too.
+// the user status is derived from the events of its Clients after the presence times and merged with the status
+// published by the other chat-api instances through the events broker.
+func NewServer(roomUseCase room.UseCase, moderationUseCase moderation.UseCase, reportUseCase report.UseCase, sessionUseCase session.UseCase, userUseCase user.UseCase, broker, events Broker, messages MessageQueue, filters filter.UseCase, limits RateLimitConfig, presence PresenceConfig, trustProxy bool) *Server {
 	s := &Server{
-		clients:     make(ClientList),
-		join:        make(chan *Client),
-		leave:       make(chan *Client),
-		handlers:    initEventHandlers(),
-		roomUseCase: roomUseCase,
-		broker:      broker,
+		clients:         make(ClientList),
+		join:            make(chan *Client),
+		leave:           make(chan *Client),
+		handlers:        initEventHandlers(),
+		roomUseCase:     roomUseCase,
+		moderation:      moderationUseCase,
+		reports:         reportUseCase,
+		sessions:        sessionUseCase,
+		users:           userUseCase,
+		broker:          broker,
+		events:          events,
+		messages:        messages,
+		filters:         filters,
+		limits:          limits,
+		trustProxy:      trustProxy,
+		slowMode:        newSlowModeTracker(),
+		presence:        newPresenceTracker(presence),
+		presenceUpdates: make(chan func()),
+		instance:        newInstanceID(),
+		stats:           NewEventStats(),
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
 	}
 
+	s.Use(Logging(), Timing(s.stats), Recovery())
+
 	rooms, err := s.roomUseCase.ListRooms()
 	if err != nil {
 		log.Fatalf("failed to load rooms: %v", err)
 	}
 
-	s.rooms = rooms
+	s.rooms = newRoomCache(roomUseCase, rooms...)
 
 	return s
 }
 
-// Start loop to receive Client connections or disconnections.
+// Start loop to receive Client connections or disconnections until the context is canceled
+// or the Server is shut down, the Clients sessions are kept alive meanwhile.
 func (s *Server) Start(ctx context.Context) {
+	defer close(s.done)
+
 	go s.listenChatbotMessages(ctx)
+	go s.listenRoomEvents(ctx)
 
-	for {
-		if ctx.Err() == context.Canceled {
-			log.Warn("context canceled")
-			break
-		}
+	ticker := time.NewTicker(session.TouchInterval)
+	defer ticker.Stop()
 
+	presenceTicker := time.NewTicker(presenceInterval)
+	defer presenceTicker.Stop()
+
+	for {
 		select {
+		case <-ticker.C:
+			s.touchSessions()
+
+		case <-presenceTicker.C:
+			s.refreshPresence()
+
+		case update := <-s.presenceUpdates:
+			update()
+
 		case client := <-s.join:
 			s.joinClient(client)
 
 		case client := <-s.leave:
 			s.leaveClient(client)
+
+		case <-s.stop:
+			log.Info("server stopped")
+			s.leavePresence()
+			return
+
+		case <-ctx.Done():
+			log.Warn("context canceled")
+			return
 		}
 	}
 }
 
+// Shutdown stops accepting connections and closes every connected Client, each Client receives
+// a serverShutdown event followed by a going away close frame.
+//
+// returns the context error if the Clients aren't closed before the context is done,
+// the remaining connections are closed anyway.
+//
+// the Client readers are waited, no event is routed, e.g. a message queued, after it returns.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.readersMu.Lock()
+	s.closing.Store(true)
+	s.readersMu.Unlock()
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+
+	// no Client joins or leaves once the Start loop is done
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	clients := s.connectedClients()
+	log.WithField("Clients", len(clients)).Info("closing client connections")
+
+	for _, client := range clients {
+		close(client.quit)
+	}
+
+	var err error
+	for _, client := range clients {
+		select {
+		case <-client.closed:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		client.conn.Close()
+
+		s.clientsMu.Lock()
+		delete(s.clients, client)
+		s.clientsMu.Unlock()
+
+		// the session is ended before the process exits
+		if client.SessionID != 0 {
+			s.sessions.End(client.SessionID)
+		}
+	}
+
+	readersDone := make(chan struct{})
+	go func() {
+		s.readers.Wait()
+		close(readersDone)
+	}()
+
+	select {
+	case <-readersDone:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	return err
+}
+
+// startReader starts the Client reader unless the Server is closing, a reader started is waited by Shutdown.
+func (s *Server) startReader(client *Client) bool {
+	s.readersMu.Lock()
+	defer s.readersMu.Unlock()
+
+	if s.closing.Load() {
+		return false
+	}
+
+	s.readers.Add(1)
+	go func() {
+		defer s.readers.Done()
+		client.readMessages()
+	}()
+
+	return true
+}
+
 // ServeWS handle the websocket connections with an authenticated Client and starts the go routines
 // to listen for read and write events.
+//
+// each connection is a user session labeled by the device query parameter, or the user agent when it's empty.
 func (s *Server) ServeWS(w http.ResponseWriter, r *http.Request) {
 	userCtxValue := r.Context().Value(auth.UserContextKey)
 	if userCtxValue == nil {
@@ -102,67 +285,234 @@ func (s *Server) ServeWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := userCtxValue.(entity.AuthenticatedUser)
+	authUser := userCtxValue.(entity.AuthenticatedUser)
+
+	if s.closing.Load() {
+		http.Error(w, ErrShuttingDown.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	sess, err := s.sessions.Start(authUser.GetId(), authUser.GetLoginID(), r.URL.Query().Get("device"), midleware.RemoteIP(r, s.trustProxy), r.UserAgent())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
+		s.sessions.End(sess.ID)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	client := NewClient(conn, s, user.GetUsername(), user.GetId())
+	client := NewClient(conn, s, authUser, sess.ID)
+
+	// the messages are sent with the username until the profile is updated
+	if profile, err := s.users.GetProfile(authUser.GetId()); err != nil {
+		log.WithError(err).WithField("UserID", authUser.GetId()).Error("could not load user profile")
+	} else {
+		client.setProfile(profile)
+	}
+
+	// the blocked users messages are sent until the block list is updated
+	if blocks, err := s.users.ListBlocks(authUser.GetId()); err != nil {
+		log.WithError(err).WithField("UserID", authUser.GetId()).Error("could not load blocked users")
+	} else {
+		client.setBlocked(blocks)
+	}
 
-	go client.readMessages()
+	if !s.startReader(client) {
+		conn.Close()
+		s.sessions.End(sess.ID)
+		return
+	}
 	go client.writeMessages()
 
-	s.join <- client
+	select {
+	case s.join <- client:
+	case <-s.done:
+		// the Server stopped during the upgrade
+		conn.Close()
+		s.sessions.End(sess.ID)
+	}
 }
 
 // joinClient adds a connected Client to the Server.
+// the user is online while any of its sessions is connected to the Server.
 func (s *Server) joinClient(client *Client) {
+	s.clientsMu.Lock()
 	s.clients[client] = true
-	log.WithField("UserID", client.ID).Info("user connected")
+	s.clientsMu.Unlock()
+
+	logger := log.WithFields(log.Fields{
+		"UserID":    client.ID,
+		"SessionID": client.SessionID,
+	})
+	logger.Info("user connected")
+
+	if len(s.userClients(client.ID)) == 1 {
+		logger.Info("user online")
+	}
+
+	s.updatePresence(client.ID)
+}
+
+// unregister asks the Start loop to disconnect the Client, it doesn't block after the Server stopped.
+func (s *Server) unregister(client *Client) {
+	select {
+	case s.leave <- client:
+	case <-s.done:
+	}
 }
 
-// leaveClient disconnects a Client from the Server.
+// leaveClient disconnects a Client from the Server and ends its session.
+// the user is offline once its last session is disconnected.
 func (s *Server) leaveClient(client *Client) {
-	if _, ok := s.clients[client]; ok {
-		client.conn.Close()
-		delete(s.clients, client)
-		log.WithField("UserID", client.ID).Info("user disconnected")
+	s.clientsMu.Lock()
+	_, ok := s.clients[client]
+	delete(s.clients, client)
+	s.clientsMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	client.conn.Close()
+
+	logger := log.WithFields(log.Fields{
+		"UserID":    client.ID,
+		"SessionID": client.SessionID,
+	})
+	logger.Info("user disconnected")
+
+	if len(s.userClients(client.ID)) == 0 {
+		logger.Info("user offline")
+	}
+
+	s.updatePresence(client.ID, client)
+
+	if client.SessionID != 0 {
+		// the error is logged by the use case, a session not ended is hidden once it's stale
+		go s.sessions.End(client.SessionID)
+	}
+}
+
+// connectedClients returns the Clients connected to the Server, the events are sent to the returned Clients
+// without holding the clients list lock.
+func (s *Server) connectedClients() []*Client {
+	s.clientsMu.RLock()
+	defer s.clientsMu.RUnlock()
+
+	clients := make([]*Client, 0, len(s.clients))
+	for client := range s.clients {
+		clients = append(clients, client)
 	}
+
+	return clients
+}
+
+// roomClients returns the Clients in the chat room.
+func (s *Server) roomClients(roomID int) []*Client {
+	var clients []*Client
+	for _, client := range s.connectedClients() {
+		if client.room() == roomID {
+			clients = append(clients, client)
+		}
+	}
+
+	return clients
+}
+
+// userClients returns the Clients of every session of the user connected to the Server.
+func (s *Server) userClients(userID int) []*Client {
+	var clients []*Client
+	for _, client := range s.connectedClients() {
+		if client.ID == userID {
+			clients = append(clients, client)
+		}
+	}
+
+	return clients
+}
+
+// touchSessions refreshes the last seen time of the connected Clients sessions.
+func (s *Server) touchSessions() {
+	clients := s.connectedClients()
+
+	ids := make([]int, 0, len(clients))
+	for _, client := range clients {
+		if client.SessionID != 0 {
+			ids = append(ids, client.SessionID)
+		}
+	}
+
+	// the error is logged by the use case
+	go s.sessions.Touch(ids)
+}
+
+// Use adds middlewares applied to the events of every action, in the given order,
+// before the middlewares of the action.
+func (s *Server) Use(middlewares ...EventMiddleware) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
+	s.middlewares = append(s.middlewares, middlewares...)
+}
+
+// Handle registers the EventHandler of the action wrapped with its own middlewares,
+// replacing the current EventHandler if any.
+func (s *Server) Handle(action string, handler EventHandler, middlewares ...EventMiddleware) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
+	s.handlers[action] = chain(handler, middlewares...)
+}
+
+// Stats returns the metrics of the events processed by the Server, by action.
+func (s *Server) Stats() map[string]ActionStats {
+	return s.stats.Snapshot()
 }
 
 // routeEvent find the EventHandler for the respective event and process it.
 // it throws an error if the EventHandler is not found.
+//
+// an event over the Client rate limit isn't processed, an ErrorEvent tells the Client when to retry.
 func (s *Server) routeEvent(event Event, c *Client) error {
-	if handler, ok := s.handlers[event.Action]; ok {
-		return handler(event, c)
+	if c.limiter != nil {
+		if ok, retryAfter := c.limiter.allow(time.Now()); !ok {
+			// the event doesn't reach the Logging middleware
+			log.WithFields(log.Fields{
+				"UserID": c.ID,
+				"Action": event.Action,
+			}).Warn("event rate limited")
+
+			return c.sendRateLimited(event.Action, ErrRateLimited, retryAfter)
+		}
+	}
+
+	s.handlersMu.RLock()
+	handler, ok := s.handlers[event.Action]
+	middlewares := s.middlewares
+	s.handlersMu.RUnlock()
+
+	if ok {
+		return chain(handler, middlewares...)(event, c)
 	}
 
 	log.WithField("Action", event.Action).Error("invalid event action")
 	return ErrInvalidEventAction
 }
 
-// isValidRoom checks if the given room ID exists in the Server memory.
-// will try to retrieve from DB if this chat room isn't in the memory.
+// isValidRoom checks if the given room ID exists.
 func (s *Server) isValidRoom(id int) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if found := findRoom(s.rooms, id); found {
-		return true
-	} else {
-		// if room isn't found server will try to retrieve from DB as a last chance
-		rooms, err := s.roomUseCase.ListRooms()
-		if err != nil {
-			return false
-		}
-
-		s.rooms = rooms
+	_, ok := s.getRoom(id)
+	return ok
+}
 
-		return findRoom(s.rooms, id)
-	}
+// getRoom retrieves the chat room for the given room ID from the Server memory.
+// will try to retrieve from DB if this chat room isn't in the memory.
+func (s *Server) getRoom(id int) (*entity.Room, bool) {
+	return s.rooms.get(id)
 }
 
 // listenChatbotMessages loop through the message channel and send the chatbot message
@@ -191,9 +541,11 @@ func (s *Server) listenChatbotMessages(ctx context.Context) {
 		}
 
 		msgInput := MessageEvent{
-			Message: output.Message,
-			From:    output.From,
-			Sent:    time.Now(),
+			Message:     output.Message,
+			HTML:        markdown.Render(output.Message),
+			From:        output.From,
+			DisplayName: output.From,
+			Sent:        time.Now(),
 		}
 
 		payload, err := json.Marshal(msgInput)
@@ -208,30 +560,354 @@ func (s *Server) listenChatbotMessages(ctx context.Context) {
 		}
 
 		// broadcast event to all clients in the same chat room
-		for client := range s.clients {
-			if client.RoomID == output.RoomID {
-				client.event <- event
+		for _, client := range s.roomClients(output.RoomID) {
+			client.send(event)
+		}
+	}
+}
+
+// listenRoomEvents loop through the room events channel, keeps the room cache up to date,
+// send the room changes to all Clients and the moderation actions to the chat room Clients
+// closes the revoked sessions and the sessions of a revoked login and sends the profile and status changes.
+func (s *Server) listenRoomEvents(ctx context.Context) {
+	msgCH := make(chan []byte)
+	go s.events.ReadMessage(ctx, msgCH)
+
+	for msg := range msgCH {
+		if ctx.Err() == context.Canceled {
+			log.Warn("context canceled")
+			return
+		}
+
+		var e room.Event
+		if err := json.Unmarshal(msg, &e); err != nil {
+			log.WithError(err).Error("could not decode room event")
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"RoomID": e.RoomID,
+			"Event":  e.Type,
+		}).Info("received room event")
+
+		if moderation.IsEvent(e.Type) {
+			var me moderation.Event
+			if err := json.Unmarshal(msg, &me); err != nil {
+				log.WithError(err).Error("could not decode moderation event")
+				continue
+			}
+
+			s.handleModerationEvent(me)
+			continue
+		}
+
+		if session.IsEvent(e.Type) {
+			var se session.Event
+			if err := json.Unmarshal(msg, &se); err != nil {
+				log.WithError(err).Error("could not decode session event")
+				continue
+			}
+
+			s.handleSessionEvent(se)
+			continue
+		}
+
+		if token.IsEvent(e.Type) {
+			var te token.Event
+			if err := json.Unmarshal(msg, &te); err != nil {
+				log.WithError(err).Error("could not decode login event")
+				continue
+			}
+
+			s.handleLoginEvent(te)
+			continue
+		}
+
+		if e.Type == PresenceUpdatedEvent {
+			var pe PresenceEvent
+			if err := json.Unmarshal(msg, &pe); err != nil {
+				log.WithError(err).Error("could not decode presence event")
+				continue
 			}
+
+			s.handlePresenceEvent(pe)
+			continue
+		}
+
+		if user.IsEvent(e.Type) {
+			var ue user.Event
+			if err := json.Unmarshal(msg, &ue); err != nil {
+				log.WithError(err).Error("could not decode profile event")
+				continue
+			}
+
+			s.handleUserEvent(ue)
+			continue
 		}
+
+		s.handleRoomEvent(e)
 	}
 }
 
-func findRoom(rooms []*entity.Room, id int) bool {
-	for _, r := range rooms {
-		if r.ID == id {
-			return true
+// handleRoomEvent invalidates the cached chat room and broadcast the change to all Clients.
+//
+// a private chat room change is only sent to its members, any other Client receives
+// the chat room as deleted to drop it from the room list and leaves it. Every Client leaves a deleted chat room.
+func (s *Server) handleRoomEvent(e room.Event) {
+	switch e.Type {
+	case room.EventRoomCreated, room.EventRoomUpdated, room.EventRoomDeleted:
+		s.rooms.invalidate(e.RoomID)
+	default:
+		return
+	}
+
+	output := RoomEvent{
+		ID: e.RoomID,
+	}
+
+	var r *entity.Room
+	if e.Type != room.EventRoomDeleted {
+		if found, ok := s.rooms.get(e.RoomID); ok {
+			r = found
+			output.Name = r.Name
+			output.Topic = r.Topic
+			output.Archived = r.IsArchived()
+		}
+	}
+
+	event, err := newRoomEvent(e.Type, output)
+	if err != nil {
+		log.WithError(err).Error("could not encode room event")
+		return
+	}
+
+	if r == nil || !r.Private {
+		s.broadcast(event)
+
+		if e.Type == room.EventRoomDeleted {
+			for _, client := range s.connectedClients() {
+				s.removeFromRoom(client, e.RoomID)
+			}
+		}
+		return
+	}
+
+	members, err := s.roomUseCase.ListMembers(r.ID)
+	if err != nil {
+		log.WithError(err).WithField("RoomID", r.ID).Error("could not send private room event")
+		return
+	}
+
+	deleted, err := newRoomEvent(room.EventRoomDeleted, RoomEvent{ID: r.ID})
+	if err != nil {
+		log.WithError(err).Error("could not encode room event")
+		return
+	}
+
+	isMember := make(map[int]bool, len(members))
+	for _, m := range members {
+		isMember[m.UserID] = true
+	}
+
+	for _, client := range s.connectedClients() {
+		if isMember[client.ID] {
+			client.send(event)
+			continue
+		}
+
+		client.send(deleted)
+		s.removeFromRoom(client, r.ID)
+	}
+}
+
+// removeFromRoom makes the Client leave the chat room if it's still joined to it.
+func (s *Server) removeFromRoom(client *Client, roomID int) {
+	if client.leaveRoom(roomID) {
+		log.WithFields(log.Fields{
+			"UserID": client.ID,
+			"RoomID": roomID,
+		}).Info("user removed from room")
+	}
+}
+
+// handleModerationEvent announces the moderation action to the Clients in the chat room.
+//
+// every session of a kicked or banned user leaves the chat room, a private chat room is also
+// removed from the room list of a banned user, a kicked member may join it again.
+func (s *Server) handleModerationEvent(e moderation.Event) {
+	payload, err := json.Marshal(ModerationEvent{
+		RoomID:    e.RoomID,
+		UserID:    e.UserID,
+		ActorID:   e.ActorID,
+		Reason:    e.Reason,
+		ExpiresAt: e.ExpiresAt,
+	})
+	if err != nil {
+		log.WithError(err).Error("could not encode moderation event")
+		return
+	}
+
+	event := Event{
+		Action:  e.Type,
+		Payload: payload,
+	}
+
+	removed := e.Type == moderation.EventMemberKicked || e.Type == moderation.EventMemberBanned
+	r, _ := s.rooms.get(e.RoomID)
+
+	for _, client := range s.connectedClients() {
+		if client.room() == e.RoomID {
+			client.send(event)
+		}
+
+		if !removed || client.ID != e.UserID {
+			continue
+		}
+
+		s.removeFromRoom(client, e.RoomID)
+
+		if e.Type == moderation.EventMemberBanned && r != nil && r.Private {
+			deleted, err := newRoomEvent(room.EventRoomDeleted, RoomEvent{ID: r.ID})
+			if err != nil {
+				log.WithError(err).Error("could not encode room event")
+				continue
+			}
+			client.send(deleted)
+		}
+	}
+}
+
+// handleSessionEvent closes the connection of a revoked session, the other sessions of the user stay connected.
+func (s *Server) handleSessionEvent(e session.Event) {
+	if e.Type != session.EventSessionRevoked {
+		return
+	}
+
+	for _, client := range s.userClients(e.UserID) {
+		if client.SessionID == e.SessionID {
+			client.revoke()
 		}
 	}
+}
 
-	log.WithField("RoomID", id).Warn("room not found")
-	return false
+// handleLoginEvent closes the sessions opened with the tokens of a revoked login, e.g. after logout.
+func (s *Server) handleLoginEvent(e token.Event) {
+	if e.Type != token.EventLoginRevoked {
+		return
+	}
+
+	for _, client := range s.userClients(e.UserID) {
+		if client.LoginID == e.LoginID {
+			client.revoke()
+		}
+	}
+}
+
+// handleUserEvent updates the profile of the user Clients, the profile changes are sent to the Clients
+// in the chat rooms joined by the user and to every session of the user, a status change updates the user status.
+// a block list change is reloaded by the user Clients.
+func (s *Server) handleUserEvent(e user.Event) {
+	clients := s.userClients(e.UserID)
+	if len(clients) == 0 {
+		// the user isn't connected to this Server, the rooms it's in are unknown
+		return
+	}
+
+	if e.Type == user.EventBlocksUpdated {
+		s.reloadBlocks(e.UserID, clients)
+		return
+	}
+
+	profile, err := s.users.GetProfile(e.UserID)
+	if err != nil {
+		log.WithError(err).WithField("UserID", e.UserID).Error("could not load user profile")
+		return
+	}
+
+	for _, client := range clients {
+		client.setProfile(profile)
+	}
+
+	if e.Type == user.EventStatusUpdated {
+		if s.presence != nil {
+			s.inPresenceLoop(func() {
+				s.updatePresence(e.UserID)
+			})
+		}
+		return
+	}
+
+	payload, err := json.Marshal(ProfileEvent{
+		UserID:      profile.ID,
+		Username:    profile.Username,
+		DisplayName: profile.Name(),
+		AvatarURL:   profile.AvatarURL(),
+		StatusText:  profile.StatusText,
+	})
+	if err != nil {
+		log.WithError(err).Error("could not encode profile event")
+		return
+	}
+
+	s.sendToUserRooms(e.UserID, clientRooms(clients), Event{
+		Action:  ProfileUpdatedAction,
+		Payload: payload,
+	})
+}
+
+// reloadBlocks updates the users blocked by the user in its Clients.
+func (s *Server) reloadBlocks(userID int, clients []*Client) {
+	blocks, err := s.users.ListBlocks(userID)
+	if err != nil {
+		log.WithError(err).WithField("UserID", userID).Error("could not load blocked users")
+		return
+	}
+
+	for _, client := range clients {
+		client.setBlocked(blocks)
+	}
+}
+
+// sendToUserRooms sends the event to every session of the user and to the Clients in the given chat rooms
+// joined by the user.
+func (s *Server) sendToUserRooms(userID int, rooms []int, event Event) {
+	for _, client := range s.connectedClients() {
+		if roomID := client.room(); client.ID == userID || (roomID != 0 && hasRoom(rooms, roomID)) {
+			client.send(event)
+		}
+	}
+}
+
+func newRoomEvent(action string, e RoomEvent) (Event, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Action:  action,
+		Payload: payload,
+	}, nil
+}
+
+// broadcast send the event to all Clients.
+func (s *Server) broadcast(event Event) {
+	for _, client := range s.connectedClients() {
+		client.send(event)
+	}
 }
 
 func initEventHandlers() map[string]EventHandler {
+	postMessage := []EventMiddleware{RequireRoom(), RequirePermission(entity.PermissionPostMessage)}
+
 	handlers := map[string]EventHandler{
-		SendMessageAction:        SendMessageHandler,
+		SendMessageAction:        chain(SendMessageHandler, postMessage...),
 		JoinRoomAction:           ChatRoomHandler,
-		SendChatbotCommandAction: ChatbotCommandHandler,
+		SendChatbotCommandAction: chain(ChatbotCommandHandler, postMessage...),
+		KickUserAction:           chain(ModerationHandler, RequireRoom()),
+		BanUserAction:            chain(ModerationHandler, RequireRoom()),
+		MuteUserAction:           chain(ModerationHandler, RequireRoom()),
+		ReportMessageAction:      ReportMessageHandler,
 	}
 
 	return handlers
@@ -2,11 +2,26 @@ package websocket
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
-	brokerMock "github.com/vsantosalmeida/browser-chat/api/websocket/mocks"
+	wsMock "github.com/vsantosalmeida/browser-chat/api/websocket/mocks"
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+	"github.com/vsantosalmeida/browser-chat/usecase/moderation"
+	"github.com/vsantosalmeida/browser-chat/usecase/room"
+	roomMock "github.com/vsantosalmeida/browser-chat/usecase/room/mocks"
+	"github.com/vsantosalmeida/browser-chat/usecase/session"
+	sessionMock "github.com/vsantosalmeida/browser-chat/usecase/session/mocks"
+	"github.com/vsantosalmeida/browser-chat/usecase/token"
+	"github.com/vsantosalmeida/browser-chat/usecase/user"
+	userMock "github.com/vsantosalmeida/browser-chat/usecase/user/mocks"
 
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/undefinedlabs/go-mpatch"
@@ -17,7 +32,7 @@ const mockAnythingOfTypeChanByte = "chan<- []uint8"
 func TestServerListenChatbotMessages(t *testing.T) {
 	var (
 		msgRaw         = `{"roomID":1,"from":"chat-bot","message":"command executed"}`
-		eventOutputRaw = `{"message":"command executed","from":"chat-bot","sent":"2020-01-01T00:00:00Z"}`
+		eventOutputRaw = `{"message":"command executed","html":"command executed","from":"chat-bot","displayName":"chat-bot","sent":"2020-01-01T00:00:00Z"}`
 
 		expected = Event{
 			Action:  MessageReceivedAction,
@@ -27,11 +42,11 @@ func TestServerListenChatbotMessages(t *testing.T) {
 
 	eventCH := make(chan Event, 1)
 
-	broker := brokerMock.NewBroker(t)
+	broker := wsMock.NewBroker(t)
 
 	s := &Server{
 		handlers: initEventHandlers(),
-		rooms:    rooms,
+		rooms:    newRoomCache(nil, rooms...),
 		clients:  make(map[*Client]bool),
 		broker:   broker,
 	}
@@ -69,3 +84,648 @@ func TestServerListenChatbotMessages(t *testing.T) {
 	got := <-eventCH
 	assert.Equal(t, expected, got)
 }
+
+func TestServerHandleRoomEvent(t *testing.T) {
+	archivedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var tt = []struct {
+		name      string
+		roomEvent room.Event
+		mockRoom  *entity.Room
+		expected  []Event
+		leftRoom  bool
+	}{
+		{
+			name: "When a room is created; should send the event to all clients",
+			roomEvent: room.Event{
+				Type:   room.EventRoomCreated,
+				RoomID: 2,
+			},
+			mockRoom: &entity.Room{
+				ID:    2,
+				Name:  "general",
+				Topic: "news",
+			},
+			expected: []Event{
+				{
+					Action:  room.EventRoomCreated,
+					Payload: []byte(`{"id":2,"name":"general","topic":"news"}`),
+				},
+			},
+		},
+		{
+			name: "When a room is archived; should send the updated room to all clients",
+			roomEvent: room.Event{
+				Type:   room.EventRoomUpdated,
+				RoomID: 1,
+			},
+			mockRoom: &entity.Room{
+				ID:         1,
+				Name:       "general",
+				ArchivedAt: &archivedAt,
+			},
+			expected: []Event{
+				{
+					Action:  room.EventRoomUpdated,
+					Payload: []byte(`{"id":1,"name":"general","archived":true}`),
+				},
+			},
+		},
+		{
+			name: "When a room is deleted; should send the event to all clients and remove them from the room",
+			roomEvent: room.Event{
+				Type:   room.EventRoomDeleted,
+				RoomID: 1,
+			},
+			expected: []Event{
+				{
+					Action:  room.EventRoomDeleted,
+					Payload: []byte(`{"id":1}`),
+				},
+			},
+			leftRoom: true,
+		},
+		{
+			name: "When the event type is unknown; should not send any event",
+			roomEvent: room.Event{
+				Type:   "unknown",
+				RoomID: 1,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			roomRepo := roomMock.NewRepository(t)
+
+			s := &Server{
+				handlers: initEventHandlers(),
+				rooms:    newRoomCache(room.NewService(roomRepo, nil), rooms...),
+				clients:  make(map[*Client]bool),
+			}
+
+			if tc.mockRoom != nil {
+				roomRepo.
+					On("FindRoom", tc.roomEvent.RoomID).
+					Return(tc.mockRoom, nil).
+					Once()
+			}
+
+			// clients in any room receive the room list changes
+			var (
+				channels []chan Event
+				inRoom   *Client
+			)
+			for _, roomID := range []int{0, 1} {
+				eventCH := make(chan Event, 1)
+				channels = append(channels, eventCH)
+				c := &Client{
+					server: s,
+					event:  eventCH,
+					ID:     10 + roomID,
+					RoomID: roomID,
+				}
+				s.joinClient(c)
+				inRoom = c
+			}
+
+			s.handleRoomEvent(tc.roomEvent)
+
+			for _, eventCH := range channels {
+				var got []Event
+				if len(eventCH) > 0 {
+					got = append(got, <-eventCH)
+				}
+				assert.Equal(t, tc.expected, got)
+			}
+
+			if tc.leftRoom {
+				assert.Zero(t, inRoom.room())
+			} else {
+				assert.Equal(t, 1, inRoom.room())
+			}
+		})
+	}
+}
+
+func TestServerHandleRoomEventPrivateRoom(t *testing.T) {
+	var (
+		memberExpected = Event{
+			Action:  room.EventRoomUpdated,
+			Payload: []byte(`{"id":2,"name":"staff"}`),
+		}
+		othersExpected = Event{
+			Action:  room.EventRoomDeleted,
+			Payload: []byte(`{"id":2}`),
+		}
+	)
+
+	roomRepo := roomMock.NewRepository(t)
+	svc := room.NewService(roomRepo, nil)
+
+	s := &Server{
+		handlers:    initEventHandlers(),
+		rooms:       newRoomCache(svc, rooms...),
+		roomUseCase: svc,
+		clients:     make(map[*Client]bool),
+	}
+
+	roomRepo.
+		On("FindRoom", 2).
+		Return(&entity.Room{ID: 2, Name: "staff", Private: true}, nil).
+		Once()
+
+	roomRepo.
+		On("ListMembers", 2).
+		Return([]*entity.RoomMember{{RoomID: 2, UserID: 10}}, nil).
+		Once()
+
+	member := &Client{server: s, event: make(chan Event, 1), ID: 10, RoomID: 2}
+	s.joinClient(member)
+
+	// a non member receives the private room as deleted to drop it from the room list and leaves it
+	other := &Client{server: s, event: make(chan Event, 1), ID: 11, RoomID: 2}
+	s.joinClient(other)
+
+	s.handleRoomEvent(room.Event{
+		Type:   room.EventRoomUpdated,
+		RoomID: 2,
+	})
+
+	assert.Equal(t, memberExpected, <-member.event)
+	assert.Equal(t, othersExpected, <-other.event)
+
+	assert.Equal(t, 2, member.room())
+	assert.Zero(t, other.room())
+}
+
+func TestServerBroadcastClosedClient(t *testing.T) {
+	expected := Event{
+		Action:  room.EventRoomDeleted,
+		Payload: []byte(`{"id":2}`),
+	}
+
+	s := &Server{
+		clients: make(map[*Client]bool),
+	}
+
+	// a Client that stopped writing never receives its events
+	closed := make(chan struct{})
+	close(closed)
+	s.joinClient(&Client{server: s, event: make(chan Event), closed: closed, ID: 10})
+
+	connectedCH := make(chan Event, 1)
+	s.joinClient(&Client{server: s, event: connectedCH, closed: make(chan struct{}), ID: 11})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.broadcast(expected)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("broadcast blocked by a closed Client")
+	}
+
+	assert.Equal(t, expected, <-connectedCH)
+}
+
+func TestServerHandleModerationEvent(t *testing.T) {
+	var expected = Event{
+		Action:  moderation.EventMemberKicked,
+		Payload: []byte(`{"roomID":1,"userID":11,"actorID":10,"reason":"spam"}`),
+	}
+
+	s := &Server{
+		handlers: initEventHandlers(),
+		rooms:    newRoomCache(nil, rooms...),
+		clients:  make(map[*Client]bool),
+	}
+
+	moderator := &Client{server: s, event: make(chan Event, 1), ID: 10, RoomID: 1}
+	kicked := &Client{server: s, event: make(chan Event, 1), ID: 11, RoomID: 1}
+	// the user session in another chat room isn't affected
+	otherRoom := &Client{server: s, event: make(chan Event, 1), ID: 11, RoomID: 2}
+
+	for _, c := range []*Client{moderator, kicked, otherRoom} {
+		s.joinClient(c)
+	}
+
+	s.handleModerationEvent(moderation.Event{
+		Type:    moderation.EventMemberKicked,
+		RoomID:  1,
+		UserID:  11,
+		ActorID: 10,
+		Reason:  "spam",
+	})
+
+	assert.Equal(t, expected, <-moderator.event)
+	assert.Equal(t, expected, <-kicked.event)
+	assert.Empty(t, otherRoom.event)
+
+	assert.Equal(t, 1, moderator.RoomID)
+	assert.Zero(t, kicked.RoomID)
+	assert.Equal(t, 2, otherRoom.RoomID)
+}
+
+func TestServerHandleModerationEventPrivateRoom(t *testing.T) {
+	deleted := Event{
+		Action:  room.EventRoomDeleted,
+		Payload: []byte(`{"id":2}`),
+	}
+
+	tests := []struct {
+		name      string
+		eventType string
+		expected  []Event
+	}{
+		{
+			name:      "When a member is kicked; should keep the private room in the member room list",
+			eventType: moderation.EventMemberKicked,
+			expected: []Event{
+				{Action: moderation.EventMemberKicked, Payload: []byte(`{"roomID":2,"userID":11,"actorID":10}`)},
+			},
+		},
+		{
+			name:      "When a member is banned; should remove the private room from the member room list",
+			eventType: moderation.EventMemberBanned,
+			expected: []Event{
+				{Action: moderation.EventMemberBanned, Payload: []byte(`{"roomID":2,"userID":11,"actorID":10}`)},
+				deleted,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				handlers: initEventHandlers(),
+				rooms:    newRoomCache(nil, &entity.Room{ID: 2, Private: true}),
+				clients:  make(map[*Client]bool),
+			}
+
+			removed := &Client{server: s, event: make(chan Event, 2), ID: 11, RoomID: 2}
+			s.joinClient(removed)
+
+			s.handleModerationEvent(moderation.Event{
+				Type:    tt.eventType,
+				RoomID:  2,
+				UserID:  11,
+				ActorID: 10,
+			})
+
+			var got []Event
+			for len(removed.event) > 0 {
+				got = append(got, <-removed.event)
+			}
+
+			assert.Equal(t, tt.expected, got)
+			assert.Zero(t, removed.RoomID)
+		})
+	}
+}
+
+func TestServerRouteEventRateLimited(t *testing.T) {
+	var expected = Event{
+		Action:  ErrorAction,
+		Payload: []byte(`{"action":"sendMessage","code":"rateLimited","message":"too many events, slow down","retryAfterMs":1000}`),
+	}
+
+	s := &Server{
+		handlers: initEventHandlers(),
+		clients:  make(map[*Client]bool),
+	}
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+		return now
+	})
+	assert.NoError(t, err)
+	defer timePatch.Unpatch()
+
+	// an empty bucket refilling one token per second
+	c := &Client{
+		server:  s,
+		event:   make(chan Event, 1),
+		ID:      10,
+		limiter: &tokenBucket{rate: 1, burst: 1, last: now},
+	}
+
+	// the event is rejected before reaching its handler
+	err = s.routeEvent(Event{Action: SendMessageAction}, c)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, <-c.event)
+}
+
+func TestServerUseWhileRouting(t *testing.T) {
+	s := &Server{
+		handlers: make(map[string]EventHandler),
+		clients:  make(map[*Client]bool),
+	}
+
+	s.Handle(SendMessageAction, func(event Event, c *Client) error {
+		return nil
+	})
+
+	c := &Client{server: s, ID: 10}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, s.routeEvent(Event{Action: SendMessageAction}, c))
+		}
+	}()
+
+	next := func(next EventHandler) EventHandler {
+		return next
+	}
+
+	// the middlewares and handlers are registered while the events are routed
+	for i := 0; i < 100; i++ {
+		s.Use(next)
+		s.Handle(JoinRoomAction, func(event Event, c *Client) error {
+			return nil
+		})
+	}
+
+	<-done
+}
+
+func TestServerShutdown(t *testing.T) {
+	broker := wsMock.NewBroker(t)
+	events := wsMock.NewBroker(t)
+
+	for _, b := range []*wsMock.Broker{broker, events} {
+		b.
+			On("ReadMessage", mock.Anything, mock.AnythingOfType(mockAnythingOfTypeChanByte)).
+			Return().
+			Run(func(args mock.Arguments) {
+				close(args.Get(1).(chan<- []byte))
+			}).
+			Maybe()
+	}
+
+	sessionRepo := sessionMock.NewRepository(t)
+
+	sessionRepo.
+		On("CreateSession", mock.AnythingOfType("*entity.UserSession")).
+		Return(1, nil).
+		Once()
+
+	sessionRepo.
+		On("EndSession", 1, mock.AnythingOfType("time.Time"), false).
+		Return(nil).
+		Once()
+
+	userRepo := userMock.NewRepository(t)
+
+	userRepo.
+		On("FindByID", 10).
+		Return(&entity.User{ID: 10, Username: "user", DisplayName: "User"}, nil).
+		Once()
+
+	userRepo.
+		On("ListBlocks", 10).
+		Return(nil, nil).
+		Once()
+
+	s := &Server{
+		handlers: initEventHandlers(),
+		rooms:    newRoomCache(nil, rooms...),
+		clients:  make(map[*Client]bool),
+		join:     make(chan *Client),
+		leave:    make(chan *Client),
+		broker:   broker,
+		events:   events,
+		sessions: session.NewService(sessionRepo, nil, nil),
+		users:    newUserService(userRepo),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	joined := make(chan struct{}, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), auth.UserContextKey, &auth.Claims{ID: 10, Username: "user"})
+		s.ServeWS(w, r.WithContext(ctx))
+		joined <- struct{}{}
+	}))
+	defer srv.Close()
+
+	go s.Start(context.Background())
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	<-joined
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err = s.Shutdown(ctx)
+	assert.NoError(t, err)
+
+	_, msg, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"action":"serverShutdown","payload":{}}`, string(msg))
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+
+	// a new connection is refused
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestServerShutdownReaders(t *testing.T) {
+	s := &Server{
+		clients: make(map[*Client]bool),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	// the Start loop is done, a Client reader is still routing an event
+	close(s.done)
+	s.readers.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := s.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// no reader starts once the Server is closing
+	assert.False(t, s.startReader(nil))
+
+	go s.readers.Done()
+
+	err = s.Shutdown(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestServerRevokeSession(t *testing.T) {
+	sessionRepo := sessionMock.NewRepository(t)
+
+	sessionRepo.
+		On("CreateSession", mock.AnythingOfType("*entity.UserSession")).
+		Return(7, nil).
+		Once()
+
+	ended := make(chan struct{})
+	sessionRepo.
+		On("EndSession", 7, mock.AnythingOfType("time.Time"), false).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			close(ended)
+		}).
+		Once()
+
+	// the Client is connected with the username when the profile isn't loaded
+	userRepo := userMock.NewRepository(t)
+
+	userRepo.
+		On("FindByID", 10).
+		Return(nil, errors.New("db error")).
+		Once()
+
+	userRepo.
+		On("ListBlocks", 10).
+		Return(nil, errors.New("db error")).
+		Once()
+
+	s := &Server{
+		handlers: initEventHandlers(),
+		rooms:    newRoomCache(nil, rooms...),
+		clients:  make(map[*Client]bool),
+		join:     make(chan *Client),
+		leave:    make(chan *Client),
+		sessions: session.NewService(sessionRepo, nil, nil),
+		users:    newUserService(userRepo),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	joined := make(chan struct{}, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), auth.UserContextKey, &auth.Claims{ID: 10, Username: "user"})
+		s.ServeWS(w, r.WithContext(ctx))
+	}))
+	defer srv.Close()
+
+	// the Start loop isn't running, the joined Client is received here
+	go func() {
+		s.joinClient(<-s.join)
+		joined <- struct{}{}
+		s.leaveClient(<-s.leave)
+	}()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "?device=laptop"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	<-joined
+
+	// a revoked session of another user is ignored
+	s.handleSessionEvent(session.Event{Type: session.EventSessionRevoked, UserID: 20, SessionID: 7})
+	s.handleSessionEvent(session.Event{Type: session.EventSessionRevoked, UserID: 10, SessionID: 7})
+
+	_, msg, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"action":"sessionRevoked","payload":{}}`, string(msg))
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation))
+
+	select {
+	case <-ended:
+	case <-time.After(2 * time.Second):
+		t.Fatal("session not ended")
+	}
+}
+
+func TestServerHandleLoginEvent(t *testing.T) {
+	s := &Server{
+		clients: make(map[*Client]bool),
+	}
+
+	loggedOut := &Client{server: s, revoked: make(chan struct{}), ID: 10, LoginID: "login-1"}
+	other := &Client{server: s, revoked: make(chan struct{}), ID: 10, LoginID: "login-2"}
+
+	s.clients[loggedOut] = true
+	s.clients[other] = true
+
+	// the event is delivered twice by a redelivered message
+	e := token.Event{Type: token.EventLoginRevoked, UserID: 10, LoginID: "login-1"}
+	s.handleLoginEvent(e)
+	s.handleLoginEvent(e)
+
+	select {
+	case <-loggedOut.revoked:
+	default:
+		t.Fatal("session of the revoked login not revoked")
+	}
+
+	select {
+	case <-other.revoked:
+		t.Fatal("session of another login revoked")
+	default:
+	}
+}
+
+func TestServerHandleUserEvent(t *testing.T) {
+	var expected = Event{
+		Action:  ProfileUpdatedAction,
+		Payload: []byte(`{"userID":10,"username":"user","displayName":"User","avatarURL":"/avatars/10-a.png","statusText":"working"}`),
+	}
+
+	userRepo := userMock.NewRepository(t)
+
+	userRepo.
+		On("FindByID", 10).
+		Return(&entity.User{ID: 10, Username: "user", DisplayName: "User", Avatar: "10-a.png", StatusText: "working"}, nil).
+		Once()
+
+	s := &Server{
+		clients: make(map[*Client]bool),
+		users:   newUserService(userRepo),
+	}
+
+	updated := &Client{server: s, event: make(chan Event, 1), ID: 10, Username: "user", DisplayName: "user", RoomID: 1}
+	// every session of the user receives the profile
+	otherSession := &Client{server: s, event: make(chan Event, 1), ID: 10, Username: "user", DisplayName: "user"}
+	sameRoom := &Client{server: s, event: make(chan Event, 1), ID: 11, RoomID: 1}
+	otherRoom := &Client{server: s, event: make(chan Event, 1), ID: 12, RoomID: 2}
+
+	for _, c := range []*Client{updated, otherSession, sameRoom, otherRoom} {
+		s.joinClient(c)
+	}
+
+	// a user connected to another Server is ignored
+	s.handleUserEvent(user.Event{Type: user.EventProfileUpdated, UserID: 20})
+	s.handleUserEvent(user.Event{Type: user.EventProfileUpdated, UserID: 10})
+
+	assert.Equal(t, expected, <-updated.event)
+	assert.Equal(t, expected, <-otherSession.event)
+	assert.Equal(t, expected, <-sameRoom.event)
+	assert.Empty(t, otherRoom.event)
+
+	for _, c := range []*Client{updated, otherSession} {
+		assert.Equal(t, "User", c.DisplayName)
+		assert.Equal(t, "/avatars/10-a.png", c.AvatarURL)
+	}
+}
+
+func newUserService(repo user.Repository) user.UseCase {
+	return user.NewService(repo, nil, entity.DefaultPasswordPolicy, user.LoginThrottle{}, nil, nil)
+}
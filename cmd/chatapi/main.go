@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,9 +13,15 @@ import (
 	"github.com/vsantosalmeida/browser-chat/api/rest/handler"
 	"github.com/vsantosalmeida/browser-chat/api/websocket"
 	"github.com/vsantosalmeida/browser-chat/config"
+	"github.com/vsantosalmeida/browser-chat/entity"
 	"github.com/vsantosalmeida/browser-chat/infrastructure/broker"
 	"github.com/vsantosalmeida/browser-chat/infrastructure/repository"
+	"github.com/vsantosalmeida/browser-chat/usecase/filter"
+	"github.com/vsantosalmeida/browser-chat/usecase/moderation"
+	"github.com/vsantosalmeida/browser-chat/usecase/report"
 	"github.com/vsantosalmeida/browser-chat/usecase/room"
+	"github.com/vsantosalmeida/browser-chat/usecase/session"
+	"github.com/vsantosalmeida/browser-chat/usecase/token"
 	"github.com/vsantosalmeida/browser-chat/usecase/user"
 
 	"github.com/apex/log"
@@ -29,16 +36,86 @@ func main() {
 
 	ch := config.InitRabbitMQ()
 
-	// Setup User context
+	// events published to every chat-api instance
+	chatEvents := broker.NewRabbitMQFanout(config.GetStingEnvVarOrPanic(config.ChatEventsExchange), ch)
+
+	// JWT keys signing and verifying the user tokens
+	jwtKeys := config.InitJWTKeys()
+
+	// Setup User and Token context
 	userRepo := repository.NewUserMySQL(db)
-	userSvc := user.NewService(userRepo)
-	userHandler := handler.NewUserHandler(userSvc)
+	tokenRepo := repository.NewRefreshTokenMySQL(db)
+	tokenSvc := token.NewService(tokenRepo, userRepo, jwtKeys, chatEvents, token.Config{
+		AccessTTL:  time.Duration(config.GetIntEnvVarOrDefault(config.JWTAccessTTLSeconds, int(token.DefaultConfig.AccessTTL.Seconds()))) * time.Second,
+		RefreshTTL: time.Duration(config.GetIntEnvVarOrDefault(config.JWTRefreshTTLSeconds, int(token.DefaultConfig.RefreshTTL.Seconds()))) * time.Second,
+	})
+	lockout := time.Duration(config.GetIntEnvVarOrDefault(config.LoginLockoutSeconds, int(user.DefaultUsernameThrottle.LockoutDuration.Seconds()))) * time.Second
+	usernameThrottle := user.DefaultUsernameThrottle
+	usernameThrottle.FreeAttempts = config.GetIntEnvVarOrDefault(config.LoginUserFreeAttempts, usernameThrottle.FreeAttempts)
+	usernameThrottle.LockoutAttempts = config.GetIntEnvVarOrDefault(config.LoginUserLockoutAttempts, usernameThrottle.LockoutAttempts)
+	usernameThrottle.LockoutDuration = lockout
+	ipThrottle := user.DefaultIPThrottle
+	ipThrottle.FreeAttempts = config.GetIntEnvVarOrDefault(config.LoginIPFreeAttempts, ipThrottle.FreeAttempts)
+	ipThrottle.LockoutAttempts = config.GetIntEnvVarOrDefault(config.LoginIPLockoutAttempts, ipThrottle.LockoutAttempts)
+	ipThrottle.LockoutDuration = lockout
+	// the avatars are stored in the DB to be served by every chat-api instance
+	avatarRepo := repository.NewAvatarMySQL(db)
+	userSvc := user.NewService(userRepo, tokenSvc, config.InitPasswordPolicy(), user.NewLoginThrottle(usernameThrottle, ipThrottle), avatarRepo, chatEvents)
+	// the empty cookie name disables the token cookie
+	tokenCookie := config.GetStringEnvVarOrDefault(config.AuthCookieName, "access_token")
+	userHandler := handler.NewUserHandler(userSvc, tokenSvc, handler.TokenCookie{
+		Name:   tokenCookie,
+		Secure: config.GetBoolEnvVarOrDefault(config.AuthCookieSecure, false),
+	}, config.GetBoolEnvVarOrDefault(config.TrustProxyHeaders, false))
+	authenticated := midleware.AuthMiddleware(jwtKeys, tokenSvc, midleware.AuthConfig{
+		CookieName:      tokenCookie,
+		AllowQueryToken: config.GetBoolEnvVarOrDefault(config.AuthQueryToken, true),
+	})
+	keyHandler := handler.NewKeyHandler(jwtKeys)
+	avatarHandler := handler.NewAvatarHandler(userSvc)
 
 	// Setup Room context
 	roomRepo := repository.NewRoomMySQL(db)
-	roomSvc := room.NewService(roomRepo)
+	roomSvc := room.NewService(roomRepo, chatEvents)
 	roomHandler := handler.NewRoomHandler(roomSvc)
 
+	// Setup Moderation context
+	moderationRepo := repository.NewModerationMySQL(db)
+	moderationSvc := moderation.NewService(moderationRepo, roomSvc, chatEvents)
+	moderationHandler := handler.NewModerationHandler(moderationSvc)
+
+	// Setup Report context
+	reportRepo := repository.NewReportMySQL(db)
+	reportSvc := report.NewService(reportRepo, roomSvc, moderationSvc)
+	reportHandler := handler.NewReportHandler(reportSvc)
+
+	// Setup Session context
+	sessionRepo := repository.NewSessionMySQL(db)
+	sessionSvc := session.NewService(sessionRepo, tokenSvc, chatEvents)
+	sessionHandler := handler.NewSessionHandler(sessionSvc)
+
+	// Setup message persistence
+	persister := room.NewPersister(roomRepo, room.PersisterConfig{
+		QueueSize:      config.GetIntEnvVarOrDefault(config.MessageQueueSize, room.DefaultPersisterConfig.QueueSize),
+		BatchSize:      config.GetIntEnvVarOrDefault(config.MessageBatchSize, room.DefaultPersisterConfig.BatchSize),
+		FlushInterval:  time.Duration(config.GetIntEnvVarOrDefault(config.MessageFlushIntervalMs, int(room.DefaultPersisterConfig.FlushInterval.Milliseconds()))) * time.Millisecond,
+		MaxRetries:     config.GetIntEnvVarOrDefault(config.MessageMaxRetries, room.DefaultPersisterConfig.MaxRetries),
+		RetryBackoff:   room.DefaultPersisterConfig.RetryBackoff,
+		EnqueueTimeout: room.DefaultPersisterConfig.EnqueueTimeout,
+	})
+	persister.Start()
+	expvar.Publish("messagePersister", expvar.Func(func() interface{} {
+		return persister.Stats()
+	}))
+
+	// Setup message content filters, the room blocked patterns reject a message before its links are checked
+	// and the profanities masked
+	filterSvc := filter.NewService(
+		filter.NewPatternFilter(),
+		filter.NewLinkFilter(config.GetListEnvVar(config.FilterLinkAllow), config.GetListEnvVar(config.FilterLinkDeny)),
+		filter.NewProfanityFilter(config.GetListEnvVar(config.FilterProfanityWords)),
+	)
+
 	// Setup WebSocket context
 	rabbitMQ := broker.NewRabbitMQ(
 		config.GetStingEnvVarOrPanic(config.ChatbotCommandOutputQueue), // read queue
@@ -46,31 +123,82 @@ func main() {
 		ch,
 	)
 	ctx, cancel := context.WithCancel(context.Background())
-	wsServer := websocket.NewServer(roomSvc, rabbitMQ)
+	wsServer := websocket.NewServer(roomSvc, moderationSvc, reportSvc, sessionSvc, userSvc, rabbitMQ, chatEvents, persister, filterSvc, websocket.RateLimitConfig{
+		EventsPerSecond: config.GetIntEnvVarOrDefault(config.WSEventsPerSecond, websocket.DefaultEventsPerSecond),
+		Burst:           config.GetIntEnvVarOrDefault(config.WSEventsBurst, websocket.DefaultEventsBurst),
+	}, websocket.PresenceConfig{
+		IdleAfter: time.Duration(config.GetIntEnvVarOrDefault(config.WSIdleSeconds, int(websocket.DefaultIdleAfter.Seconds()))) * time.Second,
+		AwayAfter: time.Duration(config.GetIntEnvVarOrDefault(config.WSAwaySeconds, int(websocket.DefaultAwayAfter.Seconds()))) * time.Second,
+	}, config.GetBoolEnvVarOrDefault(config.TrustProxyHeaders, false))
+
+	expvar.Publish("websocketEvents", expvar.Func(func() interface{} {
+		return wsServer.Stats()
+	}))
 
 	go wsServer.Start(ctx)
 
-	// Setup HTTP handlers
+	// Setup HTTP handlers, only the sign up, login, refresh, key and avatar routes are public
 	r := mux.NewRouter()
 	r.HandleFunc("/users", userHandler.HandleCreateUser).Methods(http.MethodPost)
-	r.HandleFunc("/users", userHandler.HandleListUsers).Methods(http.MethodGet)
 	r.HandleFunc("/users/login", userHandler.HandleLogin).Methods(http.MethodPost)
-
-	r.HandleFunc("/rooms", roomHandler.HandleCreateRoom).Methods(http.MethodPost)
-	r.HandleFunc("/rooms/{id}/messages", roomHandler.HandleListMessages).Methods(http.MethodGet)
-	r.HandleFunc("/rooms", roomHandler.HandleListRooms).Methods(http.MethodGet)
-
-	r.HandleFunc("/ws", midleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		wsServer.ServeWS(w, r)
-	}))
-
-	r.Use(midleware.Cors)
-
-	fs := http.FileServer(http.Dir("./templates"))
-	http.Handle("/", fs)
+	r.HandleFunc("/users/refresh", userHandler.HandleRefresh).Methods(http.MethodPost)
+	r.HandleFunc("/.well-known/jwks.json", keyHandler.HandleJWKS).Methods(http.MethodGet)
+	// the avatars are loaded by img tags, which can't send the token
+	r.HandleFunc(entity.AvatarURLPrefix+"{name}", avatarHandler.HandleGetAvatar).Methods(http.MethodGet)
+
+	// every other route requires an authenticated user
+	api := r.NewRoute().Subrouter()
+	api.Use(authenticated)
+
+	admin := midleware.RequireRole(entity.UserRoleAdmin)
+	api.Handle("/users", admin(http.HandlerFunc(userHandler.HandleListUsers))).Methods(http.MethodGet)
+	api.Handle("/debug/vars", admin(expvar.Handler())).Methods(http.MethodGet)
+
+	api.HandleFunc("/users/logout", userHandler.HandleLogout).Methods(http.MethodPost)
+	api.HandleFunc("/users/me/password", userHandler.HandleChangePassword).Methods(http.MethodPut)
+	api.HandleFunc("/users/me", userHandler.HandleGetMe).Methods(http.MethodGet)
+	api.HandleFunc("/users/me", userHandler.HandleUpdateMe).Methods(http.MethodPatch)
+	api.HandleFunc("/users/me/avatar", userHandler.HandleUploadAvatar).Methods(http.MethodPut)
+	api.HandleFunc("/users/me/status", userHandler.HandleSetStatus).Methods(http.MethodPut)
+	api.HandleFunc("/users/me/blocks", userHandler.HandleListBlocks).Methods(http.MethodGet)
+	api.HandleFunc("/users/me/blocks/{id:[0-9]+}", userHandler.HandleBlockUser).Methods(http.MethodPut)
+	api.HandleFunc("/users/me/blocks/{id:[0-9]+}", userHandler.HandleUnblockUser).Methods(http.MethodDelete)
+	api.HandleFunc("/users/{id:[0-9]+}", userHandler.HandleGetUser).Methods(http.MethodGet)
+	api.HandleFunc("/rooms", roomHandler.HandleCreateRoom).Methods(http.MethodPost)
+	api.HandleFunc("/rooms/{id}/messages", roomHandler.HandleListMessages).Methods(http.MethodGet)
+	api.HandleFunc("/rooms", roomHandler.HandleListRooms).Methods(http.MethodGet)
+	api.HandleFunc("/rooms/{id}", roomHandler.HandleGetRoom).Methods(http.MethodGet)
+	api.HandleFunc("/rooms/{id}", roomHandler.HandleUpdateRoom).Methods(http.MethodPatch)
+	api.HandleFunc("/rooms/{id}", roomHandler.HandleDeleteRoom).Methods(http.MethodDelete)
+	api.HandleFunc("/rooms/{id}/archive", roomHandler.HandleArchiveRoom).Methods(http.MethodPost)
+	api.HandleFunc("/rooms/{id}/members", roomHandler.HandleListMembers).Methods(http.MethodGet)
+	api.HandleFunc("/rooms/{id}/members/{userID}", roomHandler.HandleSetMemberRole).Methods(http.MethodPut)
+	api.HandleFunc("/rooms/{id}/invitations", roomHandler.HandleInviteUser).Methods(http.MethodPost)
+	api.HandleFunc("/rooms/{id}/kick", moderationHandler.HandleKick).Methods(http.MethodPost)
+	api.HandleFunc("/rooms/{id}/bans", moderationHandler.HandleBan).Methods(http.MethodPost)
+	api.HandleFunc("/rooms/{id}/bans/{userID}", moderationHandler.HandleUnban).Methods(http.MethodDelete)
+	api.HandleFunc("/rooms/{id}/mutes", moderationHandler.HandleMute).Methods(http.MethodPost)
+	api.HandleFunc("/rooms/{id}/mutes/{userID}", moderationHandler.HandleUnmute).Methods(http.MethodDelete)
+	api.HandleFunc("/rooms/{id}/sanctions", moderationHandler.HandleListSanctions).Methods(http.MethodGet)
+	api.HandleFunc("/rooms/{id}/reports", reportHandler.HandleListReports).Methods(http.MethodGet)
+	api.HandleFunc("/messages/{id}/reports", reportHandler.HandleReportMessage).Methods(http.MethodPost)
+	api.HandleFunc("/reports/{id}", reportHandler.HandleGetReport).Methods(http.MethodGet)
+	api.HandleFunc("/reports/{id}/resolve", reportHandler.HandleResolveReport).Methods(http.MethodPost)
+	api.HandleFunc("/users/me/invitations", roomHandler.HandleListInvitations).Methods(http.MethodGet)
+	api.HandleFunc("/users/me/sessions", sessionHandler.HandleListSessions).Methods(http.MethodGet)
+	api.HandleFunc("/users/me/sessions/{id}", sessionHandler.HandleRevokeSession).Methods(http.MethodDelete)
+	api.HandleFunc("/invitations/{id}/accept", roomHandler.HandleAcceptInvitation).Methods(http.MethodPost)
+	api.HandleFunc("/invitations/{id}/decline", roomHandler.HandleDeclineInvitation).Methods(http.MethodPost)
+
+	api.HandleFunc("/ws", wsServer.ServeWS)
+
+	// the templates are served on a dedicated mux, the DefaultServeMux exposes /debug/vars
+	templates := http.NewServeMux()
+	templates.Handle("/", http.FileServer(http.Dir("./templates")))
 
 	srv := &http.Server{
-		Handler:      r,
+		// wraps the whole router, the preflight requests don't match any route
+		Handler:      midleware.Cors(r),
 		WriteTimeout: 15 * time.Second,
 		ReadTimeout:  15 * time.Second,
 		Addr:         ":8080",
@@ -83,7 +211,7 @@ func main() {
 	}()
 
 	go func() {
-		if err := http.ListenAndServe(":3000", nil); !errors.Is(err, http.ErrServerClosed) {
+		if err := http.ListenAndServe(":3000", templates); !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("unexpected server error: %v", err)
 		}
 	}()
@@ -95,10 +223,24 @@ func main() {
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM)
 	<-sc
 
+	shutdownTimeout := time.Duration(config.GetIntEnvVarOrDefault(config.ShutdownTimeoutSeconds, 15)) * time.Second
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+
+	// close the websocket clients first, their readers are waited so no message is queued after it
+	if err := wsServer.Shutdown(shutdownCtx); err != nil {
+		log.WithError(err).Error("could not close websocket clients")
+	}
+
 	cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("server shutdown error: %v", err)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.WithError(err).Error("server shutdown error")
+	}
+
+	// flush the queued messages before stopping
+	if err := persister.Close(shutdownCtx); err != nil {
+		log.WithError(err).Error("could not flush message queue")
 	}
 
 	log.Info("server stopped")
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	jwtAlgorithmHS256 = "HS256"
+	jwtAlgorithmRS256 = "RS256"
+	jwtAlgorithmEdDSA = "EdDSA"
+
+	defaultJWTKeyID = "default"
+)
+
+// InitJWTKeys loads the key signing the JWT tokens and the previous keys still accepted to verify them.
+//
+// a HS256 key is built from JWT_SECRET, a RS256 or EdDSA key from the JWT_PRIVATE_KEY_FILE PEM file.
+// JWT_PREVIOUS_KEYS lists the rotated keys as kid=secret for HS256 or kid=public key PEM file otherwise.
+func InitJWTKeys() *auth.KeySet {
+	algorithm := os.Getenv(string(JWTAlgorithm))
+	if algorithm == "" {
+		algorithm = jwtAlgorithmHS256
+	}
+
+	keyID := os.Getenv(string(JWTKeyID))
+	if keyID == "" {
+		keyID = defaultJWTKeyID
+	}
+
+	signing, err := loadSigningKey(algorithm, keyID)
+	if err != nil {
+		log.WithError(err).WithField("Algorithm", algorithm).Fatal("failed to load JWT signing key")
+	}
+
+	var previous []*auth.Key
+	for _, entry := range GetListEnvVar(JWTPreviousKeys) {
+		key, err := loadPreviousKey(algorithm, entry)
+		if err != nil {
+			log.WithError(err).Fatal("failed to load JWT previous key")
+		}
+
+		previous = append(previous, key)
+	}
+
+	keys, err := auth.NewKeySet(signing, previous...)
+	if err != nil {
+		log.WithError(err).Fatal("failed to build JWT key set")
+	}
+
+	log.WithFields(log.Fields{
+		"Algorithm": algorithm,
+		"KeyID":     keyID,
+		"Previous":  len(previous),
+	}).Info("JWT keys loaded")
+
+	return keys
+}
+
+func loadSigningKey(algorithm, keyID string) (*auth.Key, error) {
+	switch algorithm {
+	case jwtAlgorithmHS256:
+		return auth.NewHMACKey(keyID, []byte(GetStingEnvVarOrPanic(JWTSecret)))
+	case jwtAlgorithmRS256, jwtAlgorithmEdDSA:
+		data, err := os.ReadFile(GetStingEnvVarOrPanic(JWTPrivateKeyFile))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read private key file")
+		}
+
+		key, err := auth.ParsePrivateKey(keyID, data)
+		if err != nil {
+			return nil, err
+		}
+
+		if key.Method.Alg() != algorithm {
+			return nil, errors.Errorf("private key doesn't match the %s algorithm", algorithm)
+		}
+
+		return key, nil
+	default:
+		return nil, errors.Errorf("unsupported algorithm %s", algorithm)
+	}
+}
+
+func loadPreviousKey(algorithm, entry string) (*auth.Key, error) {
+	keyID, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+	if !ok || keyID == "" || value == "" {
+		return nil, errors.Errorf("invalid previous key %q, expected kid=value", keyID)
+	}
+
+	if algorithm == jwtAlgorithmHS256 {
+		return auth.NewHMACKey(keyID, []byte(value))
+	}
+
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read public key file")
+	}
+
+	return auth.ParsePublicKey(keyID, data)
+}
+
+// InitPasswordPolicy loads the password policy, PASSWORD_BLOCKLIST_FILE lists a blocked password by line
+// and BCRYPT_COST must be a valid bcrypt cost. The hashes of a lower cost are upgraded on login.
+func InitPasswordPolicy() entity.PasswordPolicy {
+	policy := entity.PasswordPolicy{
+		MinLength:  GetIntEnvVarOrDefault(PasswordMinLength, entity.DefaultPasswordPolicy.MinLength),
+		MinClasses: GetIntEnvVarOrDefault(PasswordMinClasses, entity.DefaultPasswordPolicy.MinClasses),
+		Cost:       GetIntEnvVarOrDefault(BcryptCost, entity.DefaultPasswordPolicy.Cost),
+	}
+
+	if policy.Cost < bcrypt.MinCost || policy.Cost > bcrypt.MaxCost {
+		log.WithField("Cost", policy.Cost).Fatalf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	if path := os.Getenv(string(PasswordBlocklistFile)); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			log.WithError(err).Fatal("failed to read password blocklist")
+		}
+
+		for _, line := range strings.Split(string(b), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				policy.Blocklist = append(policy.Blocklist, line)
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"MinLength":  policy.MinLength,
+		"MinClasses": policy.MinClasses,
+		"Blocklist":  len(policy.Blocklist),
+		"Cost":       policy.Cost,
+	}).Info("password policy loaded")
+
+	return policy
+}
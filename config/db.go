@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/vsantosalmeida/browser-chat/entity"
@@ -8,12 +9,14 @@ import (
 	"github.com/apex/log"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
 const dsnPattern = "%s:%s@tcp(%s:3306)/%s?charset=utf8mb4&parseTime=True&loc=Local"
 
-// InitDB create the connection with DB and migrate the user, room and message tables.
+// InitDB create the connection with DB and migrate the user, room, message, member, invitation, sanction, report, report audit,
+// user session and refresh token tables.
 func InitDB() *gorm.DB {
 	dsn := fmt.Sprintf(
 		dsnPattern,
@@ -24,6 +27,8 @@ func InitDB() *gorm.DB {
 	)
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
+		// translates the DB errors, e.g. duplicated key to gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 	if err != nil {
 		log.WithError(err).Fatal("failed to open db connection")
@@ -33,6 +38,10 @@ func InitDB() *gorm.DB {
 		log.WithError(err).Fatal("failed to migrate user table")
 	}
 
+	if err = backfillRoomNames(db); err != nil {
+		log.WithError(err).Fatal("failed to backfill room names")
+	}
+
 	if err = db.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(&entity.Room{}); err != nil {
 		log.WithError(err).Fatal("failed to migrate room table")
 	}
@@ -41,5 +50,96 @@ func InitDB() *gorm.DB {
 		log.WithError(err).Fatal("failed to migrate message table")
 	}
 
+	if err = db.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(&entity.RoomMember{}, &entity.RoomInvitation{}, &entity.RoomSanction{}); err != nil {
+		log.WithError(err).Fatal("failed to migrate room member, invitation and sanction tables")
+	}
+
+	if err = backfillRoomOwners(db); err != nil {
+		log.WithError(err).Fatal("failed to backfill room owners")
+	}
+
+	if err = db.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(&entity.MessageReport{}, &entity.ReportAuditEntry{}); err != nil {
+		log.WithError(err).Fatal("failed to migrate message report and audit tables")
+	}
+
+	if err = db.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(&entity.UserSession{}, &entity.RefreshToken{}); err != nil {
+		log.WithError(err).Fatal("failed to migrate user session and refresh token tables")
+	}
+
+	if err = db.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(&entity.UserBlock{}); err != nil {
+		log.WithError(err).Fatal("failed to migrate user block table")
+	}
+
+	if err = db.Set("gorm:table_options", "ENGINE=InnoDB").AutoMigrate(&entity.Avatar{}); err != nil {
+		log.WithError(err).Fatal("failed to migrate avatar table")
+	}
+
 	return db
 }
+
+// backfillRoomNames gives a unique name to the rooms created before the name column existed,
+// it must run before the unique name index is created.
+func backfillRoomNames(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&entity.Room{}) || m.HasColumn(&entity.Room{}, "Name") {
+		return nil
+	}
+
+	if err := m.AddColumn(&entity.Room{}, "Name"); err != nil {
+		return err
+	}
+
+	return db.Exec("UPDATE rooms SET name = CONCAT('room-', id)").Error
+}
+
+// backfillRoomOwners gives the rooms created before the owner was recorded to the first admin,
+// the admin becomes the room owner member. The rooms stay without owner until an admin exists.
+func backfillRoomOwners(db *gorm.DB) error {
+	var rooms []int
+	if err := db.Model(&entity.Room{}).Where("creator_id = 0").Pluck("id", &rooms).Error; err != nil {
+		return err
+	}
+
+	if len(rooms) == 0 {
+		return nil
+	}
+
+	var admin entity.User
+	if err := db.Where("role = ?", entity.UserRoleAdmin).Order("id").First(&admin).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.WithField("Rooms", len(rooms)).Warn("no admin to own the rooms without owner")
+			return nil
+		}
+		return err
+	}
+
+	members := make([]*entity.RoomMember, 0, len(rooms))
+	for _, id := range rooms {
+		members = append(members, &entity.RoomMember{
+			RoomID: id,
+			UserID: admin.ID,
+			Role:   entity.RoleOwner,
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Room{}).Where("id IN ?", rooms).Update("creator_id", admin.ID).Error; err != nil {
+			return err
+		}
+
+		// the admin may already be a member of the room
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{"role": entity.RoleOwner}),
+		}).Create(&members).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"Rooms":   len(rooms),
+		"OwnerID": admin.ID,
+	}).Info("rooms without owner given to the first admin")
+
+	return nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/apex/log"
 )
@@ -14,6 +15,8 @@ const (
 	ChatbotCommandOutputQueue EnvVar = "CHATBOT_COMMAND_OUTPUT_QUEUE"
 	ChatbotMaxWorkers         EnvVar = "CHATBOT_MAX_WORKERS"
 
+	ChatEventsExchange EnvVar = "CHAT_EVENTS_EXCHANGE"
+
 	RabbitMQUser EnvVar = "RABBITMQ_USER"
 	RabbitMQPass EnvVar = "RABBITMQ_PASS"
 	RabbitMQHost EnvVar = "RABBITMQ_HOST"
@@ -22,6 +25,47 @@ const (
 	MySqlUser EnvVar = "MYSQL_USER"
 	MySqlDB   EnvVar = "MYSQL_DATABASE"
 	MySqlHost EnvVar = "MYSQL_HOST"
+
+	MessageQueueSize       EnvVar = "MESSAGE_QUEUE_SIZE"
+	MessageBatchSize       EnvVar = "MESSAGE_BATCH_SIZE"
+	MessageFlushIntervalMs EnvVar = "MESSAGE_FLUSH_INTERVAL_MS"
+	MessageMaxRetries      EnvVar = "MESSAGE_MAX_RETRIES"
+
+	WSEventsPerSecond EnvVar = "WS_EVENTS_PER_SECOND"
+	WSEventsBurst     EnvVar = "WS_EVENTS_BURST"
+	WSIdleSeconds     EnvVar = "WS_IDLE_SECONDS"
+	WSAwaySeconds     EnvVar = "WS_AWAY_SECONDS"
+
+	ShutdownTimeoutSeconds EnvVar = "SHUTDOWN_TIMEOUT_SECONDS"
+
+	FilterProfanityWords EnvVar = "FILTER_PROFANITY_WORDS"
+	FilterLinkAllow      EnvVar = "FILTER_LINK_ALLOW"
+	FilterLinkDeny       EnvVar = "FILTER_LINK_DENY"
+
+	JWTAlgorithm      EnvVar = "JWT_ALGORITHM"
+	JWTKeyID          EnvVar = "JWT_KEY_ID"
+	JWTSecret         EnvVar = "JWT_SECRET"
+	JWTPrivateKeyFile EnvVar = "JWT_PRIVATE_KEY_FILE"
+	JWTPreviousKeys   EnvVar = "JWT_PREVIOUS_KEYS"
+
+	JWTAccessTTLSeconds  EnvVar = "JWT_ACCESS_TTL_SECONDS"
+	JWTRefreshTTLSeconds EnvVar = "JWT_REFRESH_TTL_SECONDS"
+
+	AuthCookieName   EnvVar = "AUTH_COOKIE_NAME"
+	AuthCookieSecure EnvVar = "AUTH_COOKIE_SECURE"
+	AuthQueryToken   EnvVar = "AUTH_QUERY_TOKEN"
+
+	PasswordMinLength     EnvVar = "PASSWORD_MIN_LENGTH"
+	PasswordMinClasses    EnvVar = "PASSWORD_MIN_CLASSES"
+	PasswordBlocklistFile EnvVar = "PASSWORD_BLOCKLIST_FILE"
+	BcryptCost            EnvVar = "BCRYPT_COST"
+
+	LoginUserFreeAttempts    EnvVar = "LOGIN_USER_FREE_ATTEMPTS"
+	LoginUserLockoutAttempts EnvVar = "LOGIN_USER_LOCKOUT_ATTEMPTS"
+	LoginIPFreeAttempts      EnvVar = "LOGIN_IP_FREE_ATTEMPTS"
+	LoginIPLockoutAttempts   EnvVar = "LOGIN_IP_LOCKOUT_ATTEMPTS"
+	LoginLockoutSeconds      EnvVar = "LOGIN_LOCKOUT_SECONDS"
+	TrustProxyHeaders        EnvVar = "TRUST_PROXY_HEADERS"
 )
 
 func GetStingEnvVarOrPanic(env EnvVar) string {
@@ -44,3 +88,32 @@ func GetIntEnvVarOrDefault(env EnvVar, d int) int {
 
 	return intv
 }
+
+func GetBoolEnvVarOrDefault(env EnvVar, d bool) bool {
+	v := os.Getenv(string(env))
+
+	boolv, err := strconv.ParseBool(v)
+	if err != nil {
+		return d
+	}
+
+	return boolv
+}
+
+func GetStringEnvVarOrDefault(env EnvVar, d string) string {
+	v, ok := os.LookupEnv(string(env))
+	if !ok {
+		return d
+	}
+
+	return v
+}
+
+func GetListEnvVar(env EnvVar) []string {
+	v := os.Getenv(string(env))
+	if v == "" {
+		return nil
+	}
+
+	return strings.Split(v, ",")
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Avatar represents an avatar image uploaded by a User stored in the DB, shared by every chat-api instance.
+// the Name is unique for each upload and the Data is never changed.
+type Avatar struct {
+	Name        string `gorm:"primaryKey;size:100"`
+	UserID      int    `gorm:"index"`
+	ContentType string `gorm:"size:50"`
+	Data        []byte `gorm:"type:mediumblob"`
+	CreatedAt   time.Time
+}
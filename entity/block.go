@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// UserBlock represents a User blocked by another User stored in the DB,
+// the messages of the Blocked user are hidden from the user.
+type UserBlock struct {
+	UserID    int  `gorm:"primaryKey;autoIncrement:false"`
+	BlockedID int  `gorm:"primaryKey;autoIncrement:false;index"`
+	Blocked   User `gorm:"foreignKey:BlockedID"`
+	CreatedAt time.Time
+}
+
+// NewUserBlock UserBlock builder, a user can't block itself.
+func NewUserBlock(userID, blockedID int) (*UserBlock, error) {
+	if userID == 0 || blockedID == 0 {
+		return nil, ErrInvalidEntity
+	}
+
+	if userID == blockedID {
+		return nil, ErrBlockSelf
+	}
+
+	return &UserBlock{
+		UserID:    userID,
+		BlockedID: blockedID,
+	}, nil
+}
@@ -2,8 +2,183 @@ package entity
 
 import "github.com/pkg/errors"
 
+// ErrorKind category of a domain Error, the transports map it to their own errors, e.g. to an HTTP status.
+type ErrorKind int
+
+const (
+	// KindInternal unexpected error, e.g. a DB error, its message isn't shown to the user.
+	KindInternal ErrorKind = iota
+	// KindInvalid the input doesn't follow the domain rules.
+	KindInvalid
+	// KindUnauthorized the user credentials or token are invalid.
+	KindUnauthorized
+	// KindForbidden the user isn't allowed to do the action.
+	KindForbidden
+	// KindNotFound the entity doesn't exist.
+	KindNotFound
+	// KindConflict the action conflicts with the entity state, e.g. a unique name already in use.
+	KindConflict
+	// KindTooManyRequests the user must wait before retrying.
+	KindTooManyRequests
+)
+
+// Error domain error of a Kind, compared by identity with errors.Is.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewInvalidError builds an Error of KindInvalid.
+func NewInvalidError(message string) error {
+	return &Error{Kind: KindInvalid, Message: message}
+}
+
+// NewUnauthorizedError builds an Error of KindUnauthorized.
+func NewUnauthorizedError(message string) error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+// NewForbiddenError builds an Error of KindForbidden.
+func NewForbiddenError(message string) error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+// NewNotFoundError builds an Error of KindNotFound.
+func NewNotFoundError(message string) error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+// NewConflictError builds an Error of KindConflict.
+func NewConflictError(message string) error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+// NewTooManyRequestsError builds an Error of KindTooManyRequests.
+func NewTooManyRequestsError(message string) error {
+	return &Error{Kind: KindTooManyRequests, Message: message}
+}
+
+// AsError returns the first domain Error wrapped by err, a KindInternal Error otherwise.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	return &Error{Kind: KindInternal, Message: "internal error"}
+}
+
 // ErrInvalidEntity invalid entity
-var ErrInvalidEntity = errors.New("invalid entity")
+var ErrInvalidEntity = NewInvalidError("invalid entity")
 
 // ErrInvalidPassword invalid password
-var ErrInvalidPassword = errors.New("invalid password")
+var ErrInvalidPassword = NewUnauthorizedError("invalid password")
+
+// ErrWrongPassword wrong current password of an authenticated user
+var ErrWrongPassword = NewForbiddenError("wrong password")
+
+// ErrEmptyMessage empty message
+var ErrEmptyMessage = NewInvalidError("empty message")
+
+// ErrMessageTooLong message too long
+var ErrMessageTooLong = NewInvalidError("message too long")
+
+// ErrInvalidMessageContent invalid message content
+var ErrInvalidMessageContent = NewInvalidError("invalid message content")
+
+// ErrRoomNotFound room not found
+var ErrRoomNotFound = NewNotFoundError("room not found")
+
+// ErrRoomNameTaken room name already in use
+var ErrRoomNameTaken = NewConflictError("room name already in use")
+
+// ErrRoomArchived room archived
+var ErrRoomArchived = NewConflictError("room archived")
+
+// ErrForbidden action not allowed for the user
+var ErrForbidden = NewForbiddenError("forbidden")
+
+// ErrMemberNotFound room member not found
+var ErrMemberNotFound = NewNotFoundError("room member not found")
+
+// ErrAlreadyMember user is already a room member
+var ErrAlreadyMember = NewConflictError("user is already a room member")
+
+// ErrInvitationNotFound invitation not found
+var ErrInvitationNotFound = NewNotFoundError("invitation not found")
+
+// ErrInvitationNotPending invitation was already answered
+var ErrInvitationNotPending = NewConflictError("invitation is not pending")
+
+// ErrUserBanned user banned from the room
+var ErrUserBanned = NewForbiddenError("user banned from the room")
+
+// ErrUserMuted user muted in the room
+var ErrUserMuted = NewForbiddenError("user muted in the room")
+
+// ErrSlowMode message sent before the room slow mode interval elapsed
+var ErrSlowMode = NewTooManyRequestsError("slow mode is enabled, wait before sending another message")
+
+// ErrMessageRejected message rejected by a content filter
+var ErrMessageRejected = NewInvalidError("message rejected by content filter")
+
+// ErrMessageNotFound message not found
+var ErrMessageNotFound = NewNotFoundError("message not found")
+
+// ErrReportNotFound report not found
+var ErrReportNotFound = NewNotFoundError("report not found")
+
+// ErrReportNotOpen report was already reviewed
+var ErrReportNotOpen = NewConflictError("report is not open")
+
+// ErrSessionNotFound session not found
+var ErrSessionNotFound = NewNotFoundError("session not found")
+
+// ErrInvalidToken invalid, expired or revoked token
+var ErrInvalidToken = NewUnauthorizedError("invalid or expired token")
+
+// ErrUserNotFound user not found
+var ErrUserNotFound = NewNotFoundError("user not found")
+
+// ErrPasswordTooShort password shorter than the policy minimum length
+var ErrPasswordTooShort = NewInvalidError("password too short")
+
+// ErrPasswordTooLong password longer than 72 bytes, bcrypt ignores the remaining bytes
+var ErrPasswordTooLong = NewInvalidError("password too long")
+
+// ErrPasswordTooSimple password without the policy required character classes
+var ErrPasswordTooSimple = NewInvalidError("password must mix lowercase, uppercase, digits or symbols")
+
+// ErrPasswordCommon password is a common password or the username
+var ErrPasswordCommon = NewInvalidError("password too common")
+
+// ErrTooManyAttempts too many failed login attempts
+var ErrTooManyAttempts = NewTooManyRequestsError("too many failed attempts")
+
+// ErrUsernameTaken username already in use
+var ErrUsernameTaken = NewConflictError("username already in use")
+
+// ErrInvalidDisplayName display name too long or with control characters
+var ErrInvalidDisplayName = NewInvalidError("display name must have up to 50 characters without line breaks")
+
+// ErrInvalidBio bio too long or with control characters
+var ErrInvalidBio = NewInvalidError("bio must have up to 500 characters")
+
+// ErrInvalidStatusText status text too long or with control characters
+var ErrInvalidStatusText = NewInvalidError("status text must have up to 100 characters without line breaks")
+
+// ErrInvalidStatus status a user can't set
+var ErrInvalidStatus = NewInvalidError("status must be online, away or dnd")
+
+// ErrBlockSelf user blocking itself
+var ErrBlockSelf = NewInvalidError("users can't block themselves")
+
+// ErrInvalidAvatar avatar isn't a supported image or is too large
+var ErrInvalidAvatar = NewInvalidError("avatar must be a PNG, JPEG, GIF or WebP image up to 1MB")
+
+// ErrAvatarNotFound avatar not found
+var ErrAvatarNotFound = NewNotFoundError("avatar not found")
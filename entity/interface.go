@@ -5,4 +5,6 @@ package entity
 type AuthenticatedUser interface {
 	GetId() int
 	GetUsername() string
+	GetLoginID() string
+	GetRole() string
 }
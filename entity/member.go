@@ -0,0 +1,52 @@
+package entity
+
+import "time"
+
+const (
+	// InvitationPending invitation waiting for the invitee answer.
+	InvitationPending = "pending"
+	// InvitationAccepted invitation accepted, the invitee is a room member.
+	InvitationAccepted = "accepted"
+	// InvitationDeclined invitation declined by the invitee.
+	InvitationDeclined = "declined"
+)
+
+// RoomMember represents a User membership in a Room stored in the DB.
+type RoomMember struct {
+	RoomID    int    `gorm:"primaryKey;autoIncrement:false"`
+	UserID    int    `gorm:"primaryKey;autoIncrement:false;index"`
+	Role      string `gorm:"size:20;default:member"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// RoomInvitation represents an invitation to join a private Room stored in the DB.
+type RoomInvitation struct {
+	ID        int `gorm:"primaryKey"`
+	RoomID    int `gorm:"index"`
+	Room      Room
+	InviterID int
+	InviteeID int    `gorm:"index"`
+	Status    string `gorm:"size:20"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewRoomInvitation RoomInvitation builder.
+func NewRoomInvitation(roomID, inviterID, inviteeID int) (*RoomInvitation, error) {
+	if roomID == 0 || inviteeID == 0 || inviterID == inviteeID {
+		return nil, ErrInvalidEntity
+	}
+
+	return &RoomInvitation{
+		RoomID:    roomID,
+		InviterID: inviterID,
+		InviteeID: inviteeID,
+		Status:    InvitationPending,
+	}, nil
+}
+
+// IsPending returns true if the invitation wasn't answered yet.
+func (i *RoomInvitation) IsPending() bool {
+	return i.Status == InvitationPending
+}
@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPasswordBytes bcrypt only hashes the first 72 bytes.
+const maxPasswordBytes = 72
+
+// commonPasswords built-in blocklist, extended by PasswordPolicy.Blocklist.
+var commonPasswords = []string{
+	"123456", "12345678", "123456789", "1234567890", "111111", "000000", "123123", "654321",
+	"password", "password1", "password123", "passw0rd", "qwerty", "qwerty123", "qwertyuiop", "abc123",
+	"letmein", "welcome", "welcome1", "admin", "admin123", "iloveyou", "monkey", "dragon",
+	"football", "baseball", "sunshine", "princess", "master", "trustno1", "changeme", "secret",
+}
+
+// PasswordPolicy rules a new password must follow and the bcrypt cost of its hash.
+//
+// MinClasses is the number of character classes, lowercase, uppercase, digits and symbols,
+// the password must mix. The Blocklist passwords are rejected besides the built-in common passwords,
+// the comparison ignores the case.
+type PasswordPolicy struct {
+	MinLength  int
+	MinClasses int
+	Blocklist  []string
+	Cost       int
+}
+
+// DefaultPasswordPolicy policy used when not configured.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:  8,
+	MinClasses: 2,
+	Cost:       bcrypt.DefaultCost,
+}
+
+// Validate checks the password against the policy, the password can't be the username.
+func (p PasswordPolicy) Validate(username, password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return ErrPasswordTooShort
+	}
+
+	if len(password) > maxPasswordBytes {
+		return ErrPasswordTooLong
+	}
+
+	if passwordClasses(password) < p.MinClasses {
+		return ErrPasswordTooSimple
+	}
+
+	if strings.EqualFold(password, username) || p.isBlocked(password) {
+		return ErrPasswordCommon
+	}
+
+	return nil
+}
+
+func (p PasswordPolicy) isBlocked(password string) bool {
+	for _, blocked := range commonPasswords {
+		if strings.EqualFold(password, blocked) {
+			return true
+		}
+	}
+
+	for _, blocked := range p.Blocklist {
+		if strings.EqualFold(password, blocked) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// passwordClasses counts the character classes used by the password.
+func passwordClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
@@ -0,0 +1,103 @@
+package entity
+
+const (
+	// RoleOwner the room owner, allowed to do anything in the room.
+	RoleOwner = "owner"
+	// RoleModerator a room moderator, allowed to moderate the members and change the room settings.
+	RoleModerator = "moderator"
+	// RoleMember a room member, allowed to read and post messages.
+	RoleMember = "member"
+)
+
+// Permission represents an action in a Room that requires authorization.
+type Permission string
+
+const (
+	// PermissionReadRoom join the room and read its messages.
+	PermissionReadRoom Permission = "readRoom"
+	// PermissionPostMessage post a message in the room.
+	PermissionPostMessage Permission = "postMessage"
+	// PermissionEditMessage edit the messages of other users.
+	PermissionEditMessage Permission = "editMessage"
+	// PermissionPinMessage pin a message in the room.
+	PermissionPinMessage Permission = "pinMessage"
+	// PermissionBypassSlowMode post messages without waiting the room slow mode interval.
+	PermissionBypassSlowMode Permission = "bypassSlowMode"
+	// PermissionInviteMember invite a user to a private room.
+	PermissionInviteMember Permission = "inviteMember"
+	// PermissionKickMember remove a user from the room.
+	PermissionKickMember Permission = "kickMember"
+	// PermissionBanMember ban or mute a user in the room.
+	PermissionBanMember Permission = "banMember"
+	// PermissionReviewReports list and resolve the messages reported in the room.
+	PermissionReviewReports Permission = "reviewReports"
+	// PermissionChangeSettings change the room name, topic, description and message policy.
+	PermissionChangeSettings Permission = "changeSettings"
+	// PermissionManageRoles change the role of the room members.
+	PermissionManageRoles Permission = "manageRoles"
+	// PermissionManageRoom archive or delete the room.
+	PermissionManageRoom Permission = "manageRoom"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleOwner: {
+		PermissionReadRoom,
+		PermissionPostMessage,
+		PermissionEditMessage,
+		PermissionPinMessage,
+		PermissionBypassSlowMode,
+		PermissionInviteMember,
+		PermissionKickMember,
+		PermissionBanMember,
+		PermissionReviewReports,
+		PermissionChangeSettings,
+		PermissionManageRoles,
+		PermissionManageRoom,
+	},
+	RoleModerator: {
+		PermissionReadRoom,
+		PermissionPostMessage,
+		PermissionEditMessage,
+		PermissionPinMessage,
+		PermissionBypassSlowMode,
+		PermissionInviteMember,
+		PermissionKickMember,
+		PermissionBanMember,
+		PermissionReviewReports,
+		PermissionChangeSettings,
+	},
+	RoleMember: {
+		PermissionReadRoom,
+		PermissionPostMessage,
+		PermissionInviteMember,
+	},
+}
+
+var roleRanks = map[string]int{
+	RoleOwner:     3,
+	RoleModerator: 2,
+	RoleMember:    1,
+}
+
+// RoleOutranks returns true if the role is higher than the other role,
+// e.g. a moderator can only moderate a member.
+func RoleOutranks(role, other string) bool {
+	return roleRanks[role] > roleRanks[other]
+}
+
+// IsValidRole checks if the role exists.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RoleHasPermission checks if the role grants the permission.
+func RoleHasPermission(role string, p Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == p {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+package entity
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// ReportOpen report waiting for a moderator review.
+	ReportOpen = "open"
+	// ReportDismissed report reviewed without any action against the reported user.
+	ReportDismissed = "dismissed"
+	// ReportResolved report reviewed with an action against the reported message or user.
+	ReportResolved = "resolved"
+
+	// ReportActionReport audit action of a user reporting a message.
+	ReportActionReport = "report"
+	// ReportActionDismiss dismiss the report.
+	ReportActionDismiss = "dismiss"
+	// ReportActionDeleteMessage delete the reported message.
+	ReportActionDeleteMessage = "deleteMessage"
+	// ReportActionBanUser ban the reported user from the room.
+	ReportActionBanUser = "banUser"
+
+	maxReportReasonLength = 250
+)
+
+// MessageReport represents a Message reported by a User to the Room moderators stored in the DB.
+//
+// Content keeps the reported message content, the message may be deleted when the report is resolved.
+type MessageReport struct {
+	ID             int    `gorm:"primaryKey"`
+	RoomID         int    `gorm:"index:idx_report_room_status"`
+	Status         string `gorm:"size:20;index:idx_report_room_status"`
+	MessageID      int    `gorm:"index"`
+	ReportedUserID int
+	ReporterID     int
+	Reason         string `gorm:"size:250"`
+	Content        string `gorm:"type:text"`
+	Resolution     string `gorm:"size:20"`
+	ResolverID     int
+	ResolvedAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// NewMessageReport MessageReport builder.
+// a user can't report its own message and the reason is required.
+func NewMessageReport(msg *Message, reporterID int, reason string) (*MessageReport, error) {
+	r := &MessageReport{
+		RoomID:         msg.RoomID,
+		Status:         ReportOpen,
+		MessageID:      msg.ID,
+		ReportedUserID: msg.UserID,
+		ReporterID:     reporterID,
+		Reason:         strings.TrimSpace(reason),
+		Content:        msg.Content,
+	}
+
+	if r.MessageID == 0 || r.ReporterID == r.ReportedUserID ||
+		r.Reason == "" || utf8.RuneCountInString(r.Reason) > maxReportReasonLength {
+		return nil, ErrInvalidEntity
+	}
+
+	return r, nil
+}
+
+// IsOpen returns true if the report wasn't reviewed yet.
+func (r *MessageReport) IsOpen() bool {
+	return r.Status == ReportOpen
+}
+
+// Resolve closes the report with the moderator action.
+func (r *MessageReport) Resolve(resolverID int, action string, now time.Time) error {
+	switch action {
+	case ReportActionDismiss:
+		r.Status = ReportDismissed
+	case ReportActionDeleteMessage, ReportActionBanUser:
+		r.Status = ReportResolved
+	default:
+		return ErrInvalidEntity
+	}
+
+	r.Resolution = action
+	r.ResolverID = resolverID
+	r.ResolvedAt = &now
+
+	return nil
+}
+
+// ReportAuditEntry represents an action taken on a MessageReport stored in the DB,
+// the entries of a report are its audit trail.
+type ReportAuditEntry struct {
+	ID        int `gorm:"primaryKey"`
+	ReportID  int `gorm:"index"`
+	RoomID    int `gorm:"index"`
+	ActorID   int
+	Action    string `gorm:"size:20"`
+	Note      string `gorm:"size:250"`
+	CreatedAt time.Time
+}
+
+// NewReportAuditEntry ReportAuditEntry builder.
+func NewReportAuditEntry(report *MessageReport, actorID int, action, note string) (*ReportAuditEntry, error) {
+	e := &ReportAuditEntry{
+		ReportID: report.ID,
+		RoomID:   report.RoomID,
+		ActorID:  actorID,
+		Action:   action,
+		Note:     strings.TrimSpace(note),
+	}
+
+	if utf8.RuneCountInString(e.Note) > maxReportReasonLength {
+		return nil, ErrInvalidEntity
+	}
+
+	return e, nil
+}
@@ -1,22 +1,190 @@
 package entity
 
-import "time"
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vsantosalmeida/browser-chat/pkg/markdown"
+)
+
+const (
+	// DefaultMaxMessageLength max message characters used when a Room doesn't set its own limit.
+	DefaultMaxMessageLength = 500
+	// MaxMessageLength max message characters a Room can set, the websocket read limit is derived from it.
+	MaxMessageLength = 2000
+
+	maxRoomNameLength        = 100
+	maxRoomTopicLength       = 250
+	maxRoomDescriptionLength = 1000
+	// maxSlowModeSeconds max slow mode interval, 6 hours.
+	maxSlowModeSeconds = 6 * 60 * 60
+	// maxBlockedPatterns max regular expressions blocking messages in a Room.
+	maxBlockedPatterns      = 20
+	maxBlockedPatternLength = 200
+)
 
 // Room represents a Room stored in the DB.
 type Room struct {
-	ID        int `gorm:"primaryKey"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID               int    `gorm:"primaryKey"`
+	Name             string `gorm:"size:100;index:idx_room_name,unique"`
+	Topic            string `gorm:"size:250"`
+	Description      string `gorm:"type:text"`
+	CreatorID        int
+	Private          bool
+	MaxMessageLength int
+	SlowModeSeconds  int
+	BlockedPatterns  string `gorm:"type:text"`
+	ArchivedAt       *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// NewRoom Room builder.
+// a private Room is only visible to its members.
+func NewRoom(name, topic, description string, creatorID int, private bool) (*Room, error) {
+	r := &Room{
+		Name:        strings.TrimSpace(name),
+		Topic:       strings.TrimSpace(topic),
+		Description: strings.TrimSpace(description),
+		CreatorID:   creatorID,
+		Private:     private,
+	}
+
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Validate validate room data.
+func (r *Room) Validate() error {
+	if r.Name == "" ||
+		utf8.RuneCountInString(r.Name) > maxRoomNameLength ||
+		utf8.RuneCountInString(r.Topic) > maxRoomTopicLength ||
+		utf8.RuneCountInString(r.Description) > maxRoomDescriptionLength ||
+		r.MaxMessageLength < 0 || r.MaxMessageLength > MaxMessageLength ||
+		r.SlowModeSeconds < 0 || r.SlowModeSeconds > maxSlowModeSeconds {
+		return ErrInvalidEntity
+	}
+
+	patterns := r.ContentPatterns()
+	if len(patterns) > maxBlockedPatterns {
+		return ErrInvalidEntity
+	}
+
+	for _, p := range patterns {
+		if utf8.RuneCountInString(p) > maxBlockedPatternLength {
+			return ErrInvalidEntity
+		}
+		if _, err := regexp.Compile(p); err != nil {
+			return ErrInvalidEntity
+		}
+	}
+
+	return nil
+}
+
+// ContentPatterns returns the regular expressions blocking the messages sent in the Room.
+func (r *Room) ContentPatterns() []string {
+	if r.BlockedPatterns == "" {
+		return nil
+	}
+
+	return strings.Split(r.BlockedPatterns, "\n")
+}
+
+// SetContentPatterns replaces the regular expressions blocking the messages sent in the Room,
+// the empty patterns are ignored.
+func (r *Room) SetContentPatterns(patterns []string) {
+	var kept []string
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p != "" {
+			kept = append(kept, p)
+		}
+	}
+
+	r.BlockedPatterns = strings.Join(kept, "\n")
+}
+
+// IsArchived returns true if the Room was archived, an archived Room doesn't accept new messages.
+func (r *Room) IsArchived() bool {
+	return r.ArchivedAt != nil
+}
+
+// MessagePolicy limits applied to the messages sent in a Room.
+//
+// SlowMode is the minimum interval between two messages of the same user, zero disables it.
+type MessagePolicy struct {
+	MaxLength int
+	SlowMode  time.Duration
+}
+
+// MessagePolicy returns the Room message policy.
+func (r *Room) MessagePolicy() MessagePolicy {
+	policy := MessagePolicy{
+		MaxLength: DefaultMaxMessageLength,
+		SlowMode:  time.Duration(r.SlowModeSeconds) * time.Second,
+	}
+
+	if r.MaxMessageLength > 0 {
+		policy.MaxLength = r.MaxMessageLength
+	}
+
+	return policy
 }
 
 // Message represents a Message stored in the DB.
 type Message struct {
-	ID        int `gorm:"primaryKey"`
-	UserID    int
-	RoomID    int
-	User      User
-	Room      Room
-	Content   string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID          int `gorm:"primaryKey"`
+	UserID      int
+	RoomID      int
+	User        User
+	Room        Room
+	Content     string
+	ContentHTML string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NewMessage Message builder.
+// the content is validated against the given MessagePolicy and rendered to its sanitized HTML form.
+func NewMessage(userID, roomID int, content string, policy MessagePolicy) (*Message, error) {
+	if err := validateMessage(content, policy); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		UserID:      userID,
+		RoomID:      roomID,
+		Content:     content,
+		ContentHTML: markdown.Render(content),
+	}, nil
+}
+
+// validateMessage validate message content.
+func validateMessage(content string, policy MessagePolicy) error {
+	if strings.TrimSpace(content) == "" {
+		return ErrEmptyMessage
+	}
+
+	if !utf8.ValidString(content) {
+		return ErrInvalidMessageContent
+	}
+
+	for _, r := range content {
+		// line breaks and tabs are allowed to write code blocks
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			return ErrInvalidMessageContent
+		}
+	}
+
+	if utf8.RuneCountInString(content) > policy.MaxLength {
+		return ErrMessageTooLong
+	}
+
+	return nil
 }
@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// SanctionKick removes the user from the room, the user may join it again.
+	SanctionKick = "kick"
+	// SanctionBan removes the user from the room and denies joining it until the ban expires.
+	SanctionBan = "ban"
+	// SanctionMute allows the user to read the room but rejects the messages until the mute expires.
+	SanctionMute = "mute"
+
+	maxSanctionReasonLength = 250
+)
+
+// RoomSanction represents a moderation action against a User in a Room stored in the DB.
+//
+// a ban or mute without ExpiresAt never expires, a revoked sanction isn't active anymore.
+type RoomSanction struct {
+	ID        int `gorm:"primaryKey"`
+	RoomID    int `gorm:"index:idx_sanction_room_user"`
+	UserID    int `gorm:"index:idx_sanction_room_user"`
+	ActorID   int
+	Type      string `gorm:"size:20"`
+	Reason    string `gorm:"size:250"`
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// NewRoomSanction RoomSanction builder.
+// a zero duration ban or mute never expires, a kick has no duration.
+func NewRoomSanction(roomID, userID, actorID int, sanctionType, reason string, duration time.Duration) (*RoomSanction, error) {
+	s := &RoomSanction{
+		RoomID:  roomID,
+		UserID:  userID,
+		ActorID: actorID,
+		Type:    sanctionType,
+		Reason:  reason,
+	}
+
+	if duration < 0 || (sanctionType == SanctionKick && duration > 0) {
+		return nil, ErrInvalidEntity
+	}
+
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		s.ExpiresAt = &expiresAt
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Validate validates the sanction fields.
+func (s *RoomSanction) Validate() error {
+	switch s.Type {
+	case SanctionKick, SanctionBan, SanctionMute:
+	default:
+		return ErrInvalidEntity
+	}
+
+	if s.RoomID == 0 || s.UserID == 0 || s.UserID == s.ActorID || utf8.RuneCountInString(s.Reason) > maxSanctionReasonLength {
+		return ErrInvalidEntity
+	}
+
+	return nil
+}
+
+// RemovesMember returns true if the sanction removes the user membership of the room,
+// a kicked user keeps the membership to join the room again.
+func (s *RoomSanction) RemovesMember() bool {
+	return s.Type == SanctionBan
+}
+
+// IsActive returns true if the ban or mute is still enforced at the given time.
+func (s *RoomSanction) IsActive(now time.Time) bool {
+	if s.Type == SanctionKick || s.RevokedAt != nil {
+		return false
+	}
+
+	return s.ExpiresAt == nil || now.Before(*s.ExpiresAt)
+}
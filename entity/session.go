@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxDeviceLabelLength = 100
+	maxUserAgentLength   = 250
+	maxIPLength          = 45
+)
+
+// UserSession represents a websocket connection of a User stored in the DB.
+//
+// a User may be connected from many devices at the same time, an ended session was disconnected
+// or revoked by the User, revoking it revokes the login that opened it. LastSeenAt is refreshed while the connection is open, a session not seen
+// for a while was left behind by a stopped chat-api instance.
+type UserSession struct {
+	ID          int    `gorm:"primaryKey"`
+	UserID      int    `gorm:"index:idx_session_user"`
+	LoginID     string `gorm:"size:36"`
+	DeviceLabel string `gorm:"size:100"`
+	IP          string `gorm:"size:45"`
+	UserAgent   string `gorm:"size:250"`
+	ConnectedAt time.Time
+	LastSeenAt  time.Time
+	EndedAt     *time.Time
+	Revoked     bool
+}
+
+// NewUserSession UserSession builder.
+// the user agent is used as device label when the client doesn't name its device,
+// the fields too long are truncated as they are only informative.
+func NewUserSession(userID int, loginID, deviceLabel, ip, userAgent string, now time.Time) (*UserSession, error) {
+	if userID == 0 {
+		return nil, ErrInvalidEntity
+	}
+
+	userAgent = truncate(strings.TrimSpace(userAgent), maxUserAgentLength)
+
+	deviceLabel = strings.TrimSpace(deviceLabel)
+	if deviceLabel == "" {
+		deviceLabel = userAgent
+	}
+
+	return &UserSession{
+		UserID:      userID,
+		LoginID:     loginID,
+		DeviceLabel: truncate(deviceLabel, maxDeviceLabelLength),
+		IP:          truncate(ip, maxIPLength),
+		UserAgent:   userAgent,
+		ConnectedAt: now,
+		LastSeenAt:  now,
+	}, nil
+}
+
+// IsActive returns true if the session isn't ended and was seen after the given time.
+func (s *UserSession) IsActive(seenAfter time.Time) bool {
+	return s.EndedAt == nil && s.LastSeenAt.After(seenAfter)
+}
+
+// truncate cuts the string to the max characters.
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	return string([]rune(s)[:max])
+}
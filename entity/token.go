@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// refreshTokenBytes random bytes of a refresh token.
+const refreshTokenBytes = 32
+
+// RefreshToken represents a refresh token stored in the DB, only its hash is stored.
+//
+// every token issued by a login shares the LoginID, a refresh token is used once and replaced by a new one,
+// revoking the login revokes all of them.
+type RefreshToken struct {
+	ID        int    `gorm:"primaryKey"`
+	UserID    int    `gorm:"index:idx_refresh_token_user"`
+	LoginID   string `gorm:"size:36;index:idx_refresh_token_login"`
+	TokenHash string `gorm:"size:64;index:idx_refresh_token_hash,unique"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// NewRefreshToken RefreshToken builder, returns the raw token to send to the user.
+func NewRefreshToken(userID int, loginID string, ttl time.Duration, now time.Time) (*RefreshToken, string, error) {
+	if userID == 0 || loginID == "" || ttl <= 0 {
+		return nil, "", ErrInvalidEntity
+	}
+
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+
+	raw := base64.RawURLEncoding.EncodeToString(b)
+
+	return &RefreshToken{
+		UserID:    userID,
+		LoginID:   loginID,
+		TokenHash: HashRefreshToken(raw),
+		ExpiresAt: now.Add(ttl),
+	}, raw, nil
+}
+
+// HashRefreshToken returns the hash of the raw refresh token used to find it in the DB.
+func HashRefreshToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewLoginID returns a random login ID shared by the tokens issued by a login.
+func NewLoginID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	// random UUID, version 4
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	s := hex.EncodeToString(b)
+
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:], nil
+}
+
+// IsUsable returns true if the refresh token wasn't used nor revoked and didn't expire at the given time.
+func (t *RefreshToken) IsUsable(now time.Time) bool {
+	return t.UsedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
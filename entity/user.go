@@ -1,34 +1,87 @@
 package entity
 
 import (
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	// UserRoleUser a regular user.
+	UserRoleUser = "user"
+	// UserRoleAdmin a chat administrator, allowed to manage the users and read the server metrics.
+	UserRoleAdmin = "admin"
+)
+
+// UserStatus presence of a user in the chat.
+type UserStatus string
+
+const (
+	// UserStatusOnline the user is connected and active.
+	UserStatusOnline UserStatus = "online"
+	// UserStatusIdle the user is connected without recent activity.
+	UserStatusIdle UserStatus = "idle"
+	// UserStatusAway the user is connected without activity for a long time, or set it.
+	UserStatusAway UserStatus = "away"
+	// UserStatusDND the user doesn't want to be disturbed.
+	UserStatusDND UserStatus = "dnd"
+	// UserStatusOffline the user isn't connected.
+	UserStatusOffline UserStatus = "offline"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 500
+	maxStatusTextLength  = 100
+
+	// AvatarURLPrefix path serving the avatar files.
+	AvatarURLPrefix = "/avatars/"
+)
+
 // User represents a User stored in the DB.
+//
+// the profile is made of the DisplayName, shown instead of the Username when set, the Avatar file name,
+// the Bio and the custom StatusText.
+//
+// the Status set by the user, away or dnd, overrides the status derived from its activity, it's empty otherwise.
 type User struct {
-	ID        int    `gorm:"primaryKey"`
-	Username  string `gorm:"index:idx_username,unique"`
-	Password  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID          int    `gorm:"primaryKey"`
+	Username    string `gorm:"index:idx_username,unique"`
+	Password    string
+	Role        string     `gorm:"size:20;default:user"`
+	DisplayName string     `gorm:"size:50"`
+	Avatar      string     `gorm:"size:100"`
+	Bio         string     `gorm:"size:500"`
+	StatusText  string     `gorm:"size:100"`
+	Status      UserStatus `gorm:"size:10"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ProfileUpdate profile fields to change, a nil field is kept and an empty one cleared.
+type ProfileUpdate struct {
+	DisplayName *string
+	Bio         *string
+	StatusText  *string
 }
 
-// NewUser User builder.
-func NewUser(username, password string) (*User, error) {
+// NewUser User builder, the password must follow the policy.
+func NewUser(username, password string, policy PasswordPolicy) (*User, error) {
 	if err := validate(username, password); err != nil {
 		return nil, ErrInvalidEntity
 	}
 
 	u := &User{
 		Username: username,
+		Role:     UserRoleUser,
 	}
-	pwd, err := generatePassword(password)
-	if err != nil {
+	if err := u.SetPassword(password, policy); err != nil {
 		return nil, err
 	}
-	u.Password = pwd
+
 	return u, nil
 }
 
@@ -50,10 +103,108 @@ func (u *User) ValidatePassword(p string) error {
 	return nil
 }
 
-func generatePassword(raw string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(raw), 10)
+// SetPassword validates the password against the policy and replaces the user password hash.
+func (u *User) SetPassword(raw string, policy PasswordPolicy) error {
+	if err := policy.Validate(u.Username, raw); err != nil {
+		return err
+	}
+
+	return u.Rehash(raw, policy.Cost)
+}
+
+// NeedsRehash returns true if the password hash was generated with a lower cost than the given one.
+func (u *User) NeedsRehash(cost int) bool {
+	current, err := bcrypt.Cost([]byte(u.Password))
 	if err != nil {
-		return "", err
+		return false
+	}
+
+	return current < cost
+}
+
+// Rehash replaces the password hash by a hash of the given cost, the raw password must be validated first.
+func (u *User) Rehash(raw string, cost int) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(raw), cost)
+	if err != nil {
+		return err
+	}
+
+	u.Password = string(hash)
+	return nil
+}
+
+// Name returns the DisplayName, or the Username when the user didn't set one.
+func (u *User) Name() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
 	}
-	return string(hash), nil
+
+	return u.Username
+}
+
+// AvatarURL returns the avatar path, empty when the user didn't upload one.
+func (u *User) AvatarURL() string {
+	if u.Avatar == "" {
+		return ""
+	}
+
+	return AvatarURLPrefix + u.Avatar
+}
+
+// UpdateProfile validates and applies the profile changes, the fields are trimmed.
+// only the bio may have line breaks.
+func (u *User) UpdateProfile(p ProfileUpdate) error {
+	update := *u
+
+	if p.DisplayName != nil {
+		update.DisplayName = strings.TrimSpace(*p.DisplayName)
+		if !validProfileText(update.DisplayName, maxDisplayNameLength, false) {
+			return ErrInvalidDisplayName
+		}
+	}
+
+	if p.Bio != nil {
+		update.Bio = strings.TrimSpace(*p.Bio)
+		if !validProfileText(update.Bio, maxBioLength, true) {
+			return ErrInvalidBio
+		}
+	}
+
+	if p.StatusText != nil {
+		update.StatusText = strings.TrimSpace(*p.StatusText)
+		if !validProfileText(update.StatusText, maxStatusTextLength, false) {
+			return ErrInvalidStatusText
+		}
+	}
+
+	*u = update
+	return nil
+}
+
+func validProfileText(text string, maxLength int, multiline bool) bool {
+	if !utf8.ValidString(text) || utf8.RuneCountInString(text) > maxLength {
+		return false
+	}
+
+	for _, r := range text {
+		if unicode.IsControl(r) && !(multiline && (r == '\n' || r == '\r')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetStatus sets the status chosen by the user, online goes back to the status derived from its activity.
+func (u *User) SetStatus(status UserStatus) error {
+	switch status {
+	case UserStatusOnline:
+		u.Status = ""
+	case UserStatusAway, UserStatusDND:
+		u.Status = status
+	default:
+		return ErrInvalidStatus
+	}
+
+	return nil
 }
@@ -0,0 +1,119 @@
+package broker
+
+import (
+	"context"
+
+	"github.com/apex/log"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQFanout message broker to publish a message to every consumer bound to an exchange.
+type RabbitMQFanout struct {
+	ch       *amqp.Channel
+	exchange string
+}
+
+// NewRabbitMQFanout RabbitMQFanout builder.
+func NewRabbitMQFanout(exchange string, ch *amqp.Channel) *RabbitMQFanout {
+	return &RabbitMQFanout{
+		ch:       ch,
+		exchange: exchange,
+	}
+}
+
+// ReadMessage binds an exclusive queue to the exchange, loop through rabbitMQ delivery channel
+// and send the message body to the msgCH.
+// a context.Canceled error will stop the consumer.
+func (r *RabbitMQFanout) ReadMessage(ctx context.Context, msgCH chan<- []byte) {
+	if err := r.declareExchange(); err != nil {
+		log.WithError(err).Error("failed to declare exchange")
+		return
+	}
+
+	q, err := r.ch.QueueDeclare(
+		"",    // name
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		log.WithError(err).Error("failed to declare queue")
+		return
+	}
+
+	if err = r.ch.QueueBind(
+		q.Name,     // queue name
+		"",         // routing key
+		r.exchange, // exchange
+		false,      // no-wait
+		nil,        // args
+	); err != nil {
+		log.WithError(err).Error("failed to bind queue")
+		return
+	}
+
+	msgs, err := r.ch.Consume(
+		q.Name,
+		"",    // consumer
+		true,  // auto-ack
+		true,  // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,   // args
+	)
+	if err != nil {
+		log.WithError(err).Error("failed to consume messages")
+		return
+	}
+
+	for msg := range msgs {
+		if ctx.Err() == context.Canceled {
+			log.Warn("context canceled")
+			break
+		}
+
+		log.WithField("Message", msg).Debug("message received")
+		msgCH <- msg.Body
+	}
+
+	log.WithField("Exchange", r.exchange).Info("consumer stopped")
+}
+
+// WriteMessage send message to the configured exchange.
+func (r *RabbitMQFanout) WriteMessage(ctx context.Context, payload []byte) error {
+	if err := r.declareExchange(); err != nil {
+		log.WithError(err).Error("failed to declare exchange")
+		return err
+	}
+
+	if err := r.ch.PublishWithContext(ctx,
+		r.exchange, // exchange
+		"",         // routing key
+		false,      // mandatory
+		false,      // immediate
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        payload,
+		}); err != nil {
+		log.WithError(err).Error("failed to send message")
+		return err
+	}
+
+	log.WithField("Message", string(payload)).Debug("message sent")
+	return nil
+}
+
+// declareExchange declares the fanout exchange, it does nothing if the exchange already exists.
+func (r *RabbitMQFanout) declareExchange() error {
+	return r.ch.ExchangeDeclare(
+		r.exchange, // name
+		"fanout",   // type
+		true,       // durable
+		false,      // auto-deleted
+		false,      // internal
+		false,      // no-wait
+		nil,        // arguments
+	)
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"gorm.io/gorm"
+)
+
+// AvatarMySQL mysql repo
+type AvatarMySQL struct {
+	db *gorm.DB
+}
+
+// NewAvatarMySQL create new repository
+func NewAvatarMySQL(db *gorm.DB) *AvatarMySQL {
+	return &AvatarMySQL{
+		db: db,
+	}
+}
+
+// Save stores the avatar image, the name is generated by the user service and never by the client.
+func (r *AvatarMySQL) Save(e *entity.Avatar) error {
+	return r.db.Create(e).Error
+}
+
+// Find retrieves the avatar image by name.
+func (r *AvatarMySQL) Find(name string) (*entity.Avatar, error) {
+	var avatar entity.Avatar
+	if result := r.db.Where("name = ?", name).First(&avatar); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrAvatarNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &avatar, nil
+}
+
+// Delete removes the avatar image, a missing avatar is ignored.
+func (r *AvatarMySQL) Delete(name string) error {
+	return r.db.Where("name = ?", name).Delete(&entity.Avatar{}).Error
+}
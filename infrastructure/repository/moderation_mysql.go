@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"gorm.io/gorm"
+)
+
+// ModerationMySQL mysql repo
+type ModerationMySQL struct {
+	db *gorm.DB
+}
+
+// NewModerationMySQL create new repository
+func NewModerationMySQL(db *gorm.DB) *ModerationMySQL {
+	return &ModerationMySQL{
+		db: db,
+	}
+}
+
+// ListActiveSanctions lists the user bans and mutes in the room not revoked nor expired at the given time.
+func (r *ModerationMySQL) ListActiveSanctions(roomID, userID int, now time.Time) ([]*entity.RoomSanction, error) {
+	var sanctions []*entity.RoomSanction
+	if result := r.active(now).
+		Where("room_id = ? AND user_id = ?", roomID, userID).
+		Find(&sanctions); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return sanctions, nil
+}
+
+// ListRoomSanctions lists the room bans and mutes not revoked nor expired at the given time.
+func (r *ModerationMySQL) ListRoomSanctions(roomID int, now time.Time) ([]*entity.RoomSanction, error) {
+	var sanctions []*entity.RoomSanction
+	if result := r.active(now).
+		Where("room_id = ?", roomID).
+		Order("created_at desc").
+		Find(&sanctions); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return sanctions, nil
+}
+
+// CreateSanction creates the sanction, a ban also removes the room membership in the same transaction.
+func (r *ModerationMySQL) CreateSanction(e *entity.RoomSanction) (int, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Create(e); result.Error != nil {
+			return result.Error
+		}
+
+		if !e.RemovesMember() {
+			return nil
+		}
+
+		return tx.
+			Where("room_id = ? AND user_id = ?", e.RoomID, e.UserID).
+			Delete(&entity.RoomMember{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return e.ID, nil
+}
+
+// RevokeSanctions revokes the user active sanctions of the given type in the room.
+func (r *ModerationMySQL) RevokeSanctions(roomID, userID int, sanctionType string, now time.Time) error {
+	if result := r.active(now).
+		Model(&entity.RoomSanction{}).
+		Where("room_id = ? AND user_id = ? AND type = ?", roomID, userID, sanctionType).
+		Update("revoked_at", now); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// active filters the bans and mutes not revoked nor expired at the given time.
+func (r *ModerationMySQL) active(now time.Time) *gorm.DB {
+	return r.db.
+		Where("type IN ?", []string{entity.SanctionBan, entity.SanctionMute}).
+		Where("revoked_at IS NULL").
+		Where("expires_at IS NULL OR expires_at > ?", now)
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"gorm.io/gorm"
+)
+
+// ReportMySQL mysql repo
+type ReportMySQL struct {
+	db *gorm.DB
+}
+
+// NewReportMySQL create new repository
+func NewReportMySQL(db *gorm.DB) *ReportMySQL {
+	return &ReportMySQL{
+		db: db,
+	}
+}
+
+func (r *ReportMySQL) FindMessage(id int) (*entity.Message, error) {
+	var msg entity.Message
+	if result := r.db.First(&msg, id); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrMessageNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &msg, nil
+}
+
+// ListMessageContext lists the room messages sent before, up to the limit, the given message and after it, up to the limit,
+// ordered by the sent order.
+func (r *ReportMySQL) ListMessageContext(roomID, messageID, limit int) ([]*entity.Message, error) {
+	var before, after []*entity.Message
+	if result := r.db.
+		Preload("User").
+		Where("room_id = ? AND id <= ?", roomID, messageID).
+		Order("id desc").
+		Limit(limit + 1).
+		Find(&before); result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result := r.db.
+		Preload("User").
+		Where("room_id = ? AND id > ?", roomID, messageID).
+		Order("id asc").
+		Limit(limit).
+		Find(&after); result.Error != nil {
+		return nil, result.Error
+	}
+
+	mgs := make([]*entity.Message, 0, len(before)+len(after))
+	for i := len(before) - 1; i >= 0; i-- {
+		mgs = append(mgs, before[i])
+	}
+
+	return append(mgs, after...), nil
+}
+
+func (r *ReportMySQL) FindReport(id int) (*entity.MessageReport, error) {
+	var report entity.MessageReport
+	if result := r.db.First(&report, id); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrReportNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &report, nil
+}
+
+func (r *ReportMySQL) FindOpenReport(messageID, reporterID int) (*entity.MessageReport, error) {
+	var report entity.MessageReport
+	if result := r.db.
+		Where("message_id = ? AND reporter_id = ? AND status = ?", messageID, reporterID, entity.ReportOpen).
+		First(&report); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrReportNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &report, nil
+}
+
+func (r *ReportMySQL) ListReports(roomID int, status string) ([]*entity.MessageReport, error) {
+	var reports []*entity.MessageReport
+	if result := r.db.
+		Where("room_id = ? AND status = ?", roomID, status).
+		Order("created_at desc").
+		Find(&reports); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return reports, nil
+}
+
+func (r *ReportMySQL) ListAuditEntries(reportID int) ([]*entity.ReportAuditEntry, error) {
+	var entries []*entity.ReportAuditEntry
+	if result := r.db.
+		Where("report_id = ?", reportID).
+		Order("id asc").
+		Find(&entries); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return entries, nil
+}
+
+// CreateReport creates the report and its first audit entry in a single transaction.
+func (r *ReportMySQL) CreateReport(e *entity.MessageReport) (int, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Create(e); result.Error != nil {
+			return result.Error
+		}
+
+		audit, err := entity.NewReportAuditEntry(e, e.ReporterID, entity.ReportActionReport, e.Reason)
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(audit).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return e.ID, nil
+}
+
+// ResolveReport saves the resolved report and its audit entry, the other open reports of the same message
+// are closed with the same resolution. The reported message is deleted in the same transaction if required.
+// a report no longer open returns entity.ErrReportNotOpen.
+func (r *ReportMySQL) ResolveReport(e *entity.MessageReport, audit *entity.ReportAuditEntry, deleteMessage bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// only an open report is resolved, the report resolved by another moderator isn't resolved again
+		result := tx.
+			Model(e).
+			Where("status = ?", entity.ReportOpen).
+			Select("status", "resolution", "resolver_id", "resolved_at").
+			Updates(e)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return entity.ErrReportNotOpen
+		}
+
+		if result := tx.
+			Model(&entity.MessageReport{}).
+			Where("message_id = ? AND status = ?", e.MessageID, entity.ReportOpen).
+			Updates(map[string]interface{}{
+				"status":      e.Status,
+				"resolution":  e.Resolution,
+				"resolver_id": e.ResolverID,
+				"resolved_at": e.ResolvedAt,
+			}); result.Error != nil {
+			return result.Error
+		}
+
+		if result := tx.Create(audit); result.Error != nil {
+			return result.Error
+		}
+
+		if !deleteMessage {
+			return nil
+		}
+
+		return tx.Delete(&entity.Message{}, e.MessageID).Error
+	})
+}
@@ -3,6 +3,8 @@ package repository
 import (
 	"github.com/vsantosalmeida/browser-chat/entity"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
@@ -20,6 +22,30 @@ func NewRoomMySQL(db *gorm.DB) *RoomMySQL {
 	}
 }
 
+func (r *RoomMySQL) FindRoom(id int) (*entity.Room, error) {
+	var room entity.Room
+	if result := r.db.First(&room, id); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrRoomNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &room, nil
+}
+
+func (r *RoomMySQL) FindRoomByName(name string) (*entity.Room, error) {
+	var room entity.Room
+	if result := r.db.Where("name = ?", name).First(&room); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrRoomNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &room, nil
+}
+
 func (r *RoomMySQL) ListRooms() ([]*entity.Room, error) {
 	var rooms []*entity.Room
 	if result := r.db.Find(&rooms); result.Error != nil {
@@ -29,11 +55,26 @@ func (r *RoomMySQL) ListRooms() ([]*entity.Room, error) {
 	return rooms, nil
 }
 
-func (r *RoomMySQL) ListMessages(roomID int) ([]*entity.Message, error) {
+// ListVisibleRooms lists the public rooms and the private rooms the user is a member of.
+func (r *RoomMySQL) ListVisibleRooms(userID int) ([]*entity.Room, error) {
+	var rooms []*entity.Room
+	if result := r.db.
+		Where("private = ?", false).
+		Or("id IN (?)", r.db.Model(&entity.RoomMember{}).Select("room_id").Where("user_id = ?", userID)).
+		Find(&rooms); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return rooms, nil
+}
+
+// ListMessages lists the latest messages of the room, the messages of the users blocked by the user are hidden.
+func (r *RoomMySQL) ListMessages(roomID, userID int) ([]*entity.Message, error) {
 	var mgs []*entity.Message
 	if result := r.db.
 		Preload("User").
 		Where("room_id = ?", roomID).
+		Where("user_id NOT IN (?)", r.db.Model(&entity.UserBlock{}).Select("blocked_id").Where("user_id = ?", userID)).
 		Limit(maxMessages).
 		Order("created_at desc").
 		Find(&mgs); result.Error != nil {
@@ -43,18 +84,217 @@ func (r *RoomMySQL) ListMessages(roomID int) ([]*entity.Message, error) {
 	return mgs, nil
 }
 
+// CreateRoom creates the room and adds its creator as a member in a single transaction.
 func (r *RoomMySQL) CreateRoom(e *entity.Room) (int, error) {
-	if result := r.db.Create(e); result.Error != nil {
-		return 0, result.Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Create(e); result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+				return entity.ErrRoomNameTaken
+			}
+			return result.Error
+		}
+
+		member := &entity.RoomMember{
+			RoomID: e.ID,
+			UserID: e.CreatorID,
+			Role:   entity.RoleOwner,
+		}
+
+		return tx.Create(member).Error
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return e.ID, nil
 }
 
+func (r *RoomMySQL) UpdateRoom(e *entity.Room) error {
+	if result := r.db.Save(e); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return entity.ErrRoomNameTaken
+		}
+		return result.Error
+	}
+
+	return nil
+}
+
+// DeleteRoom deletes the room, its messages, members, invitations, sanctions and reports in a single transaction.
+func (r *RoomMySQL) DeleteRoom(id int) error {
+	models := []interface{}{
+		&entity.Message{},
+		&entity.RoomMember{},
+		&entity.RoomInvitation{},
+		&entity.RoomSanction{},
+		&entity.MessageReport{},
+		&entity.ReportAuditEntry{},
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range models {
+			if result := tx.Where("room_id = ?", id).Delete(model); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		result := tx.Delete(&entity.Room{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return entity.ErrRoomNotFound
+		}
+
+		return nil
+	})
+}
+
+// CreateMessage creates the message, a message rejected by the DB returns entity.ErrInvalidEntity.
 func (r *RoomMySQL) CreateMessage(e *entity.Message) error {
 	if result := r.db.Create(e); result.Error != nil {
+		return messageError(result.Error)
+	}
+
+	return nil
+}
+
+// CreateMessages creates the messages at once, a message rejected by the DB returns entity.ErrInvalidEntity.
+func (r *RoomMySQL) CreateMessages(e []*entity.Message) error {
+	if result := r.db.CreateInBatches(e, len(e)); result.Error != nil {
+		return messageError(result.Error)
+	}
+
+	return nil
+}
+
+// messageDataErrors MySQL errors of a message rejected by the DB, creating it again fails the same way,
+// e.g. a content too long or a deleted room.
+var messageDataErrors = map[uint16]bool{
+	1048: true, // column can't be null
+	1264: true, // out of range value
+	1366: true, // incorrect string value
+	1406: true, // data too long
+	1452: true, // foreign key constraint fails
+}
+
+// messageError tells a message rejected by the DB apart from the other errors, e.g. a lost connection.
+func messageError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.Is(err, gorm.ErrDuplicatedKey) || (errors.As(err, &mysqlErr) && messageDataErrors[mysqlErr.Number]) {
+		return errors.Wrap(entity.ErrInvalidEntity, err.Error())
+	}
+
+	return err
+}
+
+func (r *RoomMySQL) FindMember(roomID, userID int) (*entity.RoomMember, error) {
+	var member entity.RoomMember
+	if result := r.db.Where("room_id = ? AND user_id = ?", roomID, userID).First(&member); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrMemberNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &member, nil
+}
+
+func (r *RoomMySQL) ListMembers(roomID int) ([]*entity.RoomMember, error) {
+	var members []*entity.RoomMember
+	if result := r.db.Where("room_id = ?", roomID).Find(&members); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return members, nil
+}
+
+// SaveMember creates or updates the room member.
+func (r *RoomMySQL) SaveMember(e *entity.RoomMember) error {
+	if result := r.db.Save(e); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *RoomMySQL) FindInvitation(id int) (*entity.RoomInvitation, error) {
+	var invitation entity.RoomInvitation
+	if result := r.db.First(&invitation, id); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrInvitationNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &invitation, nil
+}
+
+func (r *RoomMySQL) FindPendingInvitation(roomID, inviteeID int) (*entity.RoomInvitation, error) {
+	var invitation entity.RoomInvitation
+	if result := r.db.
+		Where("room_id = ? AND invitee_id = ? AND status = ?", roomID, inviteeID, entity.InvitationPending).
+		First(&invitation); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrInvitationNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &invitation, nil
+}
+
+func (r *RoomMySQL) ListPendingInvitations(inviteeID int) ([]*entity.RoomInvitation, error) {
+	var invitations []*entity.RoomInvitation
+	if result := r.db.
+		Preload("Room").
+		Where("invitee_id = ? AND status = ?", inviteeID, entity.InvitationPending).
+		Order("created_at desc").
+		Find(&invitations); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return invitations, nil
+}
+
+func (r *RoomMySQL) CreateInvitation(e *entity.RoomInvitation) (int, error) {
+	if result := r.db.Create(e); result.Error != nil {
+		return 0, result.Error
+	}
+
+	return e.ID, nil
+}
+
+func (r *RoomMySQL) UpdateInvitation(e *entity.RoomInvitation) error {
+	if result := r.db.Omit("Room").Save(e); result.Error != nil {
 		return result.Error
 	}
 
 	return nil
 }
+
+// AcceptInvitation updates the invitation and adds the invitee as a room member in a single transaction.
+func (r *RoomMySQL) AcceptInvitation(e *entity.RoomInvitation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Omit("Room").Save(e); result.Error != nil {
+			return result.Error
+		}
+
+		member := &entity.RoomMember{
+			RoomID: e.RoomID,
+			UserID: e.InviteeID,
+			Role:   entity.RoleMember,
+		}
+
+		if result := tx.Create(member); result.Error != nil {
+			// already a member, e.g. invited twice before joining
+			if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+				return nil
+			}
+			return result.Error
+		}
+
+		return nil
+	})
+}
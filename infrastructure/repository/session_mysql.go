@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"gorm.io/gorm"
+)
+
+// SessionMySQL mysql repo
+type SessionMySQL struct {
+	db *gorm.DB
+}
+
+// NewSessionMySQL create new repository
+func NewSessionMySQL(db *gorm.DB) *SessionMySQL {
+	return &SessionMySQL{
+		db: db,
+	}
+}
+
+// FindSession retrieves the session by ID.
+func (r *SessionMySQL) FindSession(id int) (*entity.UserSession, error) {
+	var e entity.UserSession
+	if result := r.db.First(&e, id); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrSessionNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &e, nil
+}
+
+// ListActiveSessions lists the user sessions not ended and seen after the given time.
+func (r *SessionMySQL) ListActiveSessions(userID int, seenAfter time.Time) ([]*entity.UserSession, error) {
+	var sessions []*entity.UserSession
+	if result := r.db.
+		Where("user_id = ? AND ended_at IS NULL AND last_seen_at > ?", userID, seenAfter).
+		Order("connected_at desc").
+		Find(&sessions); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return sessions, nil
+}
+
+// CreateSession creates the session.
+func (r *SessionMySQL) CreateSession(e *entity.UserSession) (int, error) {
+	if result := r.db.Create(e); result.Error != nil {
+		return 0, result.Error
+	}
+
+	return e.ID, nil
+}
+
+// EndSession ends the session if it isn't ended yet.
+func (r *SessionMySQL) EndSession(id int, now time.Time, revoked bool) error {
+	if result := r.db.
+		Model(&entity.UserSession{}).
+		Where("id = ? AND ended_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"ended_at": now,
+			"revoked":  revoked,
+		}); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// TouchSessions sets the last seen time of the sessions not ended.
+func (r *SessionMySQL) TouchSessions(ids []int, now time.Time) error {
+	if result := r.db.
+		Model(&entity.UserSession{}).
+		Where("id IN ? AND ended_at IS NULL", ids).
+		Update("last_seen_at", now); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
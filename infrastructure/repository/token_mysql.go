@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenMySQL mysql repo
+type RefreshTokenMySQL struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenMySQL create new repository
+func NewRefreshTokenMySQL(db *gorm.DB) *RefreshTokenMySQL {
+	return &RefreshTokenMySQL{
+		db: db,
+	}
+}
+
+// FindRefreshToken retrieves the refresh token by its hash, an unknown token is an invalid token.
+func (r *RefreshTokenMySQL) FindRefreshToken(hash string) (*entity.RefreshToken, error) {
+	var e entity.RefreshToken
+	if result := r.db.Where("token_hash = ?", hash).First(&e); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrInvalidToken
+		}
+		return nil, result.Error
+	}
+
+	return &e, nil
+}
+
+// IsLoginRevoked checks if the refresh tokens of the login were revoked.
+func (r *RefreshTokenMySQL) IsLoginRevoked(loginID string) (bool, error) {
+	var count int64
+	if result := r.db.
+		Model(&entity.RefreshToken{}).
+		Where("login_id = ? AND revoked_at IS NOT NULL", loginID).
+		Limit(1).
+		Count(&count); result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// ListUserLogins retrieves the logins of the user with refresh tokens not revoked nor expired at the given time.
+func (r *RefreshTokenMySQL) ListUserLogins(userID int, now time.Time) ([]string, error) {
+	var logins []string
+	if result := r.db.
+		Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Distinct().
+		Pluck("login_id", &logins); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return logins, nil
+}
+
+// CreateRefreshToken creates the refresh token.
+func (r *RefreshTokenMySQL) CreateRefreshToken(e *entity.RefreshToken) (int, error) {
+	if result := r.db.Create(e); result.Error != nil {
+		return 0, result.Error
+	}
+
+	return e.ID, nil
+}
+
+// RotateRefreshToken marks the refresh token as used and creates the next one in the same transaction.
+// returns entity.ErrInvalidToken if the token was already used or revoked.
+func (r *RefreshTokenMySQL) RotateRefreshToken(used, next *entity.RefreshToken, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&entity.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", used.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return entity.ErrInvalidToken
+		}
+
+		return tx.Create(next).Error
+	})
+}
+
+// RevokeLogin revokes every refresh token of the login.
+func (r *RefreshTokenMySQL) RevokeLogin(loginID string, now time.Time) error {
+	if result := r.db.
+		Model(&entity.RefreshToken{}).
+		Where("login_id = ? AND revoked_at IS NULL", loginID).
+		Update("revoked_at", now); result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"errors"
+
 	"github.com/vsantosalmeida/browser-chat/entity"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserMySQL mysql repo
@@ -19,12 +22,28 @@ func NewUserMySQL(db *gorm.DB) *UserMySQL {
 }
 
 func (u *UserMySQL) FindByUsername(username string) (*entity.User, error) {
-	var user *entity.User
-	if result := u.db.Find(&user, "username = ?", username); result.Error != nil {
+	var user entity.User
+	if result := u.db.Where("username = ?", username).First(&user); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrUserNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &user, nil
+}
+
+// FindByID retrieves the user by ID.
+func (u *UserMySQL) FindByID(id int) (*entity.User, error) {
+	var user entity.User
+	if result := u.db.First(&user, id); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrUserNotFound
+		}
 		return nil, result.Error
 	}
 
-	return user, nil
+	return &user, nil
 }
 
 func (u *UserMySQL) List() ([]*entity.User, error) {
@@ -38,8 +57,50 @@ func (u *UserMySQL) List() ([]*entity.User, error) {
 
 func (u *UserMySQL) Create(e *entity.User) (int, error) {
 	if result := u.db.Create(e); result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return 0, entity.ErrUsernameTaken
+		}
 		return 0, result.Error
 	}
 
 	return e.ID, nil
 }
+
+// UpdatePassword replaces the user password hash.
+func (u *UserMySQL) UpdatePassword(id int, hash string) error {
+	return u.db.Model(&entity.User{}).Where("id = ?", id).Update("password", hash).Error
+}
+
+// UpdateProfile saves the user profile fields.
+func (u *UserMySQL) UpdateProfile(e *entity.User) error {
+	return u.db.Model(e).Select("DisplayName", "Avatar", "Bio", "StatusText").Updates(e).Error
+}
+
+// UpdateStatus replaces the status set by the user, an empty status is cleared.
+func (u *UserMySQL) UpdateStatus(id int, status entity.UserStatus) error {
+	return u.db.Model(&entity.User{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// ListBlocks lists the users blocked by the user, the latest first.
+func (u *UserMySQL) ListBlocks(userID int) ([]*entity.UserBlock, error) {
+	var blocks []*entity.UserBlock
+	if result := u.db.
+		Preload("Blocked").
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&blocks); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return blocks, nil
+}
+
+// CreateBlock stores the user block, an existing block is kept.
+func (u *UserMySQL) CreateBlock(e *entity.UserBlock) error {
+	return u.db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(e).Error
+}
+
+// DeleteBlock deletes the user block, if any.
+func (u *UserMySQL) DeleteBlock(userID, blockedID int) error {
+	return u.db.Where("user_id = ? AND blocked_id = ?", userID, blockedID).Delete(&entity.UserBlock{}).Error
+}
@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
 	"time"
 
 	"github.com/vsantosalmeida/browser-chat/entity"
@@ -13,18 +16,49 @@ import (
 type contextKey string
 
 const (
-	secret         = "none"
-	expirationTime = 86400 // 1 day
+	// issuer the iss claim of the tokens issued by the chat-api.
+	issuer         = "chat-api"
 	UserContextKey = contextKey("user")
+	// BearerProtocol websocket subprotocol followed by the token, the only subprotocol accepted by the Server.
+	BearerProtocol = "bearer"
 )
 
 // Claims implements entity.AuthenticatedUser interface
+//
+// the user ID is sent as the standard sub claim, LoginID is shared by the tokens issued by the same login
+// to revoke all of them on logout. The user Role is read when the token is issued, a role change applies
+// to the next issued token.
 type Claims struct {
-	ID       int    `json:"id"`
+	ID       int    `json:"-"`
 	Username string `json:"username"`
+	LoginID  string `json:"sid"`
+	Role     string `json:"role,omitempty"`
 	jwt.StandardClaims
 }
 
+// NewClaims Claims builder, the token expires after the ttl.
+func NewClaims(user *entity.User, loginID string, ttl time.Duration, now time.Time) (*Claims, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "could not generate token id")
+	}
+
+	return &Claims{
+		ID:       user.ID,
+		Username: user.Username,
+		LoginID:  loginID,
+		Role:     user.Role,
+		StandardClaims: jwt.StandardClaims{
+			Id:        hex.EncodeToString(b),
+			Issuer:    issuer,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+		},
+	}, nil
+}
+
 // GetId returns user's ID.
 func (c *Claims) GetId() int {
 	return c.ID
@@ -35,34 +69,42 @@ func (c *Claims) GetUsername() string {
 	return c.Username
 }
 
-// CreateJWTToken given an entity.User generate a JWT token.
-func CreateJWTToken(user *entity.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"ID":        user.ID,
-		"Username":  user.Username,
-		"ExpiresAt": time.Now().Unix() + expirationTime,
-	})
-	tokenString, err := token.SignedString([]byte(secret))
+// GetLoginID returns the ID of the login that issued the token.
+func (c *Claims) GetLoginID() string {
+	return c.LoginID
+}
 
-	return tokenString, err
+// GetRole returns user's role, a token issued before the roles were added has no role.
+func (c *Claims) GetRole() string {
+	return c.Role
 }
 
-// ValidateJWTToken validate if the JWT token is valid and builds an entity.AuthenticatedUser.
-func ValidateJWTToken(tokenString string) (entity.AuthenticatedUser, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected method")
-		}
+// CreateJWTToken signs the claims with the KeySet signing key.
+func (ks *KeySet) CreateJWTToken(claims *Claims) (string, error) {
+	return ks.sign(jwt.NewWithClaims(ks.signing.Method, claims))
+}
 
-		return []byte(secret), nil
-	})
+// ValidateJWTToken validate if the JWT token is valid and builds an entity.AuthenticatedUser.
+// the token must be signed by one of the KeySet keys, issued by the chat-api and not expired.
+func (ks *KeySet) ValidateJWTToken(tokenString string) (entity.AuthenticatedUser, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, ks.verificationKey)
 	if err != nil {
+		// jwt-go wraps the key function errors without unwrapping them
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) && ve.Inner != nil {
+			return nil, ve.Inner
+		}
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid || !claims.VerifyIssuer(issuer, true) || claims.ExpiresAt == 0 {
+		return nil, errors.New("user not validated")
+	}
+
+	if claims.ID, err = strconv.Atoi(claims.Subject); err != nil || claims.ID == 0 {
+		return nil, errors.New("user not validated")
 	}
 
-	return nil, errors.New("user not validated")
+	return claims, nil
 }
@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// minSecretLength min HS256 secret bytes, the secret must be as long as the hash output.
+const minSecretLength = 32
+
+var (
+	// ErrWeakSecret HS256 secret too short
+	ErrWeakSecret = errors.New("secret must have at least 32 bytes")
+
+	// ErrUnsupportedKey key type other than RSA or Ed25519
+	ErrUnsupportedKey = errors.New("unsupported key type")
+
+	// ErrSigningKeyRequired key set without a private key to sign the tokens
+	ErrSigningKeyRequired = errors.New("signing key required")
+
+	// ErrDuplicateKeyID two keys with the same kid
+	ErrDuplicateKeyID = errors.New("duplicate key id")
+
+	// ErrUnknownKey token signed by a key not in the key set
+	ErrUnknownKey = errors.New("unknown signing key")
+)
+
+// Key a JWT signing or verification key identified by its kid.
+//
+// a verification only Key, e.g. the public key of a rotated key pair, has no signing key.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey HS256 Key builder, the secret both signs and verifies the tokens.
+func NewHMACKey(id string, secret []byte) (*Key, error) {
+	if len(secret) < minSecretLength {
+		return nil, ErrWeakSecret
+	}
+
+	return &Key{
+		ID:        id,
+		Method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}, nil
+}
+
+// ParsePrivateKey builds a signing Key from a PEM encoded RSA (RS256) or Ed25519 (EdDSA) private key,
+// in PKCS #8 or, for RSA, PKCS #1 form.
+func ParsePrivateKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("could not decode PEM private key")
+	}
+
+	var (
+		key interface{}
+		err error
+	)
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse private key")
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: SigningMethodEdDSA, signKey: k, verifyKey: k.Public()}, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+// ParsePublicKey builds a verification only Key from a PEM encoded RSA or Ed25519 public key in PKIX form.
+func ParsePublicKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("could not decode PEM public key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse public key")
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, verifyKey: k}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: SigningMethodEdDSA, verifyKey: k}, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+// JWK a public key in the JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS the JSON Web Key Set served to let other services verify the tokens.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// publicJWK returns the Key in the JWK format, a HS256 secret is never published.
+func (k *Key) publicJWK() (JWK, bool) {
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// KeySet the Key signing the new tokens and the Keys still accepted to verify them.
+//
+// to rotate a key the new Key signs the tokens and the previous Key is kept to verify
+// the tokens it signed until they expire.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	order   []string
+}
+
+// NewKeySet KeySet builder.
+// the signing Key must have a signing key and every Key a unique kid.
+func NewKeySet(signing *Key, previous ...*Key) (*KeySet, error) {
+	if signing == nil || signing.signKey == nil {
+		return nil, ErrSigningKeyRequired
+	}
+
+	ks := &KeySet{
+		signing: signing,
+		keys:    make(map[string]*Key, len(previous)+1),
+	}
+
+	for _, k := range append([]*Key{signing}, previous...) {
+		if _, ok := ks.keys[k.ID]; ok {
+			return nil, errors.Wrapf(ErrDuplicateKeyID, "kid %q", k.ID)
+		}
+
+		ks.keys[k.ID] = k
+		ks.order = append(ks.order, k.ID)
+	}
+
+	return ks, nil
+}
+
+// JWKS returns the public keys of the KeySet, the signing key first.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.order))}
+
+	for _, id := range ks.order {
+		if jwk, ok := ks.keys[id].publicJWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	return set
+}
+
+// sign signs the token with the signing Key, its kid is set in the token header.
+func (ks *KeySet) sign(token *jwt.Token) (string, error) {
+	token.Header["kid"] = ks.signing.ID
+
+	return token.SignedString(ks.signing.signKey)
+}
+
+// verificationKey jwt.Keyfunc returning the key with the token kid,
+// the token must be signed with the algorithm of the key.
+func (ks *KeySet) verificationKey(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header["kid"].(string)
+
+	key, ok := ks.keys[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected method")
+	}
+
+	return key.verifyKey, nil
+}
@@ -0,0 +1,212 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func newClaims(t *testing.T, ttl time.Duration) *auth.Claims {
+	claims, err := auth.NewClaims(&entity.User{ID: 3, Username: "test", Role: entity.UserRoleAdmin}, "login", ttl, time.Now())
+	assert.NoError(t, err)
+
+	return claims
+}
+
+func rsaKeyPEM(t *testing.T) (private, public []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+}
+
+func ed25519KeyPEM(t *testing.T) (private, public []byte) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	priv, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	assert.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+}
+
+func TestKeySet_ValidateJWTToken(t *testing.T) {
+	rsaPrivate, _ := rsaKeyPEM(t)
+	edPrivate, _ := ed25519KeyPEM(t)
+
+	hmacKey, err := auth.NewHMACKey("hmac", secret)
+	assert.NoError(t, err)
+	rsaKey, err := auth.ParsePrivateKey("rsa", rsaPrivate)
+	assert.NoError(t, err)
+	edKey, err := auth.ParsePrivateKey("ed", edPrivate)
+	assert.NoError(t, err)
+
+	var tt = []struct {
+		name string
+		key  *auth.Key
+		alg  string
+	}{
+		{
+			name: "When the key is a HS256 secret; should validate the token",
+			key:  hmacKey,
+			alg:  "HS256",
+		},
+		{
+			name: "When the key is a RSA key pair; should validate the token",
+			key:  rsaKey,
+			alg:  "RS256",
+		},
+		{
+			name: "When the key is a Ed25519 key pair; should validate the token",
+			key:  edKey,
+			alg:  "EdDSA",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			keys, err := auth.NewKeySet(tc.key)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.alg, tc.key.Method.Alg())
+
+			token, err := keys.CreateJWTToken(newClaims(t, time.Hour))
+			assert.NoError(t, err)
+
+			user, err := keys.ValidateJWTToken(token)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, user.GetId())
+			assert.Equal(t, "test", user.GetUsername())
+			assert.Equal(t, "login", user.GetLoginID())
+			assert.Equal(t, entity.UserRoleAdmin, user.GetRole())
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldPrivate, oldPublic := rsaKeyPEM(t)
+	newPrivate, _ := ed25519KeyPEM(t)
+
+	oldKey, err := auth.ParsePrivateKey("2020-01", oldPrivate)
+	assert.NoError(t, err)
+	oldKeys, err := auth.NewKeySet(oldKey)
+	assert.NoError(t, err)
+
+	oldToken, err := oldKeys.CreateJWTToken(newClaims(t, time.Hour))
+	assert.NoError(t, err)
+
+	newKey, err := auth.ParsePrivateKey("2020-02", newPrivate)
+	assert.NoError(t, err)
+	previous, err := auth.ParsePublicKey("2020-01", oldPublic)
+	assert.NoError(t, err)
+
+	// a token signed by the rotated key is valid until it expires
+	keys, err := auth.NewKeySet(newKey, previous)
+	assert.NoError(t, err)
+
+	user, err := keys.ValidateJWTToken(oldToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, user.GetId())
+
+	// a token signed by a removed key isn't valid
+	keys, err = auth.NewKeySet(newKey)
+	assert.NoError(t, err)
+
+	_, err = keys.ValidateJWTToken(oldToken)
+	assert.ErrorIs(t, err, auth.ErrUnknownKey)
+
+	jwks := keys.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "2020-02", jwks.Keys[0].Kid)
+}
+
+func TestKeySet_ValidateJWTTokenErrors(t *testing.T) {
+	hmacKey, err := auth.NewHMACKey("key", secret)
+	assert.NoError(t, err)
+	hmacKeys, err := auth.NewKeySet(hmacKey)
+	assert.NoError(t, err)
+
+	rsaPrivate, _ := rsaKeyPEM(t)
+	rsaKey, err := auth.ParsePrivateKey("key", rsaPrivate)
+	assert.NoError(t, err)
+	rsaKeys, err := auth.NewKeySet(rsaKey)
+	assert.NoError(t, err)
+
+	// a token signed with the same kid but another algorithm isn't valid
+	token, err := hmacKeys.CreateJWTToken(newClaims(t, time.Hour))
+	assert.NoError(t, err)
+
+	_, err = rsaKeys.ValidateJWTToken(token)
+	assert.Error(t, err)
+
+	// an expired token isn't valid
+	token, err = hmacKeys.CreateJWTToken(newClaims(t, -time.Minute))
+	assert.NoError(t, err)
+
+	_, err = hmacKeys.ValidateJWTToken(token)
+	assert.Error(t, err)
+
+	// a token without expiration isn't valid
+	claims := newClaims(t, time.Hour)
+	claims.ExpiresAt = 0
+	token, err = hmacKeys.CreateJWTToken(claims)
+	assert.NoError(t, err)
+
+	_, err = hmacKeys.ValidateJWTToken(token)
+	assert.Error(t, err)
+
+	_, err = auth.NewHMACKey("weak", []byte("none"))
+	assert.ErrorIs(t, err, auth.ErrWeakSecret)
+
+	_, err = auth.NewKeySet(hmacKey, hmacKey)
+	assert.ErrorIs(t, err, auth.ErrDuplicateKeyID)
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	rsaPrivate, _ := rsaKeyPEM(t)
+	_, edPublic := ed25519KeyPEM(t)
+
+	rsaKey, err := auth.ParsePrivateKey("rsa", rsaPrivate)
+	assert.NoError(t, err)
+	edKey, err := auth.ParsePublicKey("ed", edPublic)
+	assert.NoError(t, err)
+	hmacKey, err := auth.NewHMACKey("hmac", secret)
+	assert.NoError(t, err)
+
+	keys, err := auth.NewKeySet(rsaKey, edKey, hmacKey)
+	assert.NoError(t, err)
+
+	// the HS256 secret is never published
+	jwks := keys.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+
+	assert.Equal(t, "rsa", jwks.Keys[0].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "RS256", jwks.Keys[0].Alg)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+	assert.NotEmpty(t, jwks.Keys[0].N)
+
+	assert.Equal(t, "ed", jwks.Keys[1].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+	assert.Equal(t, "EdDSA", jwks.Keys[1].Alg)
+	assert.Equal(t, "Ed25519", jwks.Keys[1].Crv)
+	assert.NotEmpty(t, jwks.Keys[1].X)
+}
@@ -0,0 +1,176 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+const (
+	codeFence = "```"
+	lineBreak = "<br>"
+)
+
+// allowedSchemes link schemes allowed to be rendered as an anchor.
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// Render parses the supported Markdown subset and returns a sanitized HTML fragment.
+//
+// supported syntax: **bold**, `inline code`, [links](https://example.com) and fenced code blocks.
+// any other content, including raw HTML, is escaped.
+func Render(raw string) string {
+	return render(raw, nil)
+}
+
+// Links returns the href of every link rendered as an anchor by Render, in order.
+// the links with a scheme not allowed, like javascript:, aren't rendered nor returned.
+func Links(raw string) []string {
+	var links []string
+	render(raw, &links)
+
+	return links
+}
+
+// render renders the content and collects the rendered hrefs in links, when not nil.
+func render(raw string, links *[]string) string {
+	var (
+		sb     strings.Builder
+		code   []string
+		inCode bool
+		text   []string
+	)
+
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+
+	flushText := func() {
+		if len(text) == 0 {
+			return
+		}
+		rendered := make([]string, 0, len(text))
+		for _, l := range text {
+			rendered = append(rendered, renderInline(l, true, links))
+		}
+		sb.WriteString(strings.Join(rendered, lineBreak))
+		text = nil
+	}
+
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), codeFence) {
+			if inCode {
+				writeCodeBlock(&sb, code)
+				code = nil
+				inCode = false
+				continue
+			}
+
+			flushText()
+			inCode = true
+			continue
+		}
+
+		if inCode {
+			code = append(code, line)
+			continue
+		}
+
+		text = append(text, line)
+	}
+
+	// an unclosed fence is rendered as a code block until the end of the content
+	if inCode {
+		writeCodeBlock(&sb, code)
+	}
+	flushText()
+
+	return sb.String()
+}
+
+// writeCodeBlock writes the escaped code lines as a preformatted block.
+func writeCodeBlock(sb *strings.Builder, lines []string) {
+	sb.WriteString("<pre><code>")
+	sb.WriteString(html.EscapeString(strings.Join(lines, "\n")))
+	sb.WriteString("</code></pre>")
+}
+
+// renderInline renders the inline elements of a single line.
+// links are not allowed inside a link text, withLinks avoids nested anchors.
+func renderInline(s string, withLinks bool, links *[]string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end > 0 {
+				sb.WriteString("<code>")
+				sb.WriteString(html.EscapeString(s[i+1 : i+1+end]))
+				sb.WriteString("</code>")
+				i += end + 2
+				continue
+			}
+
+		case strings.HasPrefix(s[i:], "**"):
+			if end := strings.Index(s[i+2:], "**"); end > 0 {
+				sb.WriteString("<strong>")
+				sb.WriteString(renderInline(s[i+2:i+2+end], withLinks, links))
+				sb.WriteString("</strong>")
+				i += end + 4
+				continue
+			}
+
+		case s[i] == '[' && withLinks:
+			if text, href, n, ok := parseLink(s[i:]); ok {
+				if links != nil {
+					*links = append(*links, href)
+				}
+				sb.WriteString(`<a href="`)
+				sb.WriteString(html.EscapeString(href))
+				sb.WriteString(`" rel="nofollow noopener noreferrer" target="_blank">`)
+				sb.WriteString(renderInline(text, false, links))
+				sb.WriteString("</a>")
+				i += n
+				continue
+			}
+		}
+
+		sb.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+
+	return sb.String()
+}
+
+// parseLink parses a [text](url) link at the beginning of s and returns the consumed bytes.
+// links with an unsafe scheme, like javascript:, are not parsed.
+func parseLink(s string) (string, string, int, bool) {
+	closeText := strings.Index(s, "](")
+	if closeText <= 1 {
+		return "", "", 0, false
+	}
+
+	closeURL := strings.IndexByte(s[closeText+2:], ')')
+	if closeURL <= 0 {
+		return "", "", 0, false
+	}
+
+	text := s[1:closeText]
+	href := strings.TrimSpace(s[closeText+2 : closeText+2+closeURL])
+
+	if !isSafeURL(href) {
+		return "", "", 0, false
+	}
+
+	return text, href, closeText + 3 + closeURL, true
+}
+
+func isSafeURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+
+	return allowedSchemes[strings.ToLower(u.Scheme)]
+}
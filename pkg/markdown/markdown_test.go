@@ -0,0 +1,79 @@
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/vsantosalmeida/browser-chat/pkg/markdown"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	var tt = []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "When content is plain text; should return the same text",
+			input:    "hello world!",
+			expected: "hello world!",
+		},
+		{
+			name:     "When content has HTML; should escape it",
+			input:    `<script>alert("xss")</script>`,
+			expected: "&lt;script&gt;alert(&#34;xss&#34;)&lt;/script&gt;",
+		},
+		{
+			name:     "When content has bold text; should render strong tag",
+			input:    "hello **world**",
+			expected: "hello <strong>world</strong>",
+		},
+		{
+			name:     "When content has inline code; should render code tag without parsing it",
+			input:    "run `**go** <test>`",
+			expected: "run <code>**go** &lt;test&gt;</code>",
+		},
+		{
+			name:     "When content has a link; should render anchor",
+			input:    "see [the **docs**](https://example.com?a=1&b=2)",
+			expected: `see <a href="https://example.com?a=1&amp;b=2" rel="nofollow noopener noreferrer" target="_blank">the <strong>docs</strong></a>`,
+		},
+		{
+			name:     "When content has an unsafe link; should keep it as text",
+			input:    "[click](javascript:alert(1))",
+			expected: "[click](javascript:alert(1))",
+		},
+		{
+			name:     "When content has a code block; should render pre tag",
+			input:    "code:\n```\nfunc main() {\n\t<b>\n}\n```\ndone",
+			expected: "code:<pre><code>func main() {\n\t&lt;b&gt;\n}</code></pre>done",
+		},
+		{
+			name:     "When content has multiple lines; should render line breaks",
+			input:    "first\r\nsecond",
+			expected: "first<br>second",
+		},
+		{
+			name:     "When markers are not closed; should keep them as text",
+			input:    "**bold `code",
+			expected: "**bold `code",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, markdown.Render(tc.input))
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	input := "see [docs](https://example.com), [x](http:evil.com) and [mail](MAILTO:a@spam.com)\n" +
+		"`[code](https://code.com)` [js](javascript:alert(1)) **[bold](HTTP://Bold.com)**\n" +
+		"```\n[fenced](https://fenced.com)\n```"
+
+	expected := []string{"https://example.com", "http:evil.com", "MAILTO:a@spam.com", "HTTP://Bold.com"}
+
+	assert.Equal(t, expected, markdown.Links(input))
+}
@@ -0,0 +1,16 @@
+package filter
+
+import "github.com/vsantosalmeida/browser-chat/entity"
+
+// Filter checks a message content sent to a room.
+//
+// it returns the content to keep, changed or not, or an error wrapping entity.ErrMessageRejected
+// with the rejection reason.
+type Filter interface {
+	Apply(room *entity.Room, content string) (string, error)
+}
+
+// UseCase service to apply the content filters to the messages sent to a room.
+type UseCase interface {
+	Apply(room *entity.Room, content string) (string, error)
+}
@@ -0,0 +1,124 @@
+package filter
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/markdown"
+
+	"github.com/pkg/errors"
+)
+
+var linkRegexp = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>()\[\]"']+`)
+
+// LinkFilter rejects the messages with a link to a denied domain, or to a domain out of the allow list.
+// a domain matches its subdomains either.
+//
+// the links are the plain text URLs and every href rendered by markdown.Render, including the links
+// without slashes, e.g. http:example.com, and the mailto: addresses domains.
+type LinkFilter struct {
+	allow []string
+	deny  []string
+}
+
+// NewLinkFilter LinkFilter builder.
+// an empty allow list allows any domain not denied.
+func NewLinkFilter(allow, deny []string) *LinkFilter {
+	return &LinkFilter{
+		allow: normalizeDomains(allow),
+		deny:  normalizeDomains(deny),
+	}
+}
+
+// Apply checks the domain of every link in the content.
+func (f *LinkFilter) Apply(_ *entity.Room, content string) (string, error) {
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return content, nil
+	}
+
+	links := append(linkRegexp.FindAllString(content, -1), markdown.Links(content)...)
+
+	for _, link := range links {
+		for _, host := range linkHosts(link) {
+			if matchDomain(host, f.deny) || (len(f.allow) > 0 && !matchDomain(host, f.allow)) {
+				return "", errors.Wrapf(entity.ErrMessageRejected, "link to %s is not allowed", host)
+			}
+		}
+	}
+
+	return content, nil
+}
+
+// linkHosts returns the hosts a link leads to, the domain of each address of a mailto: link.
+// a link without a host returns an empty host, it's only allowed when there's no allow list.
+func linkHosts(link string) []string {
+	// a plain text link may start with www. without a scheme
+	if strings.HasPrefix(strings.ToLower(link), "www.") {
+		link = "http://" + link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return []string{""}
+	}
+
+	if strings.EqualFold(u.Scheme, "mailto") {
+		var hosts []string
+		for _, address := range strings.Split(u.Opaque, ",") {
+			hosts = append(hosts, normalizeHost(address[strings.LastIndex(address, "@")+1:]))
+		}
+		return hosts
+	}
+
+	if u.Host != "" {
+		return []string{normalizeHost(u.Hostname())}
+	}
+
+	// the browsers read http:example.com and http:/example.com as http://example.com
+	rest := u.Opaque
+	if rest == "" {
+		rest = u.Path
+	}
+	rest = strings.TrimLeft(rest, "/\\")
+	if end := strings.IndexAny(rest, "/\\?#"); end >= 0 {
+		rest = rest[:end]
+	}
+
+	u, err = url.Parse("http://" + rest)
+	if err != nil {
+		return []string{""}
+	}
+
+	return []string{normalizeHost(u.Hostname())}
+}
+
+func normalizeHost(host string) string {
+	if unescaped, err := url.PathUnescape(host); err == nil {
+		host = unescaped
+	}
+
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+func matchDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeDomains(domains []string) []string {
+	var normalized []string
+	for _, d := range domains {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			normalized = append(normalized, d)
+		}
+	}
+
+	return normalized
+}
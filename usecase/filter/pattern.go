@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"github.com/pkg/errors"
+)
+
+// maxCachedPatterns max compiled patterns kept, the cache is cleared when it's reached.
+const maxCachedPatterns = 1000
+
+// PatternFilter rejects the messages matching one of the room blocked patterns.
+type PatternFilter struct {
+	mu    sync.Mutex
+	cache map[string]*regexp.Regexp
+}
+
+// NewPatternFilter PatternFilter builder.
+func NewPatternFilter() *PatternFilter {
+	return &PatternFilter{
+		cache: make(map[string]*regexp.Regexp),
+	}
+}
+
+// Apply rejects the content if any room blocked pattern matches it.
+func (f *PatternFilter) Apply(room *entity.Room, content string) (string, error) {
+	for _, p := range room.ContentPatterns() {
+		re, err := f.compile(p)
+		if err != nil {
+			// the patterns are validated with the room, an invalid one is skipped
+			continue
+		}
+
+		if re.MatchString(content) {
+			return "", errors.Wrap(entity.ErrMessageRejected, "message matches a blocked pattern")
+		}
+	}
+
+	return content, nil
+}
+
+func (f *PatternFilter) compile(p string) (*regexp.Regexp, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if re, ok := f.cache[p]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.cache) >= maxCachedPatterns {
+		f.cache = make(map[string]*regexp.Regexp)
+	}
+	f.cache[p] = re
+
+	return re, nil
+}
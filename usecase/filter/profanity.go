@@ -0,0 +1,44 @@
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
+
+// ProfanityFilter masks the words of a word list, the message is never rejected.
+type ProfanityFilter struct {
+	re *regexp.Regexp
+}
+
+// NewProfanityFilter ProfanityFilter builder.
+// the words are matched as whole words ignoring the case, an empty list masks nothing.
+func NewProfanityFilter(words []string) *ProfanityFilter {
+	var quoted []string
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+
+	if len(quoted) == 0 {
+		return &ProfanityFilter{}
+	}
+
+	return &ProfanityFilter{
+		re: regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`),
+	}
+}
+
+// Apply replaces every character of a listed word with an asterisk.
+func (f *ProfanityFilter) Apply(_ *entity.Room, content string) (string, error) {
+	if f.re == nil {
+		return content, nil
+	}
+
+	return f.re.ReplaceAllStringFunc(content, func(word string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	}), nil
+}
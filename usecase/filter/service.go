@@ -0,0 +1,35 @@
+package filter
+
+import (
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"github.com/apex/log"
+)
+
+// Service implements UseCase interface.
+type Service struct {
+	filters []Filter
+}
+
+// NewService Service builder.
+// the filters are applied in the given order.
+func NewService(filters ...Filter) *Service {
+	return &Service{
+		filters: filters,
+	}
+}
+
+// Apply runs the message content through every filter, each filter receives the content returned by the previous one.
+// the first rejection stops the chain.
+func (s *Service) Apply(room *entity.Room, content string) (string, error) {
+	var err error
+	for _, f := range s.filters {
+		content, err = f.Apply(room, content)
+		if err != nil {
+			log.WithError(err).WithField("RoomID", room.ID).Info("message rejected")
+			return "", err
+		}
+	}
+
+	return content, nil
+}
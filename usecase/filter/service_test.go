@@ -0,0 +1,147 @@
+package filter_test
+
+import (
+	"testing"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/filter"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_Apply(t *testing.T) {
+	var tt = []struct {
+		name        string
+		room        *entity.Room
+		content     string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "When content has no filtered word or link; should return the content",
+			room:     &entity.Room{ID: 1},
+			content:  "hello world",
+			expected: "hello world",
+		},
+		{
+			name:     "When content has a profanity; should mask the word",
+			room:     &entity.Room{ID: 1},
+			content:  "what the Heck, heckler",
+			expected: "what the ****, heckler",
+		},
+		{
+			name:        "When content matches a room blocked pattern; should reject the message",
+			room:        &entity.Room{ID: 1, BlockedPatterns: "(?i)free\\s+money\ncrypto"},
+			content:     "get FREE money now",
+			expectedErr: "message matches a blocked pattern: message rejected by content filter",
+		},
+		{
+			name:        "When content matches any room blocked pattern; should reject the message",
+			room:        &entity.Room{ID: 1, BlockedPatterns: "(?i)free\\s+money\ncrypto"},
+			content:     "buy crypto",
+			expectedErr: "message matches a blocked pattern: message rejected by content filter",
+		},
+		{
+			name:     "When content has a link to an allowed subdomain; should return the content",
+			room:     &entity.Room{ID: 1},
+			content:  "see https://docs.example.com/page",
+			expected: "see https://docs.example.com/page",
+		},
+		{
+			name:        "When content has a link to a denied domain; should reject the message",
+			room:        &entity.Room{ID: 1},
+			content:     "see https://spam.example.com/page",
+			expectedErr: "link to spam.example.com is not allowed: message rejected by content filter",
+		},
+		{
+			name:        "When content has a link out of the allow list; should reject the message",
+			room:        &entity.Room{ID: 1},
+			content:     "see www.other.com",
+			expectedErr: "link to www.other.com is not allowed: message rejected by content filter",
+		},
+	}
+
+	svc := filter.NewService(
+		filter.NewPatternFilter(),
+		filter.NewLinkFilter([]string{"example.com"}, []string{"spam.example.com"}),
+		filter.NewProfanityFilter([]string{"heck", " "}),
+	)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := svc.Apply(tc.room, tc.content)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				assert.ErrorIs(t, err, entity.ErrMessageRejected)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestLinkFilter_ApplyDenyOnly(t *testing.T) {
+	f := filter.NewLinkFilter(nil, []string{"Bad.com"})
+
+	got, err := f.Apply(&entity.Room{ID: 1}, "see https://good.com and http://notbad.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "see https://good.com and http://notbad.com", got)
+
+	_, err = f.Apply(&entity.Room{ID: 1}, "see HTTPS://www.bad.com/x")
+	assert.ErrorIs(t, err, entity.ErrMessageRejected)
+}
+
+func TestLinkFilter_ApplyMarkdownLinks(t *testing.T) {
+	var tt = []struct {
+		name        string
+		content     string
+		expectedErr string
+	}{
+		{
+			name:        "When a link has no slashes after the scheme; should check its host",
+			content:     "[x](http:bad.com)",
+			expectedErr: "link to bad.com is not allowed: message rejected by content filter",
+		},
+		{
+			name:        "When a link has a single slash after the scheme; should check its host",
+			content:     "[x](https:/www.bad.com/page)",
+			expectedErr: "link to www.bad.com is not allowed: message rejected by content filter",
+		},
+		{
+			name:        "When a link scheme is upper case; should check its host",
+			content:     "[x](HTTP://Bad.com)",
+			expectedErr: "link to bad.com is not allowed: message rejected by content filter",
+		},
+		{
+			name:        "When a mailto link has an address in a denied domain; should reject the message",
+			content:     "[mail](mailto:me@good.com,spam@bad.com?subject=hi)",
+			expectedErr: "link to bad.com is not allowed: message rejected by content filter",
+		},
+		{
+			name:    "When a mailto link has an address in an allowed domain; should return the content",
+			content: "[mail](mailto:me@good.com)",
+		},
+		{
+			name:    "When a denied link is in inline code; should return the content, it isn't rendered as a link",
+			content: "`[x](http:bad.com)`",
+		},
+	}
+
+	f := filter.NewLinkFilter(nil, []string{"bad.com"})
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := f.Apply(&entity.Room{ID: 1}, tc.content)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				assert.ErrorIs(t, err, entity.ErrMessageRejected)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.content, got)
+		})
+	}
+}
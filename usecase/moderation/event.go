@@ -0,0 +1,36 @@
+package moderation
+
+import "time"
+
+const (
+	// EventMemberKicked a user was kicked from a room.
+	EventMemberKicked = "memberKicked"
+	// EventMemberBanned a user was banned from a room.
+	EventMemberBanned = "memberBanned"
+	// EventMemberMuted a user was muted in a room.
+	EventMemberMuted = "memberMuted"
+	// EventMemberUnbanned a user ban was revoked.
+	EventMemberUnbanned = "memberUnbanned"
+	// EventMemberUnmuted a user mute was revoked.
+	EventMemberUnmuted = "memberUnmuted"
+)
+
+// Event notifies a moderation action to every chat-api instance.
+type Event struct {
+	Type      string     `json:"type"`
+	RoomID    int        `json:"roomID"`
+	UserID    int        `json:"userID"`
+	ActorID   int        `json:"actorID"`
+	Reason    string     `json:"reason,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// IsEvent checks if the event type is a moderation event.
+func IsEvent(eventType string) bool {
+	switch eventType {
+	case EventMemberKicked, EventMemberBanned, EventMemberMuted, EventMemberUnbanned, EventMemberUnmuted:
+		return true
+	default:
+		return false
+	}
+}
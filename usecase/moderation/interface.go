@@ -0,0 +1,50 @@
+package moderation
+
+import (
+	"context"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
+
+// Reader handle the required methods to read room sanctions DB.
+type Reader interface {
+	ListActiveSanctions(roomID, userID int, now time.Time) ([]*entity.RoomSanction, error)
+	ListRoomSanctions(roomID int, now time.Time) ([]*entity.RoomSanction, error)
+}
+
+// Writer handle the required methods to write room sanctions DB.
+type Writer interface {
+	CreateSanction(e *entity.RoomSanction) (int, error)
+	RevokeSanctions(roomID, userID int, sanctionType string, now time.Time) error
+}
+
+// Repository interface to bind Reader and Writer methods.
+type Repository interface {
+	Reader
+	Writer
+}
+
+// Publisher interface to notify the moderation events to every chat-api instance.
+type Publisher interface {
+	WriteMessage(ctx context.Context, payload []byte) error
+}
+
+// SanctionInput input to kick, ban or mute a user, a zero Duration ban or mute never expires.
+type SanctionInput struct {
+	UserID   int
+	Reason   string
+	Duration time.Duration
+}
+
+// UseCase service to handle the business rules for moderation context.
+type UseCase interface {
+	Kick(actorID, roomID int, input SanctionInput) error
+	Ban(actorID, roomID int, input SanctionInput) error
+	Mute(actorID, roomID int, input SanctionInput) error
+	Unban(actorID, roomID, userID int) error
+	Unmute(actorID, roomID, userID int) error
+	ListSanctions(actorID, roomID int) ([]*entity.RoomSanction, error)
+	CheckCanJoin(userID int, room *entity.Room) error
+	CheckCanPost(userID int, room *entity.Room) error
+}
@@ -0,0 +1,111 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	entity "github.com/vsantosalmeida/browser-chat/entity"
+
+	time "time"
+)
+
+// Repository is an autogenerated mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// CreateSanction provides a mock function with given fields: e
+func (_m *Repository) CreateSanction(e *entity.RoomSanction) (int, error) {
+	ret := _m.Called(e)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(*entity.RoomSanction) int); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*entity.RoomSanction) error); ok {
+		r1 = rf(e)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListActiveSanctions provides a mock function with given fields: roomID, userID, now
+func (_m *Repository) ListActiveSanctions(roomID int, userID int, now time.Time) ([]*entity.RoomSanction, error) {
+	ret := _m.Called(roomID, userID, now)
+
+	var r0 []*entity.RoomSanction
+	if rf, ok := ret.Get(0).(func(int, int, time.Time) []*entity.RoomSanction); ok {
+		r0 = rf(roomID, userID, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.RoomSanction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, int, time.Time) error); ok {
+		r1 = rf(roomID, userID, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRoomSanctions provides a mock function with given fields: roomID, now
+func (_m *Repository) ListRoomSanctions(roomID int, now time.Time) ([]*entity.RoomSanction, error) {
+	ret := _m.Called(roomID, now)
+
+	var r0 []*entity.RoomSanction
+	if rf, ok := ret.Get(0).(func(int, time.Time) []*entity.RoomSanction); ok {
+		r0 = rf(roomID, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.RoomSanction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, time.Time) error); ok {
+		r1 = rf(roomID, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeSanctions provides a mock function with given fields: roomID, userID, sanctionType, now
+func (_m *Repository) RevokeSanctions(roomID int, userID int, sanctionType string, now time.Time) error {
+	ret := _m.Called(roomID, userID, sanctionType, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, int, string, time.Time) error); ok {
+		r0 = rf(roomID, userID, sanctionType, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewRepository(t mockConstructorTestingTNewRepository) *Repository {
+	mock := &Repository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
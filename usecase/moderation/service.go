@@ -0,0 +1,258 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/room"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// Service implements UseCase interface.
+type Service struct {
+	repo   Repository
+	rooms  room.UseCase
+	events Publisher
+}
+
+// NewService Service builder.
+func NewService(r Repository, rooms room.UseCase, events Publisher) *Service {
+	return &Service{
+		repo:   r,
+		rooms:  rooms,
+		events: events,
+	}
+}
+
+// Kick removes the user from the room on every session, the membership is kept and the user may join it again.
+func (s *Service) Kick(actorID, roomID int, input SanctionInput) error {
+	return s.sanction(actorID, roomID, entity.SanctionKick, EventMemberKicked, input)
+}
+
+// Ban kicks the user, removes the room membership and denies joining the room until the ban expires.
+func (s *Service) Ban(actorID, roomID int, input SanctionInput) error {
+	return s.sanction(actorID, roomID, entity.SanctionBan, EventMemberBanned, input)
+}
+
+// Mute rejects the user messages in the room until the mute expires, the user still reads the room.
+func (s *Service) Mute(actorID, roomID int, input SanctionInput) error {
+	return s.sanction(actorID, roomID, entity.SanctionMute, EventMemberMuted, input)
+}
+
+// Unban revokes the user active bans in the room.
+func (s *Service) Unban(actorID, roomID, userID int) error {
+	return s.revoke(actorID, roomID, userID, entity.SanctionBan, EventMemberUnbanned)
+}
+
+// Unmute revokes the user active mutes in the room.
+func (s *Service) Unmute(actorID, roomID, userID int) error {
+	return s.revoke(actorID, roomID, userID, entity.SanctionMute, EventMemberUnmuted)
+}
+
+// ListSanctions retrieve the active bans and mutes of the room.
+// only the room owner and moderators are allowed to list them.
+func (s *Service) ListSanctions(actorID, roomID int) ([]*entity.RoomSanction, error) {
+	rm, err := s.rooms.FindRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.rooms.Authorize(actorID, rm, entity.PermissionBanMember); err != nil {
+		return nil, errors.Wrap(err, "could not list sanctions")
+	}
+
+	sanctions, err := s.repo.ListRoomSanctions(roomID, time.Now())
+	if err != nil {
+		log.WithError(err).WithField("RoomID", roomID).Error("could not retrieve sanctions list")
+		return nil, errors.Wrap(err, "could not retrieve sanctions list")
+	}
+
+	return sanctions, nil
+}
+
+// CheckCanJoin checks if the user isn't banned from the room.
+func (s *Service) CheckCanJoin(userID int, rm *entity.Room) error {
+	sanctions, err := s.activeSanctions(userID, rm.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, sanction := range sanctions {
+		if sanction.Type == entity.SanctionBan {
+			return sanctionError(entity.ErrUserBanned, "could not join room", sanction)
+		}
+	}
+
+	return nil
+}
+
+// CheckCanPost checks if the user isn't banned from or muted in the room.
+func (s *Service) CheckCanPost(userID int, rm *entity.Room) error {
+	sanctions, err := s.activeSanctions(userID, rm.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, sanction := range sanctions {
+		switch sanction.Type {
+		case entity.SanctionBan:
+			return sanctionError(entity.ErrUserBanned, "could not post message", sanction)
+		case entity.SanctionMute:
+			return sanctionError(entity.ErrUserMuted, "could not post message", sanction)
+		}
+	}
+
+	return nil
+}
+
+// sanction stores the sanction and notify it to every chat-api instance.
+func (s *Service) sanction(actorID, roomID int, sanctionType, eventType string, input SanctionInput) error {
+	logger := log.WithFields(log.Fields{
+		"RoomID":   roomID,
+		"UserID":   input.UserID,
+		"ActorID":  actorID,
+		"Sanction": sanctionType,
+	})
+
+	sanction, err := entity.NewRoomSanction(roomID, input.UserID, actorID, sanctionType, input.Reason, input.Duration)
+	if err != nil {
+		logger.WithError(err).Error("could not create a sanction object")
+		return errors.Wrap(err, "could not create a sanction object")
+	}
+
+	p := entity.PermissionBanMember
+	if sanctionType == entity.SanctionKick {
+		p = entity.PermissionKickMember
+	}
+
+	if _, err = s.findRoomToModerate(actorID, roomID, input.UserID, p); err != nil {
+		return err
+	}
+
+	id, err := s.repo.CreateSanction(sanction)
+	if err != nil {
+		logger.WithError(err).Error("could not create sanction on DB")
+		return errors.Wrap(err, "could not create sanction on DB")
+	}
+
+	logger.WithField("id", id).Info("sanction created")
+
+	s.publish(Event{
+		Type:      eventType,
+		RoomID:    roomID,
+		UserID:    input.UserID,
+		ActorID:   actorID,
+		Reason:    sanction.Reason,
+		ExpiresAt: sanction.ExpiresAt,
+	})
+
+	return nil
+}
+
+// revoke revokes the user active sanctions of the given type and notify it to every chat-api instance.
+func (s *Service) revoke(actorID, roomID, userID int, sanctionType, eventType string) error {
+	if _, err := s.findRoomToModerate(actorID, roomID, userID, entity.PermissionBanMember); err != nil {
+		return err
+	}
+
+	if err := s.repo.RevokeSanctions(roomID, userID, sanctionType, time.Now()); err != nil {
+		log.WithError(err).WithField("RoomID", roomID).Error("could not revoke sanctions on DB")
+		return errors.Wrap(err, "could not revoke sanctions on DB")
+	}
+
+	log.WithFields(log.Fields{
+		"RoomID":   roomID,
+		"UserID":   userID,
+		"ActorID":  actorID,
+		"Sanction": sanctionType,
+	}).Info("sanctions revoked")
+
+	s.publish(Event{
+		Type:    eventType,
+		RoomID:  roomID,
+		UserID:  userID,
+		ActorID: actorID,
+	})
+
+	return nil
+}
+
+// findRoomToModerate retrieve a room where the actor is allowed to moderate the user,
+// the actor role must be granted the permission and be higher than the user role.
+func (s *Service) findRoomToModerate(actorID, roomID, userID int, p entity.Permission) (*entity.Room, error) {
+	rm, err := s.rooms.FindRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.rooms.Authorize(actorID, rm, p); err != nil {
+		return nil, errors.Wrap(err, "could not moderate user")
+	}
+
+	actorRole, err := s.rooms.Role(actorID, rm)
+	if err != nil {
+		return nil, err
+	}
+
+	userRole, err := s.rooms.Role(userID, rm)
+	if err != nil {
+		return nil, err
+	}
+
+	if !entity.RoleOutranks(actorRole, userRole) {
+		log.WithFields(log.Fields{
+			"RoomID":  roomID,
+			"UserID":  userID,
+			"ActorID": actorID,
+		}).Warn("user not allowed to moderate a higher role")
+		return nil, errors.Wrap(entity.ErrForbidden, "could not moderate user")
+	}
+
+	return rm, nil
+}
+
+func (s *Service) activeSanctions(userID, roomID int) ([]*entity.RoomSanction, error) {
+	sanctions, err := s.repo.ListActiveSanctions(roomID, userID, time.Now())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"RoomID": roomID,
+			"UserID": userID,
+		}).Error("could not retrieve active sanctions")
+		return nil, errors.Wrap(err, "could not retrieve active sanctions")
+	}
+
+	return sanctions, nil
+}
+
+// publish notifies a moderation event to every chat-api instance.
+// errors are only logged, the event is a best effort notification.
+func (s *Service) publish(e Event) {
+	logger := log.WithFields(log.Fields{
+		"RoomID": e.RoomID,
+		"UserID": e.UserID,
+		"Event":  e.Type,
+	})
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		logger.WithError(err).Error("could not encode moderation event")
+		return
+	}
+
+	if err = s.events.WriteMessage(context.Background(), b); err != nil {
+		logger.WithError(err).Error("could not publish moderation event")
+	}
+}
+
+// sanctionError wraps the sanction error with its expiration time.
+func sanctionError(err error, msg string, sanction *entity.RoomSanction) error {
+	if sanction.ExpiresAt == nil {
+		return errors.Wrap(err, msg)
+	}
+
+	return errors.Wrapf(err, "%s until %s", msg, sanction.ExpiresAt.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,278 @@
+package moderation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/moderation"
+	"github.com/vsantosalmeida/browser-chat/usecase/moderation/mocks"
+	"github.com/vsantosalmeida/browser-chat/usecase/room"
+	roomMock "github.com/vsantosalmeida/browser-chat/usecase/room/mocks"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/undefinedlabs/go-mpatch"
+)
+
+var errDB = errors.New("db error")
+
+func TestService_Ban(t *testing.T) {
+	var (
+		now       = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		expiresAt = now.Add(time.Hour)
+
+		sanction = &entity.RoomSanction{
+			RoomID:    1,
+			UserID:    3,
+			ActorID:   2,
+			Type:      entity.SanctionBan,
+			Reason:    "spam",
+			ExpiresAt: &expiresAt,
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	roomRepo := roomMock.NewRepository(t)
+	svc := moderation.NewService(repository, room.NewService(roomRepo, nil), publisher)
+
+	// bypass time.Now function to set a static ban expiration
+	timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+		return now
+	})
+	assert.NoError(t, err)
+	defer timePatch.Unpatch()
+
+	roomRepo.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, CreatorID: 2}, nil).
+		Once()
+
+	roomRepo.
+		On("FindMember", 1, 3).
+		Return(nil, entity.ErrMemberNotFound).
+		Once()
+
+	repository.
+		On("CreateSanction", sanction).
+		Return(1, nil).
+		Once()
+
+	publisher.
+		On("WriteMessage", context.Background(), []byte(`{"type":"memberBanned","roomID":1,"userID":3,"actorID":2,"reason":"spam","expiresAt":"2020-01-01T01:00:00Z"}`)).
+		Return(nil).
+		Once()
+
+	err = svc.Ban(2, 1, moderation.SanctionInput{
+		UserID:   3,
+		Reason:   "spam",
+		Duration: time.Hour,
+	})
+	assert.NoError(t, err)
+}
+
+func TestService_KickErrors(t *testing.T) {
+	var tt = []struct {
+		name        string
+		actorID     int
+		userID      int
+		roles       map[int]string
+		expectedErr error
+	}{
+		{
+			name:        "When the user kicks itself; should return invalid entity",
+			actorID:     3,
+			userID:      3,
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:        "When a member kicks a user; should return forbidden",
+			actorID:     4,
+			userID:      3,
+			roles:       map[int]string{4: entity.RoleMember},
+			expectedErr: entity.ErrForbidden,
+		},
+		{
+			name:        "When a moderator kicks another moderator; should return forbidden",
+			actorID:     4,
+			userID:      3,
+			roles:       map[int]string{4: entity.RoleModerator, 3: entity.RoleModerator},
+			expectedErr: entity.ErrForbidden,
+		},
+		{
+			name:        "When a moderator kicks the owner; should return forbidden",
+			actorID:     4,
+			userID:      2,
+			roles:       map[int]string{4: entity.RoleModerator},
+			expectedErr: entity.ErrForbidden,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			roomRepo := roomMock.NewRepository(t)
+			svc := moderation.NewService(repository, room.NewService(roomRepo, nil), nil)
+
+			roomRepo.
+				On("FindRoom", 1).
+				Return(&entity.Room{ID: 1, CreatorID: 2}, nil).
+				Maybe()
+
+			for userID, role := range tc.roles {
+				roomRepo.
+					On("FindMember", 1, userID).
+					Return(&entity.RoomMember{RoomID: 1, UserID: userID, Role: role}, nil).
+					Maybe()
+			}
+
+			err := svc.Kick(tc.actorID, 1, moderation.SanctionInput{
+				UserID: tc.userID,
+				Reason: "spam",
+			})
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestService_Unmute(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	roomRepo := roomMock.NewRepository(t)
+	svc := moderation.NewService(repository, room.NewService(roomRepo, nil), publisher)
+
+	roomRepo.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, CreatorID: 2}, nil).
+		Once()
+
+	roomRepo.
+		On("FindMember", 1, 3).
+		Return(nil, entity.ErrMemberNotFound).
+		Once()
+
+	repository.
+		On("RevokeSanctions", 1, 3, entity.SanctionMute, mock.AnythingOfType("time.Time")).
+		Return(nil).
+		Once()
+
+	publisher.
+		On("WriteMessage", context.Background(), []byte(`{"type":"memberUnmuted","roomID":1,"userID":3,"actorID":2}`)).
+		Return(nil).
+		Once()
+
+	err := svc.Unmute(2, 1, 3)
+	assert.NoError(t, err)
+}
+
+func TestService_ListSanctions(t *testing.T) {
+	var expected = []*entity.RoomSanction{
+		{
+			ID:     1,
+			RoomID: 1,
+			UserID: 3,
+			Type:   entity.SanctionMute,
+		},
+	}
+
+	repository := mocks.NewRepository(t)
+	roomRepo := roomMock.NewRepository(t)
+	svc := moderation.NewService(repository, room.NewService(roomRepo, nil), nil)
+
+	roomRepo.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, CreatorID: 2}, nil).
+		Once()
+
+	repository.
+		On("ListRoomSanctions", 1, mock.AnythingOfType("time.Time")).
+		Return([]*entity.RoomSanction{
+			{
+				ID:     1,
+				RoomID: 1,
+				UserID: 3,
+				Type:   entity.SanctionMute,
+			},
+		}, nil).
+		Once()
+
+	sanctions, err := svc.ListSanctions(2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, sanctions)
+}
+
+func TestService_CheckCanPost(t *testing.T) {
+	var tt = []struct {
+		name          string
+		mockSanctions []*entity.RoomSanction
+		mockErr       error
+		expected      string
+	}{
+		{
+			name: "When the user has no active sanction; should allow",
+		},
+		{
+			name: "When the user is muted; should return user muted",
+			mockSanctions: []*entity.RoomSanction{
+				{
+					Type: entity.SanctionMute,
+				},
+			},
+			expected: "could not post message: user muted in the room",
+		},
+		{
+			name: "When the user is banned; should return user banned",
+			mockSanctions: []*entity.RoomSanction{
+				{
+					Type: entity.SanctionBan,
+				},
+			},
+			expected: "could not post message: user banned from the room",
+		},
+		{
+			name:     "When the sanctions can't be retrieved; should return an error",
+			mockErr:  errDB,
+			expected: "could not retrieve active sanctions: db error",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			svc := moderation.NewService(repository, nil, nil)
+
+			repository.
+				On("ListActiveSanctions", 1, 3, mock.AnythingOfType("time.Time")).
+				Return(tc.mockSanctions, tc.mockErr).
+				Once()
+
+			err := svc.CheckCanPost(3, &entity.Room{ID: 1})
+			if tc.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
+
+func TestService_CheckCanJoin(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	svc := moderation.NewService(repository, nil, nil)
+
+	// a muted user still reads the room
+	repository.
+		On("ListActiveSanctions", 1, 3, mock.AnythingOfType("time.Time")).
+		Return([]*entity.RoomSanction{
+			{
+				Type: entity.SanctionMute,
+			},
+		}, nil).
+		Once()
+
+	err := svc.CheckCanJoin(3, &entity.Room{ID: 1})
+	assert.NoError(t, err)
+}
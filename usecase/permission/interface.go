@@ -0,0 +1,14 @@
+package permission
+
+import "github.com/vsantosalmeida/browser-chat/entity"
+
+// Reader handle the required methods to read the room members DB.
+type Reader interface {
+	FindMember(roomID, userID int) (*entity.RoomMember, error)
+}
+
+// UseCase service to handle the authorization rules of the room actions.
+type UseCase interface {
+	Role(userID int, room *entity.Room) (string, error)
+	Authorize(userID int, room *entity.Room, p entity.Permission) error
+}
@@ -0,0 +1,51 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	entity "github.com/vsantosalmeida/browser-chat/entity"
+)
+
+// Reader is an autogenerated mock type for the Reader type
+type Reader struct {
+	mock.Mock
+}
+
+// FindMember provides a mock function with given fields: roomID, userID
+func (_m *Reader) FindMember(roomID int, userID int) (*entity.RoomMember, error) {
+	ret := _m.Called(roomID, userID)
+
+	var r0 *entity.RoomMember
+	if rf, ok := ret.Get(0).(func(int, int) *entity.RoomMember); ok {
+		r0 = rf(roomID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.RoomMember)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, int) error); ok {
+		r1 = rf(roomID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewReader interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewReader creates a new instance of Reader. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewReader(t mockConstructorTestingTNewReader) *Reader {
+	mock := &Reader{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
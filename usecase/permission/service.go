@@ -0,0 +1,80 @@
+package permission
+
+import (
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// Service implements UseCase interface.
+type Service struct {
+	repo Reader
+}
+
+// NewService Service builder.
+func NewService(r Reader) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// Role retrieve the user role in the room.
+//
+// the room creator is always the owner, any user without a membership is a member of a public room.
+// returns an empty role if the user isn't a member of a private room.
+func (s *Service) Role(userID int, room *entity.Room) (string, error) {
+	if room.CreatorID == userID {
+		return entity.RoleOwner, nil
+	}
+
+	member, err := s.repo.FindMember(room.ID, userID)
+	if err != nil {
+		if !errors.Is(err, entity.ErrMemberNotFound) {
+			log.WithError(err).WithFields(log.Fields{
+				"RoomID": room.ID,
+				"UserID": userID,
+			}).Error("could not retrieve room member")
+			return "", errors.Wrap(err, "could not retrieve room member")
+		}
+
+		if room.Private {
+			return "", nil
+		}
+
+		return entity.RoleMember, nil
+	}
+
+	if member.Role == "" {
+		return entity.RoleMember, nil
+	}
+
+	return member.Role, nil
+}
+
+// Authorize checks if the user role in the room grants the permission.
+// returns entity.ErrForbidden if the user isn't allowed.
+//
+// any user is a member of a public room, the member permissions are granted without hitting the DB.
+func (s *Service) Authorize(userID int, room *entity.Room, p entity.Permission) error {
+	if !room.Private && entity.RoleHasPermission(entity.RoleMember, p) {
+		return nil
+	}
+
+	role, err := s.Role(userID, room)
+	if err != nil {
+		return err
+	}
+
+	if !entity.RoleHasPermission(role, p) {
+		log.WithFields(log.Fields{
+			"RoomID":     room.ID,
+			"UserID":     userID,
+			"Role":       role,
+			"Permission": p,
+		}).Warn("permission denied")
+		return entity.ErrForbidden
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package permission_test
+
+import (
+	"testing"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/permission"
+	"github.com/vsantosalmeida/browser-chat/usecase/permission/mocks"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var errDB = errors.New("db error")
+
+func TestService_Role(t *testing.T) {
+	var tt = []struct {
+		name       string
+		userID     int
+		room       *entity.Room
+		mockMember *entity.RoomMember
+		mockErr    error
+		expected   string
+	}{
+		{
+			name:     "When user is the room creator; should return owner",
+			userID:   2,
+			room:     &entity.Room{ID: 1, CreatorID: 2, Private: true},
+			expected: entity.RoleOwner,
+		},
+		{
+			name:       "When user is a moderator; should return moderator",
+			userID:     3,
+			room:       &entity.Room{ID: 1, CreatorID: 2},
+			mockMember: &entity.RoomMember{RoomID: 1, UserID: 3, Role: entity.RoleModerator},
+			expected:   entity.RoleModerator,
+		},
+		{
+			name:     "When user isn't a member of a public room; should return member",
+			userID:   3,
+			room:     &entity.Room{ID: 1, CreatorID: 2},
+			mockErr:  entity.ErrMemberNotFound,
+			expected: entity.RoleMember,
+		},
+		{
+			name:    "When user isn't a member of a private room; should return an empty role",
+			userID:  3,
+			room:    &entity.Room{ID: 1, CreatorID: 2, Private: true},
+			mockErr: entity.ErrMemberNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewReader(t)
+			svc := permission.NewService(repository)
+
+			if tc.userID != tc.room.CreatorID {
+				repository.
+					On("FindMember", tc.room.ID, tc.userID).
+					Return(tc.mockMember, tc.mockErr).
+					Once()
+			}
+
+			role, err := svc.Role(tc.userID, tc.room)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, role)
+		})
+	}
+}
+
+func TestService_RoleError(t *testing.T) {
+	var expected = "could not retrieve room member: db error"
+
+	repository := mocks.NewReader(t)
+	svc := permission.NewService(repository)
+
+	repository.
+		On("FindMember", 1, 3).
+		Return(nil, errDB).
+		Once()
+
+	role, err := svc.Role(3, &entity.Room{ID: 1, CreatorID: 2})
+	assert.EqualError(t, err, expected)
+	assert.Empty(t, role)
+}
+
+func TestService_Authorize(t *testing.T) {
+	var tt = []struct {
+		name        string
+		room        *entity.Room
+		permission  entity.Permission
+		mockRole    string
+		expectedErr error
+	}{
+		{
+			name:       "When a public room member permission is required; should allow without DB lookup",
+			room:       &entity.Room{ID: 1, CreatorID: 2},
+			permission: entity.PermissionPostMessage,
+		},
+		{
+			name:       "When a moderator changes the room settings; should allow",
+			room:       &entity.Room{ID: 1, CreatorID: 2},
+			permission: entity.PermissionChangeSettings,
+			mockRole:   entity.RoleModerator,
+		},
+		{
+			name:        "When a moderator deletes the room; should return forbidden",
+			room:        &entity.Room{ID: 1, CreatorID: 2},
+			permission:  entity.PermissionManageRoom,
+			mockRole:    entity.RoleModerator,
+			expectedErr: entity.ErrForbidden,
+		},
+		{
+			name:        "When a member kicks a user; should return forbidden",
+			room:        &entity.Room{ID: 1, CreatorID: 2, Private: true},
+			permission:  entity.PermissionKickMember,
+			mockRole:    entity.RoleMember,
+			expectedErr: entity.ErrForbidden,
+		},
+		{
+			name:       "When a member posts in a private room; should allow",
+			room:       &entity.Room{ID: 1, CreatorID: 2, Private: true},
+			permission: entity.PermissionPostMessage,
+			mockRole:   entity.RoleMember,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewReader(t)
+			svc := permission.NewService(repository)
+
+			if tc.mockRole != "" {
+				repository.
+					On("FindMember", 1, 3).
+					Return(&entity.RoomMember{RoomID: 1, UserID: 3, Role: tc.mockRole}, nil).
+					Once()
+			}
+
+			err := svc.Authorize(3, tc.room, tc.permission)
+			if tc.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
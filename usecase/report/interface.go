@@ -0,0 +1,53 @@
+package report
+
+import (
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
+
+// Reader handle the required methods to read the message reports DB.
+type Reader interface {
+	FindMessage(id int) (*entity.Message, error)
+	ListMessageContext(roomID, messageID, limit int) ([]*entity.Message, error)
+	FindReport(id int) (*entity.MessageReport, error)
+	FindOpenReport(messageID, reporterID int) (*entity.MessageReport, error)
+	ListReports(roomID int, status string) ([]*entity.MessageReport, error)
+	ListAuditEntries(reportID int) ([]*entity.ReportAuditEntry, error)
+}
+
+// Writer handle the required methods to write the message reports DB.
+type Writer interface {
+	CreateReport(e *entity.MessageReport) (int, error)
+	ResolveReport(e *entity.MessageReport, audit *entity.ReportAuditEntry, deleteMessage bool) error
+}
+
+// Repository interface to bind Reader and Writer methods.
+type Repository interface {
+	Reader
+	Writer
+}
+
+// ResolveInput moderator action to resolve a report.
+//
+// Duration is the ban duration of the entity.ReportActionBanUser action, a zero Duration ban never expires.
+type ResolveInput struct {
+	Action   string
+	Note     string
+	Duration time.Duration
+}
+
+// Detail a report with the messages around the reported message and its audit trail.
+type Detail struct {
+	Report  *entity.MessageReport
+	Context []*entity.Message
+	Audit   []*entity.ReportAuditEntry
+}
+
+// UseCase service to handle the business rules for the message reports context.
+type UseCase interface {
+	ReportMessage(reporterID, messageID int, reason string) (int, error)
+	ListReports(actorID, roomID int, status string) ([]*entity.MessageReport, error)
+	GetReport(actorID, reportID int) (*Detail, error)
+	ResolveReport(actorID, reportID int, input ResolveInput) error
+}
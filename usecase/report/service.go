@@ -0,0 +1,221 @@
+package report
+
+import (
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/moderation"
+	"github.com/vsantosalmeida/browser-chat/usecase/room"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// contextMessages messages listed before and after the reported message.
+const contextMessages = 5
+
+// Service implements UseCase interface.
+type Service struct {
+	repo       Repository
+	rooms      room.UseCase
+	moderation moderation.UseCase
+}
+
+// NewService Service builder.
+func NewService(r Repository, rooms room.UseCase, moderationUseCase moderation.UseCase) *Service {
+	return &Service{
+		repo:       r,
+		rooms:      rooms,
+		moderation: moderationUseCase,
+	}
+}
+
+// ReportMessage reports a message to the room moderators, the reporter must have access to the room.
+// a message already reported by the user and not reviewed yet isn't reported again.
+func (s *Service) ReportMessage(reporterID, messageID int, reason string) (int, error) {
+	logger := log.WithFields(log.Fields{
+		"MessageID":  messageID,
+		"ReporterID": reporterID,
+	})
+
+	msg, err := s.repo.FindMessage(messageID)
+	if err != nil {
+		if !errors.Is(err, entity.ErrMessageNotFound) {
+			logger.WithError(err).Error("could not retrieve message")
+		}
+		return 0, errors.Wrap(err, "could not retrieve message")
+	}
+
+	rm, err := s.rooms.FindRoom(msg.RoomID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = s.rooms.CheckAccess(reporterID, rm); err != nil {
+		return 0, err
+	}
+
+	report, err := entity.NewMessageReport(msg, reporterID, reason)
+	if err != nil {
+		logger.WithError(err).Error("could not create a report object")
+		return 0, errors.Wrap(err, "could not create a report object")
+	}
+
+	open, err := s.repo.FindOpenReport(messageID, reporterID)
+	if err != nil && !errors.Is(err, entity.ErrReportNotFound) {
+		logger.WithError(err).Error("could not retrieve open report")
+		return 0, errors.Wrap(err, "could not retrieve open report")
+	}
+	if open != nil {
+		return open.ID, nil
+	}
+
+	id, err := s.repo.CreateReport(report)
+	if err != nil {
+		logger.WithError(err).Error("could not create report on DB")
+		return 0, errors.Wrap(err, "could not create report on DB")
+	}
+
+	logger.WithField("id", id).Info("message reported")
+
+	return id, nil
+}
+
+// ListReports retrieve the room reports with the given status, the open reports by default.
+// only the room owner and moderators are allowed to list them.
+func (s *Service) ListReports(actorID, roomID int, status string) ([]*entity.MessageReport, error) {
+	switch status {
+	case "":
+		status = entity.ReportOpen
+	case entity.ReportOpen, entity.ReportDismissed, entity.ReportResolved:
+	default:
+		return nil, errors.Wrap(entity.ErrInvalidEntity, "could not use report status")
+	}
+
+	rm, err := s.rooms.FindRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.rooms.Authorize(actorID, rm, entity.PermissionReviewReports); err != nil {
+		return nil, errors.Wrap(err, "could not list reports")
+	}
+
+	reports, err := s.repo.ListReports(roomID, status)
+	if err != nil {
+		log.WithError(err).WithField("RoomID", roomID).Error("could not retrieve reports list")
+		return nil, errors.Wrap(err, "could not retrieve reports list")
+	}
+
+	return reports, nil
+}
+
+// GetReport retrieve the report with the messages sent around the reported message and its audit trail.
+// only the room owner and moderators are allowed to review it.
+func (s *Service) GetReport(actorID, reportID int) (*Detail, error) {
+	report, err := s.findReportToReview(actorID, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.repo.ListMessageContext(report.RoomID, report.MessageID, contextMessages)
+	if err != nil {
+		log.WithError(err).WithField("ReportID", reportID).Error("could not retrieve report context")
+		return nil, errors.Wrap(err, "could not retrieve report context")
+	}
+
+	audit, err := s.repo.ListAuditEntries(reportID)
+	if err != nil {
+		log.WithError(err).WithField("ReportID", reportID).Error("could not retrieve report audit")
+		return nil, errors.Wrap(err, "could not retrieve report audit")
+	}
+
+	return &Detail{
+		Report:  report,
+		Context: messages,
+		Audit:   audit,
+	}, nil
+}
+
+// ResolveReport reviews an open report with the moderator action, the action is recorded in the report audit trail.
+//
+// the entity.ReportActionBanUser action is applied as a moderation ban, the moderator must outrank the reported user.
+// the user is banned before the report is resolved, a failed ban leaves the report open to be resolved again.
+func (s *Service) ResolveReport(actorID, reportID int, input ResolveInput) error {
+	logger := log.WithFields(log.Fields{
+		"ReportID": reportID,
+		"ActorID":  actorID,
+		"Action":   input.Action,
+	})
+
+	report, err := s.findReportToReview(actorID, reportID)
+	if err != nil {
+		return err
+	}
+
+	if !report.IsOpen() {
+		return errors.Wrap(entity.ErrReportNotOpen, "could not resolve report")
+	}
+
+	if err = report.Resolve(actorID, input.Action, time.Now()); err != nil {
+		return errors.Wrap(err, "could not resolve report")
+	}
+
+	audit, err := entity.NewReportAuditEntry(report, actorID, input.Action, input.Note)
+	if err != nil {
+		return errors.Wrap(err, "could not create an audit object")
+	}
+
+	if input.Action == entity.ReportActionBanUser {
+		reason := audit.Note
+		if reason == "" {
+			reason = report.Reason
+		}
+
+		if err = s.moderation.Ban(actorID, report.RoomID, moderation.SanctionInput{
+			UserID:   report.ReportedUserID,
+			Reason:   reason,
+			Duration: input.Duration,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// only an open report is resolved, a report resolved meanwhile returns entity.ErrReportNotOpen
+	if err = s.repo.ResolveReport(report, audit, input.Action == entity.ReportActionDeleteMessage); err != nil {
+		if !errors.Is(err, entity.ErrReportNotOpen) {
+			logger.WithError(err).Error("could not resolve report on DB")
+		}
+		return errors.Wrap(err, "could not resolve report on DB")
+	}
+
+	logger.Info("report resolved")
+
+	return nil
+}
+
+// findReportToReview retrieve a report the actor is allowed to review.
+func (s *Service) findReportToReview(actorID, reportID int) (*entity.MessageReport, error) {
+	report, err := s.repo.FindReport(reportID)
+	if err != nil {
+		if !errors.Is(err, entity.ErrReportNotFound) {
+			log.WithError(err).WithField("ReportID", reportID).Error("could not retrieve report")
+		}
+		return nil, errors.Wrap(err, "could not retrieve report")
+	}
+
+	rm, err := s.rooms.FindRoom(report.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a user not allowed to review the report doesn't learn it exists
+	if err = s.rooms.Authorize(actorID, rm, entity.PermissionReviewReports); err != nil {
+		if errors.Is(err, entity.ErrForbidden) {
+			return nil, errors.Wrap(entity.ErrReportNotFound, "could not retrieve report")
+		}
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,427 @@
+package report_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/moderation"
+	moderationMock "github.com/vsantosalmeida/browser-chat/usecase/moderation/mocks"
+	"github.com/vsantosalmeida/browser-chat/usecase/report"
+	"github.com/vsantosalmeida/browser-chat/usecase/report/mocks"
+	"github.com/vsantosalmeida/browser-chat/usecase/room"
+	roomMock "github.com/vsantosalmeida/browser-chat/usecase/room/mocks"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/undefinedlabs/go-mpatch"
+)
+
+var errDB = errors.New("db error")
+
+func TestService_ReportMessage(t *testing.T) {
+	var (
+		msg = &entity.Message{ID: 7, UserID: 3, RoomID: 1, Content: "buy now"}
+
+		expected = &entity.MessageReport{
+			RoomID:         1,
+			Status:         entity.ReportOpen,
+			MessageID:      7,
+			ReportedUserID: 3,
+			ReporterID:     4,
+			Reason:         "spam",
+			Content:        "buy now",
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	roomRepo := roomMock.NewRepository(t)
+	svc := report.NewService(repository, room.NewService(roomRepo, nil), nil)
+
+	repository.
+		On("FindMessage", 7).
+		Return(msg, nil).
+		Once()
+
+	roomRepo.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, CreatorID: 2}, nil).
+		Once()
+
+	repository.
+		On("FindOpenReport", 7, 4).
+		Return(nil, entity.ErrReportNotFound).
+		Once()
+
+	repository.
+		On("CreateReport", expected).
+		Return(1, nil).
+		Once()
+
+	id, err := svc.ReportMessage(4, 7, " spam ")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+}
+
+func TestService_ReportMessageAlreadyReported(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	roomRepo := roomMock.NewRepository(t)
+	svc := report.NewService(repository, room.NewService(roomRepo, nil), nil)
+
+	repository.
+		On("FindMessage", 7).
+		Return(&entity.Message{ID: 7, UserID: 3, RoomID: 1}, nil).
+		Once()
+
+	roomRepo.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, CreatorID: 2}, nil).
+		Once()
+
+	repository.
+		On("FindOpenReport", 7, 4).
+		Return(&entity.MessageReport{ID: 5}, nil).
+		Once()
+
+	id, err := svc.ReportMessage(4, 7, "spam")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, id)
+}
+
+func TestService_ReportMessageErrors(t *testing.T) {
+	var tt = []struct {
+		name        string
+		reporterID  int
+		reason      string
+		mockMsgErr  error
+		mockRoom    *entity.Room
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:        "When message doesn't exist; should return error",
+			reporterID:  4,
+			reason:      "spam",
+			mockMsgErr:  entity.ErrMessageNotFound,
+			expected:    "could not retrieve message: message not found",
+			expectedErr: entity.ErrMessageNotFound,
+		},
+		{
+			name:        "When user reports its own message; should return invalid entity",
+			reporterID:  3,
+			reason:      "spam",
+			mockRoom:    &entity.Room{ID: 1, CreatorID: 2},
+			expected:    "could not create a report object: invalid entity",
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:        "When reason is empty; should return invalid entity",
+			reporterID:  4,
+			reason:      "  ",
+			mockRoom:    &entity.Room{ID: 1, CreatorID: 2},
+			expected:    "could not create a report object: invalid entity",
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:        "When user isn't a member of the private room; should return forbidden",
+			reporterID:  4,
+			reason:      "spam",
+			mockRoom:    &entity.Room{ID: 1, CreatorID: 2, Private: true},
+			expected:    "could not access room: forbidden",
+			expectedErr: entity.ErrForbidden,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			roomRepo := roomMock.NewRepository(t)
+			svc := report.NewService(repository, room.NewService(roomRepo, nil), nil)
+
+			if tc.mockMsgErr != nil {
+				repository.
+					On("FindMessage", 7).
+					Return(nil, tc.mockMsgErr).
+					Once()
+			} else {
+				repository.
+					On("FindMessage", 7).
+					Return(&entity.Message{ID: 7, UserID: 3, RoomID: 1}, nil).
+					Once()
+
+				roomRepo.
+					On("FindRoom", 1).
+					Return(tc.mockRoom, nil).
+					Once()
+			}
+
+			roomRepo.
+				On("FindMember", 1, tc.reporterID).
+				Return(nil, entity.ErrMemberNotFound).
+				Maybe()
+
+			id, err := svc.ReportMessage(tc.reporterID, 7, tc.reason)
+			assert.EqualError(t, err, tc.expected)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Zero(t, id)
+		})
+	}
+}
+
+func TestService_ResolveReport(t *testing.T) {
+	var (
+		now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		resolved = &entity.MessageReport{
+			ID:             5,
+			RoomID:         1,
+			Status:         entity.ReportResolved,
+			MessageID:      7,
+			ReportedUserID: 3,
+			ReporterID:     4,
+			Reason:         "spam",
+			Resolution:     entity.ReportActionBanUser,
+			ResolverID:     2,
+			ResolvedAt:     &now,
+		}
+
+		audit = &entity.ReportAuditEntry{
+			ReportID: 5,
+			RoomID:   1,
+			ActorID:  2,
+			Action:   entity.ReportActionBanUser,
+		}
+
+		sanction = &entity.RoomSanction{
+			RoomID:  1,
+			UserID:  3,
+			ActorID: 2,
+			Type:    entity.SanctionBan,
+			Reason:  "spam",
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	roomRepo := roomMock.NewRepository(t)
+	moderationRepo := moderationMock.NewRepository(t)
+	publisher := moderationMock.NewPublisher(t)
+
+	roomSvc := room.NewService(roomRepo, nil)
+	svc := report.NewService(repository, roomSvc, moderation.NewService(moderationRepo, roomSvc, publisher))
+
+	// bypass time.Now function to set a static resolution date
+	timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+		return now
+	})
+	assert.NoError(t, err)
+	defer timePatch.Unpatch()
+
+	repository.
+		On("FindReport", 5).
+		Return(&entity.MessageReport{
+			ID:             5,
+			RoomID:         1,
+			Status:         entity.ReportOpen,
+			MessageID:      7,
+			ReportedUserID: 3,
+			ReporterID:     4,
+			Reason:         "spam",
+		}, nil).
+		Once()
+
+	roomRepo.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, CreatorID: 2}, nil)
+
+	roomRepo.
+		On("FindMember", 1, 3).
+		Return(nil, entity.ErrMemberNotFound).
+		Once()
+
+	moderationRepo.
+		On("CreateSanction", sanction).
+		Return(1, nil).
+		Once()
+
+	publisher.
+		On("WriteMessage", context.Background(), mock.Anything).
+		Return(nil).
+		Once()
+
+	repository.
+		On("ResolveReport", resolved, audit, false).
+		Return(nil).
+		Once()
+
+	// the ban reason falls back to the report reason
+	err = svc.ResolveReport(2, 5, report.ResolveInput{
+		Action: entity.ReportActionBanUser,
+	})
+	assert.NoError(t, err)
+}
+
+func TestService_ResolveReportBanFailed(t *testing.T) {
+	var tt = []struct {
+		name        string
+		creatorID   int
+		sanctionErr error
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:        "When the moderator can't ban the room owner; should keep the report open",
+			creatorID:   3,
+			expected:    "could not moderate user: forbidden",
+			expectedErr: entity.ErrForbidden,
+		},
+		{
+			name:        "When the ban can't be stored; should keep the report open",
+			creatorID:   2,
+			sanctionErr: errDB,
+			expected:    "could not create sanction on DB: db error",
+			expectedErr: errDB,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			roomRepo := roomMock.NewRepository(t)
+			moderationRepo := moderationMock.NewRepository(t)
+
+			roomSvc := room.NewService(roomRepo, nil)
+			svc := report.NewService(repository, roomSvc, moderation.NewService(moderationRepo, roomSvc, nil))
+
+			repository.
+				On("FindReport", 5).
+				Return(&entity.MessageReport{ID: 5, RoomID: 1, Status: entity.ReportOpen, MessageID: 7, ReportedUserID: 3}, nil).
+				Once()
+
+			roomRepo.
+				On("FindRoom", 1).
+				Return(&entity.Room{ID: 1, CreatorID: tc.creatorID}, nil)
+
+			roomRepo.
+				On("FindMember", 1, mock.AnythingOfType("int")).
+				Return(&entity.RoomMember{RoomID: 1, UserID: 2, Role: entity.RoleModerator}, nil).
+				Maybe()
+
+			moderationRepo.
+				On("CreateSanction", mock.AnythingOfType("*entity.RoomSanction")).
+				Return(0, tc.sanctionErr).
+				Maybe()
+
+			// the report isn't resolved, the ban can be retried
+			err := svc.ResolveReport(2, 5, report.ResolveInput{Action: entity.ReportActionBanUser})
+			assert.EqualError(t, err, tc.expected)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			repository.AssertNotCalled(t, "ResolveReport", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestService_ResolveReportErrors(t *testing.T) {
+	var tt = []struct {
+		name        string
+		actorID     int
+		status      string
+		action      string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:        "When user isn't allowed to review reports; should return not found",
+			actorID:     4,
+			status:      entity.ReportOpen,
+			action:      entity.ReportActionDismiss,
+			expected:    "could not retrieve report: report not found",
+			expectedErr: entity.ErrReportNotFound,
+		},
+		{
+			name:        "When report was already reviewed; should return error",
+			actorID:     2,
+			status:      entity.ReportDismissed,
+			action:      entity.ReportActionDismiss,
+			expected:    "could not resolve report: report is not open",
+			expectedErr: entity.ErrReportNotOpen,
+		},
+		{
+			name:        "When action is unknown; should return invalid entity",
+			actorID:     2,
+			status:      entity.ReportOpen,
+			action:      "warn",
+			expected:    "could not resolve report: invalid entity",
+			expectedErr: entity.ErrInvalidEntity,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			roomRepo := roomMock.NewRepository(t)
+			svc := report.NewService(repository, room.NewService(roomRepo, nil), nil)
+
+			repository.
+				On("FindReport", 5).
+				Return(&entity.MessageReport{ID: 5, RoomID: 1, Status: tc.status, MessageID: 7, ReportedUserID: 3}, nil).
+				Once()
+
+			roomRepo.
+				On("FindRoom", 1).
+				Return(&entity.Room{ID: 1, CreatorID: 2}, nil).
+				Once()
+
+			roomRepo.
+				On("FindMember", 1, 4).
+				Return(nil, entity.ErrMemberNotFound).
+				Maybe()
+
+			err := svc.ResolveReport(tc.actorID, 5, report.ResolveInput{Action: tc.action})
+			assert.EqualError(t, err, tc.expected)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestService_GetReport(t *testing.T) {
+	var (
+		r        = &entity.MessageReport{ID: 5, RoomID: 1, Status: entity.ReportOpen, MessageID: 7}
+		messages = []*entity.Message{{ID: 6}, {ID: 7}, {ID: 8}}
+		audit    = []*entity.ReportAuditEntry{{ReportID: 5, ActorID: 4, Action: entity.ReportActionReport}}
+	)
+
+	repository := mocks.NewRepository(t)
+	roomRepo := roomMock.NewRepository(t)
+	svc := report.NewService(repository, room.NewService(roomRepo, nil), nil)
+
+	repository.
+		On("FindReport", 5).
+		Return(r, nil).
+		Once()
+
+	roomRepo.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, CreatorID: 2}, nil).
+		Once()
+
+	roomRepo.
+		On("FindMember", 1, 3).
+		Return(&entity.RoomMember{RoomID: 1, UserID: 3, Role: entity.RoleModerator}, nil).
+		Once()
+
+	repository.
+		On("ListMessageContext", 1, 7, 5).
+		Return(messages, nil).
+		Once()
+
+	repository.
+		On("ListAuditEntries", 5).
+		Return(audit, nil).
+		Once()
+
+	detail, err := svc.GetReport(3, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, &report.Detail{Report: r, Context: messages, Audit: audit}, detail)
+}
@@ -0,0 +1,16 @@
+package room
+
+const (
+	// EventRoomCreated a new room was created.
+	EventRoomCreated = "roomCreated"
+	// EventRoomUpdated a room was updated.
+	EventRoomUpdated = "roomUpdated"
+	// EventRoomDeleted a room was deleted.
+	EventRoomDeleted = "roomDeleted"
+)
+
+// Event represents a change in a room published to every chat-api instance.
+type Event struct {
+	Type   string `json:"type"`
+	RoomID int    `json:"roomID"`
+}
@@ -1,17 +1,36 @@
 package room
 
-import "github.com/vsantosalmeida/browser-chat/entity"
+import (
+	"context"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
 
 // Reader handle the required methods to read rooms DB.
 type Reader interface {
+	FindRoom(id int) (*entity.Room, error)
+	FindRoomByName(name string) (*entity.Room, error)
 	ListRooms() ([]*entity.Room, error)
-	ListMessages(roomID int) ([]*entity.Message, error)
+	ListVisibleRooms(userID int) ([]*entity.Room, error)
+	ListMessages(roomID, userID int) ([]*entity.Message, error)
+	FindMember(roomID, userID int) (*entity.RoomMember, error)
+	ListMembers(roomID int) ([]*entity.RoomMember, error)
+	FindInvitation(id int) (*entity.RoomInvitation, error)
+	FindPendingInvitation(roomID, inviteeID int) (*entity.RoomInvitation, error)
+	ListPendingInvitations(inviteeID int) ([]*entity.RoomInvitation, error)
 }
 
 // Writer handle the required methods to write rooms DB.
 type Writer interface {
 	CreateRoom(e *entity.Room) (int, error)
+	UpdateRoom(e *entity.Room) error
+	DeleteRoom(id int) error
 	CreateMessage(e *entity.Message) error
+	CreateMessages(e []*entity.Message) error
+	SaveMember(e *entity.RoomMember) error
+	CreateInvitation(e *entity.RoomInvitation) (int, error)
+	UpdateInvitation(e *entity.RoomInvitation) error
+	AcceptInvitation(e *entity.RoomInvitation) error
 }
 
 // Repository interface to bind Reader and Writer methods.
@@ -20,10 +39,29 @@ type Repository interface {
 	Writer
 }
 
+// Publisher interface to notify the room events to every chat-api instance.
+type Publisher interface {
+	WriteMessage(ctx context.Context, payload []byte) error
+}
+
 // UseCase service to handle the business rules for room context.
 type UseCase interface {
+	FindRoom(id int) (*entity.Room, error)
 	ListRooms() ([]*entity.Room, error)
-	ListMessages(roomID int) ([]*entity.Message, error)
-	CreateRoom() (int, error)
-	CreateMessage(userID, roomID int, content string) error
+	ListVisibleRooms(userID int) ([]*entity.Room, error)
+	ListMessages(userID, roomID int) ([]*entity.Message, error)
+	CheckAccess(userID int, room *entity.Room) error
+	Role(userID int, room *entity.Room) (string, error)
+	Authorize(userID int, room *entity.Room, p entity.Permission) error
+	ListMembers(roomID int) ([]*entity.RoomMember, error)
+	SetMemberRole(userID, roomID, memberID int, role string) error
+	CreateRoom(creatorID int, input CreateInput) (int, error)
+	UpdateRoom(userID, id int, input UpdateInput) (*entity.Room, error)
+	ArchiveRoom(userID, id int) (*entity.Room, error)
+	DeleteRoom(userID, id int) error
+	InviteUser(inviterID, roomID, inviteeID int) (int, error)
+	ListInvitations(userID int) ([]*entity.RoomInvitation, error)
+	AcceptInvitation(userID, invitationID int) error
+	DeclineInvitation(userID, invitationID int) error
+	CreateMessage(msg *entity.Message) error
 }
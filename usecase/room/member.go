@@ -0,0 +1,244 @@
+package room
+
+import (
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// CheckAccess checks if the user is allowed to join and read the room.
+// a public room is open to any user, a private room only to its members.
+func (s *Service) CheckAccess(userID int, room *entity.Room) error {
+	if err := s.permissions.Authorize(userID, room, entity.PermissionReadRoom); err != nil {
+		return errors.Wrap(err, "could not access room")
+	}
+
+	return nil
+}
+
+// Role retrieve the user role in the room.
+func (s *Service) Role(userID int, room *entity.Room) (string, error) {
+	return s.permissions.Role(userID, room)
+}
+
+// Authorize checks if the user role in the room grants the permission.
+func (s *Service) Authorize(userID int, room *entity.Room, p entity.Permission) error {
+	return s.permissions.Authorize(userID, room, p)
+}
+
+// ListMembers retrieve the room members from DB.
+func (s *Service) ListMembers(roomID int) ([]*entity.RoomMember, error) {
+	members, err := s.repo.ListMembers(roomID)
+	if err != nil {
+		log.WithError(err).WithField("RoomID", roomID).Error("could not retrieve members list")
+		return nil, errors.Wrap(err, "could not retrieve members list")
+	}
+
+	return members, nil
+}
+
+// InviteUser invite a user to join a private room.
+// only a room member is allowed to invite, and the invitee must not be a member or already invited.
+func (s *Service) InviteUser(inviterID, roomID, inviteeID int) (int, error) {
+	logger := log.WithFields(log.Fields{
+		"RoomID":    roomID,
+		"InviterID": inviterID,
+		"InviteeID": inviteeID,
+	})
+
+	invitation, err := entity.NewRoomInvitation(roomID, inviterID, inviteeID)
+	if err != nil {
+		logger.WithError(err).Error("could not create an invitation object")
+		return 0, errors.Wrap(err, "could not create an invitation object")
+	}
+
+	room, err := s.FindRoom(roomID)
+	if err != nil {
+		return 0, err
+	}
+
+	// a public room is open to any user, no invitation required
+	if !room.Private {
+		return 0, errors.Wrap(entity.ErrInvalidEntity, "could not invite to a public room")
+	}
+
+	if err = s.permissions.Authorize(inviterID, room, entity.PermissionInviteMember); err != nil {
+		return 0, errors.Wrap(err, "could not invite user")
+	}
+
+	isMember, err := s.isMember(roomID, inviteeID)
+	if err != nil {
+		return 0, err
+	}
+	if isMember {
+		return 0, errors.Wrap(entity.ErrAlreadyMember, "could not invite user")
+	}
+
+	pending, err := s.repo.FindPendingInvitation(roomID, inviteeID)
+	if err != nil && !errors.Is(err, entity.ErrInvitationNotFound) {
+		logger.WithError(err).Error("could not retrieve pending invitation")
+		return 0, errors.Wrap(err, "could not retrieve pending invitation")
+	}
+	if pending != nil {
+		return pending.ID, nil
+	}
+
+	id, err := s.repo.CreateInvitation(invitation)
+	if err != nil {
+		logger.WithError(err).Error("could not create invitation on DB")
+		return 0, errors.Wrap(err, "could not create invitation on DB")
+	}
+
+	logger.WithField("id", id).Info("invitation created")
+
+	return id, nil
+}
+
+// SetMemberRole change the role of a room member, only the room owner is allowed to change it.
+//
+// the owner role can't be given, a user without membership becomes a member of a public room
+// and must be invited to a private room.
+func (s *Service) SetMemberRole(userID, roomID, memberID int, role string) error {
+	if !entity.IsValidRole(role) || role == entity.RoleOwner {
+		return errors.Wrap(entity.ErrInvalidEntity, "could not use role")
+	}
+
+	room, err := s.findRoomForChange(userID, roomID, entity.PermissionManageRoles)
+	if err != nil {
+		return err
+	}
+
+	if memberID == room.CreatorID {
+		return errors.Wrap(entity.ErrInvalidEntity, "could not change the room owner role")
+	}
+
+	member, err := s.repo.FindMember(roomID, memberID)
+	switch {
+	case err == nil:
+	case errors.Is(err, entity.ErrMemberNotFound) && !room.Private:
+		member = &entity.RoomMember{
+			RoomID: roomID,
+			UserID: memberID,
+		}
+	case errors.Is(err, entity.ErrMemberNotFound):
+		return errors.Wrap(err, "could not change member role")
+	default:
+		log.WithError(err).WithField("RoomID", roomID).Error("could not retrieve room member")
+		return errors.Wrap(err, "could not retrieve room member")
+	}
+
+	member.Role = role
+
+	if err = s.repo.SaveMember(member); err != nil {
+		log.WithError(err).WithField("RoomID", roomID).Error("could not save room member on DB")
+		return errors.Wrap(err, "could not save room member on DB")
+	}
+
+	log.WithFields(log.Fields{
+		"RoomID": roomID,
+		"UserID": memberID,
+		"Role":   role,
+	}).Info("member role changed")
+
+	return nil
+}
+
+// ListInvitations retrieve the pending invitations of the user.
+func (s *Service) ListInvitations(userID int) ([]*entity.RoomInvitation, error) {
+	invitations, err := s.repo.ListPendingInvitations(userID)
+	if err != nil {
+		log.WithError(err).WithField("UserID", userID).Error("could not retrieve invitations list")
+		return nil, errors.Wrap(err, "could not retrieve invitations list")
+	}
+
+	return invitations, nil
+}
+
+// AcceptInvitation accept a pending invitation, the user becomes a room member.
+func (s *Service) AcceptInvitation(userID, invitationID int) error {
+	invitation, err := s.findPendingInvitation(userID, invitationID)
+	if err != nil {
+		return err
+	}
+
+	invitation.Status = entity.InvitationAccepted
+
+	if err = s.repo.AcceptInvitation(invitation); err != nil {
+		log.WithError(err).WithField("InvitationID", invitationID).Error("could not accept invitation on DB")
+		return errors.Wrap(err, "could not accept invitation on DB")
+	}
+
+	log.WithFields(log.Fields{
+		"RoomID": invitation.RoomID,
+		"UserID": userID,
+	}).Info("invitation accepted")
+
+	// the new member sees the private room in the room list
+	s.publish(Event{
+		Type:   EventRoomUpdated,
+		RoomID: invitation.RoomID,
+	})
+
+	return nil
+}
+
+// DeclineInvitation decline a pending invitation.
+func (s *Service) DeclineInvitation(userID, invitationID int) error {
+	invitation, err := s.findPendingInvitation(userID, invitationID)
+	if err != nil {
+		return err
+	}
+
+	invitation.Status = entity.InvitationDeclined
+
+	if err = s.repo.UpdateInvitation(invitation); err != nil {
+		log.WithError(err).WithField("InvitationID", invitationID).Error("could not decline invitation on DB")
+		return errors.Wrap(err, "could not decline invitation on DB")
+	}
+
+	log.WithFields(log.Fields{
+		"RoomID": invitation.RoomID,
+		"UserID": userID,
+	}).Info("invitation declined")
+
+	return nil
+}
+
+// findPendingInvitation retrieve a pending invitation sent to the user.
+// an invitation sent to another user is reported as not found.
+func (s *Service) findPendingInvitation(userID, invitationID int) (*entity.RoomInvitation, error) {
+	invitation, err := s.repo.FindInvitation(invitationID)
+	if err != nil {
+		if !errors.Is(err, entity.ErrInvitationNotFound) {
+			log.WithError(err).WithField("InvitationID", invitationID).Error("could not retrieve invitation")
+		}
+		return nil, errors.Wrap(err, "could not retrieve invitation")
+	}
+
+	if invitation.InviteeID != userID {
+		return nil, errors.Wrap(entity.ErrInvitationNotFound, "could not retrieve invitation")
+	}
+
+	if !invitation.IsPending() {
+		return nil, errors.Wrap(entity.ErrInvitationNotPending, "could not answer invitation")
+	}
+
+	return invitation, nil
+}
+
+func (s *Service) isMember(roomID, userID int) (bool, error) {
+	if _, err := s.repo.FindMember(roomID, userID); err != nil {
+		if errors.Is(err, entity.ErrMemberNotFound) {
+			return false, nil
+		}
+
+		log.WithError(err).WithFields(log.Fields{
+			"RoomID": roomID,
+			"UserID": userID,
+		}).Error("could not retrieve room member")
+		return false, errors.Wrap(err, "could not retrieve room member")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,503 @@
+package room_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/room"
+	"github.com/vsantosalmeida/browser-chat/usecase/room/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_CheckAccess(t *testing.T) {
+	var tt = []struct {
+		name        string
+		room        *entity.Room
+		mockMember  *entity.RoomMember
+		mockErr     error
+		expectedErr string
+	}{
+		{
+			name: "When the room is public; should allow any user",
+			room: &entity.Room{ID: 1},
+		},
+		{
+			name:       "When the user is a member of the private room; should allow the user",
+			room:       &entity.Room{ID: 1, Private: true},
+			mockMember: &entity.RoomMember{RoomID: 1, UserID: 2},
+		},
+		{
+			name:        "When the user isn't a member of the private room; should return forbidden",
+			room:        &entity.Room{ID: 1, Private: true},
+			mockErr:     entity.ErrMemberNotFound,
+			expectedErr: "could not access room: forbidden",
+		},
+		{
+			name:        "When the member can't be retrieved; should return an error",
+			room:        &entity.Room{ID: 1, Private: true},
+			mockErr:     errDB,
+			expectedErr: "could not access room: could not retrieve room member: db error",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			svc := room.NewService(repository, nil)
+
+			if tc.room.Private {
+				repository.
+					On("FindMember", 1, 2).
+					Return(tc.mockMember, tc.mockErr).
+					Once()
+			}
+
+			err := svc.CheckAccess(2, tc.room)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestService_InviteUser(t *testing.T) {
+	var (
+		expected = 7
+
+		invitation = &entity.RoomInvitation{
+			RoomID:    1,
+			InviterID: 2,
+			InviteeID: 3,
+			Status:    entity.InvitationPending,
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	svc := room.NewService(repository, nil)
+
+	repository.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, Private: true}, nil).
+		Once()
+
+	repository.
+		On("FindMember", 1, 2).
+		Return(&entity.RoomMember{RoomID: 1, UserID: 2}, nil).
+		Once()
+
+	repository.
+		On("FindMember", 1, 3).
+		Return(nil, entity.ErrMemberNotFound).
+		Once()
+
+	repository.
+		On("FindPendingInvitation", 1, 3).
+		Return(nil, entity.ErrInvitationNotFound).
+		Once()
+
+	repository.
+		On("CreateInvitation", invitation).
+		Return(7, nil).
+		Once()
+
+	id, err := svc.InviteUser(2, 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, id)
+}
+
+func TestService_InviteUserPending(t *testing.T) {
+	var expected = 5
+
+	repository := mocks.NewRepository(t)
+	svc := room.NewService(repository, nil)
+
+	repository.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, Private: true}, nil).
+		Once()
+
+	repository.
+		On("FindMember", 1, 2).
+		Return(&entity.RoomMember{RoomID: 1, UserID: 2}, nil).
+		Once()
+
+	repository.
+		On("FindMember", 1, 3).
+		Return(nil, entity.ErrMemberNotFound).
+		Once()
+
+	// the user is already invited, the pending invitation is reused
+	repository.
+		On("FindPendingInvitation", 1, 3).
+		Return(&entity.RoomInvitation{ID: 5, RoomID: 1, InviteeID: 3, Status: entity.InvitationPending}, nil).
+		Once()
+
+	id, err := svc.InviteUser(2, 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, id)
+}
+
+func TestService_InviteUserErrors(t *testing.T) {
+	var tt = []struct {
+		name          string
+		inviteeID     int
+		mockRoom      *entity.Room
+		mockRoomErr   error
+		inviterMember bool
+		inviteeMember bool
+		expectedErr   error
+	}{
+		{
+			name:        "When the user invites itself; should return invalid entity",
+			inviteeID:   2,
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:        "When the room doesn't exist; should return room not found",
+			inviteeID:   3,
+			mockRoomErr: entity.ErrRoomNotFound,
+			expectedErr: entity.ErrRoomNotFound,
+		},
+		{
+			name:        "When the room is public; should return invalid entity",
+			inviteeID:   3,
+			mockRoom:    &entity.Room{ID: 1},
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:        "When the inviter isn't a member; should return forbidden",
+			inviteeID:   3,
+			mockRoom:    &entity.Room{ID: 1, Private: true},
+			expectedErr: entity.ErrForbidden,
+		},
+		{
+			name:          "When the invitee is already a member; should return already member",
+			inviteeID:     3,
+			mockRoom:      &entity.Room{ID: 1, Private: true},
+			inviterMember: true,
+			inviteeMember: true,
+			expectedErr:   entity.ErrAlreadyMember,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			svc := room.NewService(repository, nil)
+
+			if tc.mockRoom != nil || tc.mockRoomErr != nil {
+				repository.
+					On("FindRoom", 1).
+					Return(tc.mockRoom, tc.mockRoomErr).
+					Once()
+			}
+
+			if tc.mockRoom != nil && tc.mockRoom.Private {
+				mockMember(repository, 2, tc.inviterMember)
+			}
+
+			if tc.inviterMember {
+				mockMember(repository, tc.inviteeID, tc.inviteeMember)
+			}
+
+			id, err := svc.InviteUser(2, 1, tc.inviteeID)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Zero(t, id)
+		})
+	}
+}
+
+func TestService_ListInvitations(t *testing.T) {
+	var expected = []*entity.RoomInvitation{
+		{
+			ID:        5,
+			RoomID:    1,
+			InviteeID: 3,
+			Status:    entity.InvitationPending,
+		},
+	}
+
+	repository := mocks.NewRepository(t)
+	svc := room.NewService(repository, nil)
+
+	repository.
+		On("ListPendingInvitations", 3).
+		Return([]*entity.RoomInvitation{
+			{
+				ID:        5,
+				RoomID:    1,
+				InviteeID: 3,
+				Status:    entity.InvitationPending,
+			},
+		}, nil).
+		Once()
+
+	invitations, err := svc.ListInvitations(3)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, invitations)
+}
+
+func TestService_AcceptInvitation(t *testing.T) {
+	var accepted = &entity.RoomInvitation{
+		ID:        5,
+		RoomID:    1,
+		InviteeID: 3,
+		Status:    entity.InvitationAccepted,
+	}
+
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
+
+	repository.
+		On("FindInvitation", 5).
+		Return(&entity.RoomInvitation{
+			ID:        5,
+			RoomID:    1,
+			InviteeID: 3,
+			Status:    entity.InvitationPending,
+		}, nil).
+		Once()
+
+	repository.
+		On("AcceptInvitation", accepted).
+		Return(nil).
+		Once()
+
+	publisher.
+		On("WriteMessage", context.Background(), []byte(`{"type":"roomUpdated","roomID":1}`)).
+		Return(nil).
+		Once()
+
+	err := svc.AcceptInvitation(3, 5)
+	assert.NoError(t, err)
+}
+
+func TestService_DeclineInvitation(t *testing.T) {
+	var declined = &entity.RoomInvitation{
+		ID:        5,
+		RoomID:    1,
+		InviteeID: 3,
+		Status:    entity.InvitationDeclined,
+	}
+
+	repository := mocks.NewRepository(t)
+	svc := room.NewService(repository, nil)
+
+	repository.
+		On("FindInvitation", 5).
+		Return(&entity.RoomInvitation{
+			ID:        5,
+			RoomID:    1,
+			InviteeID: 3,
+			Status:    entity.InvitationPending,
+		}, nil).
+		Once()
+
+	repository.
+		On("UpdateInvitation", declined).
+		Return(nil).
+		Once()
+
+	err := svc.DeclineInvitation(3, 5)
+	assert.NoError(t, err)
+}
+
+func TestService_AnswerInvitationErrors(t *testing.T) {
+	var tt = []struct {
+		name           string
+		mockInvitation *entity.RoomInvitation
+		mockErr        error
+		expectedErr    error
+	}{
+		{
+			name:        "When the invitation doesn't exist; should return invitation not found",
+			mockErr:     entity.ErrInvitationNotFound,
+			expectedErr: entity.ErrInvitationNotFound,
+		},
+		{
+			name: "When the invitation was sent to another user; should return invitation not found",
+			mockInvitation: &entity.RoomInvitation{
+				ID:        5,
+				RoomID:    1,
+				InviteeID: 4,
+				Status:    entity.InvitationPending,
+			},
+			expectedErr: entity.ErrInvitationNotFound,
+		},
+		{
+			name: "When the invitation was already answered; should return invitation not pending",
+			mockInvitation: &entity.RoomInvitation{
+				ID:        5,
+				RoomID:    1,
+				InviteeID: 3,
+				Status:    entity.InvitationDeclined,
+			},
+			expectedErr: entity.ErrInvitationNotPending,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			svc := room.NewService(repository, nil)
+
+			repository.
+				On("FindInvitation", 5).
+				Return(tc.mockInvitation, tc.mockErr).
+				Twice()
+
+			err := svc.AcceptInvitation(3, 5)
+			assert.ErrorIs(t, err, tc.expectedErr)
+
+			err = svc.DeclineInvitation(3, 5)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func mockMember(repository *mocks.Repository, userID int, isMember bool) {
+	if isMember {
+		repository.
+			On("FindMember", 1, userID).
+			Return(&entity.RoomMember{RoomID: 1, UserID: userID}, nil).
+			Once()
+		return
+	}
+
+	repository.
+		On("FindMember", 1, userID).
+		Return(nil, entity.ErrMemberNotFound).
+		Once()
+}
+
+func TestService_SetMemberRole(t *testing.T) {
+	var tt = []struct {
+		name       string
+		room       *entity.Room
+		mockMember *entity.RoomMember
+		mockErr    error
+		expected   *entity.RoomMember
+	}{
+		{
+			name:       "When the user is a member; should change the member role",
+			room:       &entity.Room{ID: 1, CreatorID: 2, Private: true},
+			mockMember: &entity.RoomMember{RoomID: 1, UserID: 3, Role: entity.RoleMember},
+			expected:   &entity.RoomMember{RoomID: 1, UserID: 3, Role: entity.RoleModerator},
+		},
+		{
+			name:     "When the user isn't a member of a public room; should add the member with the role",
+			room:     &entity.Room{ID: 1, CreatorID: 2},
+			mockErr:  entity.ErrMemberNotFound,
+			expected: &entity.RoomMember{RoomID: 1, UserID: 3, Role: entity.RoleModerator},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			svc := room.NewService(repository, nil)
+
+			repository.
+				On("FindRoom", 1).
+				Return(tc.room, nil).
+				Once()
+
+			repository.
+				On("FindMember", 1, 3).
+				Return(tc.mockMember, tc.mockErr).
+				Once()
+
+			repository.
+				On("SaveMember", tc.expected).
+				Return(nil).
+				Once()
+
+			err := svc.SetMemberRole(2, 1, 3, entity.RoleModerator)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_SetMemberRoleErrors(t *testing.T) {
+	var tt = []struct {
+		name        string
+		userID      int
+		memberID    int
+		role        string
+		room        *entity.Room
+		mockErr     error
+		expectedErr error
+	}{
+		{
+			name:        "When the role doesn't exist; should return invalid entity",
+			userID:      2,
+			memberID:    3,
+			role:        "admin",
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:        "When the owner role is given; should return invalid entity",
+			userID:      2,
+			memberID:    3,
+			role:        entity.RoleOwner,
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:        "When the user isn't the room owner; should return forbidden",
+			userID:      4,
+			memberID:    3,
+			role:        entity.RoleModerator,
+			room:        &entity.Room{ID: 1, CreatorID: 2},
+			mockErr:     entity.ErrMemberNotFound,
+			expectedErr: entity.ErrForbidden,
+		},
+		{
+			name:        "When the owner role is changed; should return invalid entity",
+			userID:      2,
+			memberID:    2,
+			role:        entity.RoleMember,
+			room:        &entity.Room{ID: 1, CreatorID: 2},
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:        "When the user isn't a member of a private room; should return member not found",
+			userID:      2,
+			memberID:    3,
+			role:        entity.RoleModerator,
+			room:        &entity.Room{ID: 1, CreatorID: 2, Private: true},
+			mockErr:     entity.ErrMemberNotFound,
+			expectedErr: entity.ErrMemberNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			svc := room.NewService(repository, nil)
+
+			if tc.room != nil {
+				repository.
+					On("FindRoom", 1).
+					Return(tc.room, nil).
+					Once()
+			}
+
+			if tc.mockErr != nil {
+				// the member lookup of the user changing the role or of the changed member
+				repository.
+					On("FindMember", 1, mock.AnythingOfType("int")).
+					Return(nil, tc.mockErr).
+					Once()
+			}
+
+			err := svc.SetMemberRole(tc.userID, 1, tc.memberID, tc.role)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -12,6 +12,41 @@ type Repository struct {
 	mock.Mock
 }
 
+// AcceptInvitation provides a mock function with given fields: e
+func (_m *Repository) AcceptInvitation(e *entity.RoomInvitation) error {
+	ret := _m.Called(e)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*entity.RoomInvitation) error); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateInvitation provides a mock function with given fields: e
+func (_m *Repository) CreateInvitation(e *entity.RoomInvitation) (int, error) {
+	ret := _m.Called(e)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(*entity.RoomInvitation) int); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*entity.RoomInvitation) error); ok {
+		r1 = rf(e)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateMessage provides a mock function with given fields: e
 func (_m *Repository) CreateMessage(e *entity.Message) error {
 	ret := _m.Called(e)
@@ -26,6 +61,20 @@ func (_m *Repository) CreateMessage(e *entity.Message) error {
 	return r0
 }
 
+// CreateMessages provides a mock function with given fields: e
+func (_m *Repository) CreateMessages(e []*entity.Message) error {
+	ret := _m.Called(e)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]*entity.Message) error); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateRoom provides a mock function with given fields: e
 func (_m *Repository) CreateRoom(e *entity.Room) (int, error) {
 	ret := _m.Called(e)
@@ -47,16 +96,145 @@ func (_m *Repository) CreateRoom(e *entity.Room) (int, error) {
 	return r0, r1
 }
 
-// ListMessages provides a mock function with given fields: roomID
-func (_m *Repository) ListMessages(roomID int) ([]*entity.Message, error) {
+// DeleteRoom provides a mock function with given fields: id
+func (_m *Repository) DeleteRoom(id int) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindInvitation provides a mock function with given fields: id
+func (_m *Repository) FindInvitation(id int) (*entity.RoomInvitation, error) {
+	ret := _m.Called(id)
+
+	var r0 *entity.RoomInvitation
+	if rf, ok := ret.Get(0).(func(int) *entity.RoomInvitation); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.RoomInvitation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindMember provides a mock function with given fields: roomID, userID
+func (_m *Repository) FindMember(roomID int, userID int) (*entity.RoomMember, error) {
+	ret := _m.Called(roomID, userID)
+
+	var r0 *entity.RoomMember
+	if rf, ok := ret.Get(0).(func(int, int) *entity.RoomMember); ok {
+		r0 = rf(roomID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.RoomMember)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, int) error); ok {
+		r1 = rf(roomID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindPendingInvitation provides a mock function with given fields: roomID, inviteeID
+func (_m *Repository) FindPendingInvitation(roomID int, inviteeID int) (*entity.RoomInvitation, error) {
+	ret := _m.Called(roomID, inviteeID)
+
+	var r0 *entity.RoomInvitation
+	if rf, ok := ret.Get(0).(func(int, int) *entity.RoomInvitation); ok {
+		r0 = rf(roomID, inviteeID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.RoomInvitation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, int) error); ok {
+		r1 = rf(roomID, inviteeID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindRoom provides a mock function with given fields: id
+func (_m *Repository) FindRoom(id int) (*entity.Room, error) {
+	ret := _m.Called(id)
+
+	var r0 *entity.Room
+	if rf, ok := ret.Get(0).(func(int) *entity.Room); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Room)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindRoomByName provides a mock function with given fields: name
+func (_m *Repository) FindRoomByName(name string) (*entity.Room, error) {
+	ret := _m.Called(name)
+
+	var r0 *entity.Room
+	if rf, ok := ret.Get(0).(func(string) *entity.Room); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Room)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListMembers provides a mock function with given fields: roomID
+func (_m *Repository) ListMembers(roomID int) ([]*entity.RoomMember, error) {
 	ret := _m.Called(roomID)
 
-	var r0 []*entity.Message
-	if rf, ok := ret.Get(0).(func(int) []*entity.Message); ok {
+	var r0 []*entity.RoomMember
+	if rf, ok := ret.Get(0).(func(int) []*entity.RoomMember); ok {
 		r0 = rf(roomID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*entity.Message)
+			r0 = ret.Get(0).([]*entity.RoomMember)
 		}
 	}
 
@@ -70,6 +248,52 @@ func (_m *Repository) ListMessages(roomID int) ([]*entity.Message, error) {
 	return r0, r1
 }
 
+// ListMessages provides a mock function with given fields: roomID, userID
+func (_m *Repository) ListMessages(roomID int, userID int) ([]*entity.Message, error) {
+	ret := _m.Called(roomID, userID)
+
+	var r0 []*entity.Message
+	if rf, ok := ret.Get(0).(func(int, int) []*entity.Message); ok {
+		r0 = rf(roomID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, int) error); ok {
+		r1 = rf(roomID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPendingInvitations provides a mock function with given fields: inviteeID
+func (_m *Repository) ListPendingInvitations(inviteeID int) ([]*entity.RoomInvitation, error) {
+	ret := _m.Called(inviteeID)
+
+	var r0 []*entity.RoomInvitation
+	if rf, ok := ret.Get(0).(func(int) []*entity.RoomInvitation); ok {
+		r0 = rf(inviteeID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.RoomInvitation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(inviteeID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListRooms provides a mock function with given fields:
 func (_m *Repository) ListRooms() ([]*entity.Room, error) {
 	ret := _m.Called()
@@ -93,6 +317,71 @@ func (_m *Repository) ListRooms() ([]*entity.Room, error) {
 	return r0, r1
 }
 
+// ListVisibleRooms provides a mock function with given fields: userID
+func (_m *Repository) ListVisibleRooms(userID int) ([]*entity.Room, error) {
+	ret := _m.Called(userID)
+
+	var r0 []*entity.Room
+	if rf, ok := ret.Get(0).(func(int) []*entity.Room); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.Room)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveMember provides a mock function with given fields: e
+func (_m *Repository) SaveMember(e *entity.RoomMember) error {
+	ret := _m.Called(e)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*entity.RoomMember) error); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateInvitation provides a mock function with given fields: e
+func (_m *Repository) UpdateInvitation(e *entity.RoomInvitation) error {
+	ret := _m.Called(e)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*entity.RoomInvitation) error); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRoom provides a mock function with given fields: e
+func (_m *Repository) UpdateRoom(e *entity.Room) error {
+	ret := _m.Called(e)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*entity.Room) error); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewRepository interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,242 @@
+package room
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrQueueFull the persistence queue didn't have room for the message in time
+	ErrQueueFull = errors.New("message queue is full")
+	// ErrPersisterClosed the Persister doesn't accept messages anymore
+	ErrPersisterClosed = errors.New("message persister is closed")
+)
+
+// PersisterConfig configures the Persister pipeline.
+type PersisterConfig struct {
+	// QueueSize max messages waiting to be persisted.
+	QueueSize int
+	// BatchSize max messages inserted at once.
+	BatchSize int
+	// FlushInterval max time a message waits for the batch to be full.
+	FlushInterval time.Duration
+	// MaxRetries max attempts to insert a batch after the first transient failure, e.g. a lost DB connection.
+	MaxRetries int
+	// RetryBackoff wait time before the first retry, doubled on every attempt.
+	RetryBackoff time.Duration
+	// EnqueueTimeout max time Enqueue blocks while the queue is full.
+	EnqueueTimeout time.Duration
+}
+
+// DefaultPersisterConfig default Persister settings.
+var DefaultPersisterConfig = PersisterConfig{
+	QueueSize:      1000,
+	BatchSize:      100,
+	FlushInterval:  500 * time.Millisecond,
+	MaxRetries:     3,
+	RetryBackoff:   100 * time.Millisecond,
+	EnqueueTimeout: 2 * time.Second,
+}
+
+// PersisterStats Persister metrics.
+type PersisterStats struct {
+	Queued    int   `json:"queued"`
+	Persisted int64 `json:"persisted"`
+	Failed    int64 `json:"failed"`
+	Retries   int64 `json:"retries"`
+	Rejected  int64 `json:"rejected"`
+}
+
+// Persister write-behind pipeline that persists messages in batches.
+//
+// a full queue blocks Enqueue until EnqueueTimeout, applying backpressure to the senders.
+type Persister struct {
+	repo   Writer
+	cfg    PersisterConfig
+	queue  chan *entity.Message
+	done   chan struct{}
+	mu     sync.RWMutex
+	closed bool
+
+	persisted int64
+	failed    int64
+	retries   int64
+	rejected  int64
+}
+
+// NewPersister Persister builder.
+func NewPersister(repo Writer, cfg PersisterConfig) *Persister {
+	// avoids a zero or negative batch and queue sizes
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultPersisterConfig.BatchSize
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultPersisterConfig.QueueSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultPersisterConfig.FlushInterval
+	}
+
+	return &Persister{
+		repo:  repo,
+		cfg:   cfg,
+		queue: make(chan *entity.Message, cfg.QueueSize),
+		done:  make(chan struct{}),
+	}
+}
+
+// Start starts the loop to persist the queued messages.
+func (p *Persister) Start() {
+	go p.run()
+}
+
+// Enqueue adds a message to the persistence queue.
+// returns ErrQueueFull if the queue stays full for EnqueueTimeout.
+func (p *Persister) Enqueue(msg *entity.Message) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPersisterClosed
+	}
+
+	timer := time.NewTimer(p.cfg.EnqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case p.queue <- msg:
+		return nil
+
+	case <-timer.C:
+		atomic.AddInt64(&p.rejected, 1)
+		log.WithFields(log.Fields{
+			"RoomID": msg.RoomID,
+			"UserID": msg.UserID,
+		}).Error("message rejected, persistence queue is full")
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting messages and waits until the queued messages are flushed.
+// returns the context error if the queue isn't drained before the context is done.
+func (p *Persister) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		log.Info("message persister stopped")
+		return nil
+
+	case <-ctx.Done():
+		log.WithField("Queued", len(p.queue)).Error("message persister not drained")
+		return ctx.Err()
+	}
+}
+
+// Stats returns the Persister metrics.
+func (p *Persister) Stats() PersisterStats {
+	return PersisterStats{
+		Queued:    len(p.queue),
+		Persisted: atomic.LoadInt64(&p.persisted),
+		Failed:    atomic.LoadInt64(&p.failed),
+		Retries:   atomic.LoadInt64(&p.retries),
+		Rejected:  atomic.LoadInt64(&p.rejected),
+	}
+}
+
+// run loop through the queue grouping the messages in batches.
+// a batch is flushed when it's full, on every FlushInterval or when the queue is closed.
+func (p *Persister) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*entity.Message, 0, p.cfg.BatchSize)
+
+	for {
+		select {
+		case msg, ok := <-p.queue:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+
+			batch = append(batch, msg)
+			if len(batch) >= p.cfg.BatchSize {
+				p.flush(batch)
+				batch = make([]*entity.Message, 0, p.cfg.BatchSize)
+			}
+
+		case <-ticker.C:
+			if len(batch) > 0 {
+				p.flush(batch)
+				batch = make([]*entity.Message, 0, p.cfg.BatchSize)
+			}
+		}
+	}
+}
+
+// flush inserts the batch retrying a transient error with an exponential backoff.
+//
+// a batch rejected by the DB, or still failing after the retries, is inserted one message at a time,
+// only the messages that can't be inserted are lost.
+func (p *Persister) flush(batch []*entity.Message) {
+	if len(batch) == 0 {
+		return
+	}
+
+	logger := log.WithField("BatchSize", len(batch))
+	backoff := p.cfg.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := p.repo.CreateMessages(batch)
+		if err == nil {
+			atomic.AddInt64(&p.persisted, int64(len(batch)))
+			logger.Info("messages created")
+			return
+		}
+
+		if !isTransient(err) || attempt >= p.cfg.MaxRetries {
+			logger.WithError(err).Warn("could not create messages on DB, creating one at a time")
+			break
+		}
+
+		atomic.AddInt64(&p.retries, 1)
+		logger.WithError(err).WithField("Attempt", attempt+1).Warn("retrying to create messages on DB")
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	for _, msg := range batch {
+		if err := p.repo.CreateMessage(msg); err != nil {
+			atomic.AddInt64(&p.failed, 1)
+			log.WithFields(log.Fields{
+				"RoomID": msg.RoomID,
+				"UserID": msg.UserID,
+			}).WithError(err).Error("could not create message on DB")
+			continue
+		}
+
+		atomic.AddInt64(&p.persisted, 1)
+	}
+}
+
+// isTransient checks if inserting the messages again may succeed, a domain error, e.g. a message
+// rejected by the DB, fails the same way.
+func isTransient(err error) bool {
+	return entity.AsError(err).Kind == entity.KindInternal
+}
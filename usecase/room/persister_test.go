@@ -0,0 +1,232 @@
+package room_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/room"
+	"github.com/vsantosalmeida/browser-chat/usecase/room/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var persisterConfig = room.PersisterConfig{
+	QueueSize:      10,
+	BatchSize:      2,
+	FlushInterval:  time.Hour,
+	MaxRetries:     2,
+	RetryBackoff:   time.Millisecond,
+	EnqueueTimeout: 10 * time.Millisecond,
+}
+
+func TestPersister_Enqueue(t *testing.T) {
+	var (
+		messages = []*entity.Message{
+			{
+				UserID:  1,
+				RoomID:  1,
+				Content: "first",
+			},
+			{
+				UserID:  2,
+				RoomID:  1,
+				Content: "second",
+			},
+			{
+				UserID:  1,
+				RoomID:  1,
+				Content: "third",
+			},
+		}
+
+		expected = room.PersisterStats{
+			Persisted: 3,
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	p := room.NewPersister(repository, persisterConfig)
+	p.Start()
+
+	// a full batch is flushed right away
+	repository.
+		On("CreateMessages", messages[:2]).
+		Return(nil).
+		Once()
+
+	// the remaining messages are flushed on close
+	repository.
+		On("CreateMessages", messages[2:]).
+		Return(nil).
+		Once()
+
+	for _, m := range messages {
+		assert.NoError(t, p.Enqueue(m))
+	}
+
+	assert.NoError(t, p.Close(context.Background()))
+	assert.Equal(t, expected, p.Stats())
+}
+
+func TestPersister_EnqueueRetry(t *testing.T) {
+	var (
+		messages = []*entity.Message{
+			{
+				UserID:  1,
+				RoomID:  1,
+				Content: "first",
+			},
+		}
+
+		expected = room.PersisterStats{
+			Persisted: 1,
+			Retries:   1,
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	p := room.NewPersister(repository, persisterConfig)
+	p.Start()
+
+	repository.
+		On("CreateMessages", messages).
+		Return(errDB).
+		Once()
+
+	repository.
+		On("CreateMessages", messages).
+		Return(nil).
+		Once()
+
+	assert.NoError(t, p.Enqueue(messages[0]))
+	assert.NoError(t, p.Close(context.Background()))
+	assert.Equal(t, expected, p.Stats())
+}
+
+func TestPersister_EnqueueRetryExhausted(t *testing.T) {
+	var (
+		messages = []*entity.Message{
+			{
+				UserID:  1,
+				RoomID:  1,
+				Content: "first",
+			},
+		}
+
+		expected = room.PersisterStats{
+			Failed:  1,
+			Retries: 2,
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	p := room.NewPersister(repository, persisterConfig)
+	p.Start()
+
+	repository.
+		On("CreateMessages", messages).
+		Return(errDB).
+		Times(3)
+
+	// the messages are inserted one at a time after the retries
+	repository.
+		On("CreateMessage", messages[0]).
+		Return(errDB).
+		Once()
+
+	assert.NoError(t, p.Enqueue(messages[0]))
+	assert.NoError(t, p.Close(context.Background()))
+	assert.Equal(t, expected, p.Stats())
+}
+
+func TestPersister_EnqueueRejectedMessage(t *testing.T) {
+	var (
+		messages = []*entity.Message{
+			{
+				UserID:  1,
+				RoomID:  1,
+				Content: "first",
+			},
+			{
+				UserID:  2,
+				RoomID:  99,
+				Content: "second",
+			},
+		}
+
+		expected = room.PersisterStats{
+			Persisted: 1,
+			Failed:    1,
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	p := room.NewPersister(repository, persisterConfig)
+	p.Start()
+
+	// a batch rejected by the DB isn't retried
+	repository.
+		On("CreateMessages", messages).
+		Return(entity.ErrInvalidEntity).
+		Once()
+
+	// only the rejected message is lost
+	repository.
+		On("CreateMessage", messages[0]).
+		Return(nil).
+		Once()
+
+	repository.
+		On("CreateMessage", messages[1]).
+		Return(entity.ErrInvalidEntity).
+		Once()
+
+	for _, m := range messages {
+		assert.NoError(t, p.Enqueue(m))
+	}
+
+	assert.NoError(t, p.Close(context.Background()))
+	assert.Equal(t, expected, p.Stats())
+}
+
+func TestPersister_EnqueueErrors(t *testing.T) {
+	var tt = []struct {
+		name     string
+		closed   bool
+		expected error
+	}{
+		{
+			name:     "When queue is full; should return error",
+			expected: room.ErrQueueFull,
+		},
+		{
+			name:     "When persister is closed; should return error",
+			closed:   true,
+			expected: room.ErrPersisterClosed,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := persisterConfig
+			cfg.QueueSize = 1
+
+			repository := mocks.NewRepository(t)
+			// the persister isn't started, so the queue isn't consumed
+			p := room.NewPersister(repository, cfg)
+
+			if tc.closed {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				assert.Error(t, p.Close(ctx))
+			} else {
+				assert.NoError(t, p.Enqueue(&entity.Message{}))
+			}
+
+			err := p.Enqueue(&entity.Message{})
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
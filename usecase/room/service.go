@@ -1,7 +1,13 @@
 package room
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
 	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/permission"
 
 	"github.com/apex/log"
 	"github.com/pkg/errors"
@@ -9,16 +15,36 @@ import (
 
 // Service implements UseCase interface.
 type Service struct {
-	repo Repository
+	repo        Repository
+	events      Publisher
+	permissions permission.UseCase
 }
 
 // NewService Service builder.
-func NewService(r Repository) *Service {
+//
+// the room actions are authorized by a permission.Service reading the room members from the same repository.
+func NewService(r Repository, events Publisher) *Service {
 	return &Service{
-		repo: r,
+		repo:        r,
+		events:      events,
+		permissions: permission.NewService(r),
 	}
 }
 
+// FindRoom retrieve a room from DB.
+// returns entity.ErrRoomNotFound if the room doesn't exist.
+func (s *Service) FindRoom(id int) (*entity.Room, error) {
+	room, err := s.repo.FindRoom(id)
+	if err != nil {
+		if !errors.Is(err, entity.ErrRoomNotFound) {
+			log.WithError(err).WithField("RoomID", id).Error("could not retrieve room")
+		}
+		return nil, errors.Wrap(err, "could not retrieve room")
+	}
+
+	return room, nil
+}
+
 // ListRooms retrieve all rooms from DB.
 func (s *Service) ListRooms() ([]*entity.Room, error) {
 	rooms, err := s.repo.ListRooms()
@@ -30,9 +56,30 @@ func (s *Service) ListRooms() ([]*entity.Room, error) {
 	return rooms, nil
 }
 
+// ListVisibleRooms retrieve the public rooms and the private rooms the user is a member of.
+func (s *Service) ListVisibleRooms(userID int) ([]*entity.Room, error) {
+	rooms, err := s.repo.ListVisibleRooms(userID)
+	if err != nil {
+		log.WithError(err).Error("could not retrieve rooms list")
+		return nil, errors.Wrap(err, "could not retrieve rooms list")
+	}
+
+	return rooms, nil
+}
+
 // ListMessages given a room ID retrieve the latest messages from DB.
-func (s *Service) ListMessages(roomID int) ([]*entity.Message, error) {
-	mgs, err := s.repo.ListMessages(roomID)
+// the user must have access to the room, the messages of the users it blocked are hidden.
+func (s *Service) ListMessages(userID, roomID int) ([]*entity.Message, error) {
+	room, err := s.FindRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.CheckAccess(userID, room); err != nil {
+		return nil, err
+	}
+
+	mgs, err := s.repo.ListMessages(roomID, userID)
 	if err != nil {
 		log.WithError(err).Error("could not retrieve messages list")
 		return nil, errors.Wrap(err, "could not retrieve messages list")
@@ -41,9 +88,39 @@ func (s *Service) ListMessages(roomID int) ([]*entity.Message, error) {
 	return mgs, nil
 }
 
-// CreateRoom create new room in DB.
-func (s *Service) CreateRoom() (int, error) {
-	id, err := s.repo.CreateRoom(&entity.Room{})
+// UpdateInput room fields to be updated, a nil field keeps the current value.
+type UpdateInput struct {
+	Name             *string
+	Topic            *string
+	Description      *string
+	Private          *bool
+	MaxMessageLength *int
+	SlowModeSeconds  *int
+	BlockedPatterns  *[]string
+}
+
+// CreateInput room fields to create a room.
+type CreateInput struct {
+	Name        string
+	Topic       string
+	Description string
+	Private     bool
+}
+
+// CreateRoom validate room input and create it in the DB, the creator becomes a room member.
+// the room name must be unique.
+func (s *Service) CreateRoom(creatorID int, input CreateInput) (int, error) {
+	room, err := entity.NewRoom(input.Name, input.Topic, input.Description, creatorID, input.Private)
+	if err != nil {
+		log.WithError(err).Error("could not create a room object")
+		return 0, errors.Wrap(err, "could not create a room object")
+	}
+
+	if err = s.checkNameAvailable(room.Name, 0); err != nil {
+		return 0, err
+	}
+
+	id, err := s.repo.CreateRoom(room)
 	if err != nil {
 		log.WithError(err).Error("could not create room on DB")
 		return 0, errors.Wrap(err, "could not create room on DB")
@@ -51,23 +128,105 @@ func (s *Service) CreateRoom() (int, error) {
 
 	log.WithField("id", id).Info("room created")
 
+	s.publish(Event{
+		Type:   EventRoomCreated,
+		RoomID: id,
+	})
+
 	return id, nil
 }
 
+// UpdateRoom update the room metadata, a renamed room name must be unique.
+// only the room owner and moderators are allowed to update it.
+func (s *Service) UpdateRoom(userID, id int, input UpdateInput) (*entity.Room, error) {
+	room, err := s.findRoomForChange(userID, id, entity.PermissionChangeSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Name != nil {
+		room.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Topic != nil {
+		room.Topic = strings.TrimSpace(*input.Topic)
+	}
+	if input.Description != nil {
+		room.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.Private != nil {
+		room.Private = *input.Private
+	}
+	if input.MaxMessageLength != nil {
+		room.MaxMessageLength = *input.MaxMessageLength
+	}
+	if input.SlowModeSeconds != nil {
+		room.SlowModeSeconds = *input.SlowModeSeconds
+	}
+	if input.BlockedPatterns != nil {
+		room.SetContentPatterns(*input.BlockedPatterns)
+	}
+
+	if err = room.Validate(); err != nil {
+		log.WithError(err).Error("could not update the room object")
+		return nil, errors.Wrap(err, "could not update the room object")
+	}
+
+	if err = s.checkNameAvailable(room.Name, room.ID); err != nil {
+		return nil, err
+	}
+
+	return s.updateRoom(room)
+}
+
+// ArchiveRoom archive the room, an archived room keeps its messages but doesn't accept new ones.
+// only the room owner is allowed to archive it.
+func (s *Service) ArchiveRoom(userID, id int) (*entity.Room, error) {
+	room, err := s.findRoomForChange(userID, id, entity.PermissionManageRoom)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.IsArchived() {
+		return room, nil
+	}
+
+	now := time.Now()
+	room.ArchivedAt = &now
+
+	return s.updateRoom(room)
+}
+
+// DeleteRoom delete the room, its messages, members and invitations from DB.
+// only the room owner is allowed to delete it.
+func (s *Service) DeleteRoom(userID, id int) error {
+	if _, err := s.findRoomForChange(userID, id, entity.PermissionManageRoom); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteRoom(id); err != nil {
+		log.WithError(err).WithField("RoomID", id).Error("could not delete room on DB")
+		return errors.Wrap(err, "could not delete room on DB")
+	}
+
+	log.WithField("id", id).Info("room deleted")
+
+	s.publish(Event{
+		Type:   EventRoomDeleted,
+		RoomID: id,
+	})
+
+	return nil
+}
+
 // CreateMessage create a user message in DB.
-func (s *Service) CreateMessage(userID, roomID int, content string) error {
+// the message must be built with entity.NewMessage to be validated.
+func (s *Service) CreateMessage(msg *entity.Message) error {
 	logger := log.WithFields(log.Fields{
-		"RoomID": roomID,
-		"UserID": userID,
+		"RoomID": msg.RoomID,
+		"UserID": msg.UserID,
 	})
 
-	msg := entity.Message{
-		UserID:  userID,
-		RoomID:  roomID,
-		Content: content,
-	}
-
-	if err := s.repo.CreateMessage(&msg); err != nil {
+	if err := s.repo.CreateMessage(msg); err != nil {
 		logger.WithError(err).Error("could not create message on DB")
 		return errors.Wrap(err, "could not create message on DB")
 	}
@@ -76,3 +235,72 @@ func (s *Service) CreateMessage(userID, roomID int, content string) error {
 
 	return nil
 }
+
+// publish notifies a room event to every chat-api instance.
+// errors are only logged, the event is a best effort notification.
+func (s *Service) publish(e Event) {
+	logger := log.WithFields(log.Fields{
+		"RoomID": e.RoomID,
+		"Event":  e.Type,
+	})
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		logger.WithError(err).Error("could not encode room event")
+		return
+	}
+
+	if err = s.events.WriteMessage(context.Background(), b); err != nil {
+		logger.WithError(err).Error("could not publish room event")
+	}
+}
+
+// findRoomForChange retrieve a room that the user is allowed to change with the given permission.
+func (s *Service) findRoomForChange(userID, id int, p entity.Permission) (*entity.Room, error) {
+	room, err := s.FindRoom(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.permissions.Authorize(userID, room, p); err != nil {
+		return nil, errors.Wrap(err, "could not change room")
+	}
+
+	return room, nil
+}
+
+// updateRoom saves the room changes and notify them.
+func (s *Service) updateRoom(room *entity.Room) (*entity.Room, error) {
+	if err := s.repo.UpdateRoom(room); err != nil {
+		log.WithError(err).WithField("RoomID", room.ID).Error("could not update room on DB")
+		return nil, errors.Wrap(err, "could not update room on DB")
+	}
+
+	log.WithField("id", room.ID).Info("room updated")
+
+	s.publish(Event{
+		Type:   EventRoomUpdated,
+		RoomID: room.ID,
+	})
+
+	return room, nil
+}
+
+// checkNameAvailable checks if the name isn't used by another room.
+func (s *Service) checkNameAvailable(name string, roomID int) error {
+	found, err := s.repo.FindRoomByName(name)
+	if err != nil {
+		if errors.Is(err, entity.ErrRoomNotFound) {
+			return nil
+		}
+
+		log.WithError(err).Error("could not retrieve room by name")
+		return errors.Wrap(err, "could not retrieve room by name")
+	}
+
+	if found.ID != roomID {
+		return errors.Wrap(entity.ErrRoomNameTaken, "could not use room name")
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package room_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/vsantosalmeida/browser-chat/entity"
 	"github.com/vsantosalmeida/browser-chat/usecase/room"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/undefinedlabs/go-mpatch"
 )
 
 var errDB = errors.New("db error")
@@ -16,77 +19,495 @@ var errDB = errors.New("db error")
 func TestService_CreateMessage(t *testing.T) {
 	var (
 		message = &entity.Message{
-			UserID:  1,
-			RoomID:  3,
-			Content: "hello world!",
+			UserID:      1,
+			RoomID:      3,
+			Content:     "hello world!",
+			ContentHTML: "hello world!",
 		}
 	)
 
 	repository := mocks.NewRepository(t)
-	svc := room.NewService(repository)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
 
 	repository.
 		On("CreateMessage", message).
 		Return(nil).
 		Once()
 
-	err := svc.CreateMessage(1, 3, "hello world!")
+	err := svc.CreateMessage(message)
 	assert.NoError(t, err)
 }
 
 func TestService_CreateMessageError(t *testing.T) {
 	var (
 		message = &entity.Message{
-			UserID:  1,
-			RoomID:  3,
-			Content: "hello world!",
+			UserID:      1,
+			RoomID:      3,
+			Content:     "hello world!",
+			ContentHTML: "hello world!",
 		}
 
 		expected = "could not create message on DB: db error"
 	)
 
 	repository := mocks.NewRepository(t)
-	svc := room.NewService(repository)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
 
 	repository.
 		On("CreateMessage", message).
 		Return(errDB).
 		Once()
 
-	err := svc.CreateMessage(1, 3, "hello world!")
+	err := svc.CreateMessage(message)
 	assert.EqualError(t, err, expected)
 }
 
 func TestService_CreateRoom(t *testing.T) {
+	var (
+		expected = 1
+
+		roomEntity = &entity.Room{
+			Name:        "general",
+			Topic:       "news",
+			Description: "general chat",
+			CreatorID:   2,
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
+
+	repository.
+		On("FindRoomByName", "general").
+		Return(nil, entity.ErrRoomNotFound).
+		Once()
+
+	repository.
+		On("CreateRoom", roomEntity).
+		Return(1, nil).
+		Once()
+
+	publisher.
+		On("WriteMessage", context.Background(), []byte(`{"type":"roomCreated","roomID":1}`)).
+		Return(nil).
+		Once()
+
+	id, err := svc.CreateRoom(2, room.CreateInput{
+		Name:        " general ",
+		Topic:       "news",
+		Description: "general chat",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, id)
+}
+
+func TestService_CreateRoomErrors(t *testing.T) {
+	var tt = []struct {
+		name         string
+		roomName     string
+		mockFound    *entity.Room
+		mockFindErr  error
+		mockErr      error
+		expected     string
+		expectedErr  error
+		expectCreate bool
+	}{
+		{
+			name:        "When name is empty; should return error",
+			roomName:    " ",
+			expected:    "could not create a room object: invalid entity",
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:     "When name is already in use; should return error",
+			roomName: "general",
+			mockFound: &entity.Room{
+				ID:   3,
+				Name: "general",
+			},
+			expected:    "could not use room name: room name already in use",
+			expectedErr: entity.ErrRoomNameTaken,
+		},
+		{
+			name:        "When could not check the room name; should return error",
+			roomName:    "general",
+			mockFindErr: errDB,
+			expected:    "could not retrieve room by name: db error",
+			expectedErr: errDB,
+		},
+		{
+			name:         "When could not create room on DB; should return error",
+			roomName:     "general",
+			mockFindErr:  entity.ErrRoomNotFound,
+			mockErr:      errDB,
+			expected:     "could not create room on DB: db error",
+			expectedErr:  errDB,
+			expectCreate: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			publisher := mocks.NewPublisher(t)
+			svc := room.NewService(repository, publisher)
+
+			repository.
+				On("FindRoomByName", tc.roomName).
+				Return(tc.mockFound, tc.mockFindErr).
+				Maybe()
+
+			if tc.expectCreate {
+				repository.
+					On("CreateRoom", &entity.Room{Name: tc.roomName, CreatorID: 2}).
+					Return(0, tc.mockErr).
+					Once()
+			}
+
+			id, err := svc.CreateRoom(2, room.CreateInput{Name: tc.roomName})
+			assert.EqualError(t, err, tc.expected)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Empty(t, id)
+		})
+	}
+}
+
+func TestService_CreateRoomPublishError(t *testing.T) {
 	var expected = 1
 
 	repository := mocks.NewRepository(t)
-	svc := room.NewService(repository)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
 
 	repository.
-		On("CreateRoom", &entity.Room{}).
+		On("FindRoomByName", "general").
+		Return(nil, entity.ErrRoomNotFound).
+		Once()
+
+	repository.
+		On("CreateRoom", &entity.Room{Name: "general", CreatorID: 2}).
 		Return(1, nil).
 		Once()
 
-	id, err := svc.CreateRoom()
+	publisher.
+		On("WriteMessage", context.Background(), []byte(`{"type":"roomCreated","roomID":1}`)).
+		Return(errors.New("broker error")).
+		Once()
+
+	// the room is created even if the event isn't published
+	id, err := svc.CreateRoom(2, room.CreateInput{Name: "general"})
 	assert.NoError(t, err)
 	assert.Equal(t, expected, id)
 }
 
-func TestService_CreateRoomError(t *testing.T) {
-	var expected = "could not create room on DB: db error"
+func TestService_UpdateRoom(t *testing.T) {
+	var (
+		name     = "random"
+		topic    = " anything "
+		limit    = 100
+		patterns = []string{" free money ", ""}
+
+		expected = &entity.Room{
+			ID:               1,
+			Name:             "random",
+			Topic:            "anything",
+			Description:      "general chat",
+			CreatorID:        2,
+			MaxMessageLength: 100,
+			BlockedPatterns:  "free money",
+		}
+	)
 
 	repository := mocks.NewRepository(t)
-	svc := room.NewService(repository)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
 
 	repository.
-		On("CreateRoom", &entity.Room{}).
-		Return(0, errDB).
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, Name: "general", Description: "general chat", CreatorID: 2}, nil).
 		Once()
 
-	id, err := svc.CreateRoom()
-	assert.EqualError(t, err, expected)
-	assert.Empty(t, id)
+	repository.
+		On("FindRoomByName", "random").
+		Return(nil, entity.ErrRoomNotFound).
+		Once()
+
+	repository.
+		On("UpdateRoom", expected).
+		Return(nil).
+		Once()
+
+	publisher.
+		On("WriteMessage", context.Background(), []byte(`{"type":"roomUpdated","roomID":1}`)).
+		Return(nil).
+		Once()
+
+	r, err := svc.UpdateRoom(2, 1, room.UpdateInput{
+		Name:             &name,
+		Topic:            &topic,
+		MaxMessageLength: &limit,
+		BlockedPatterns:  &patterns,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, r)
+}
+
+func TestService_UpdateRoomErrors(t *testing.T) {
+	var (
+		emptyName       = ""
+		usedName        = "random"
+		invalidPatterns = []string{"spam", "(unclosed"}
+		longLimit       = entity.MaxMessageLength + 1
+	)
+
+	var tt = []struct {
+		name        string
+		userID      int
+		input       room.UpdateInput
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:        "When user role doesn't allow to change the room; should return error",
+			userID:      3,
+			expected:    "could not change room: forbidden",
+			expectedErr: entity.ErrForbidden,
+		},
+		{
+			name:   "When name is empty; should return error",
+			userID: 2,
+			input: room.UpdateInput{
+				Name: &emptyName,
+			},
+			expected:    "could not update the room object: invalid entity",
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:   "When name is used by another room; should return error",
+			userID: 2,
+			input: room.UpdateInput{
+				Name: &usedName,
+			},
+			expected:    "could not use room name: room name already in use",
+			expectedErr: entity.ErrRoomNameTaken,
+		},
+		{
+			name:   "When max message length is above the limit; should return error",
+			userID: 2,
+			input: room.UpdateInput{
+				MaxMessageLength: &longLimit,
+			},
+			expected:    "could not update the room object: invalid entity",
+			expectedErr: entity.ErrInvalidEntity,
+		},
+		{
+			name:   "When a blocked pattern isn't a valid regular expression; should return error",
+			userID: 2,
+			input: room.UpdateInput{
+				BlockedPatterns: &invalidPatterns,
+			},
+			expected:    "could not update the room object: invalid entity",
+			expectedErr: entity.ErrInvalidEntity,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			publisher := mocks.NewPublisher(t)
+			svc := room.NewService(repository, publisher)
+
+			repository.
+				On("FindRoom", 1).
+				Return(&entity.Room{ID: 1, Name: "general", CreatorID: 2}, nil).
+				Once()
+
+			repository.
+				On("FindRoomByName", usedName).
+				Return(&entity.Room{ID: 5, Name: usedName}, nil).
+				Maybe()
+
+			// the user 3 isn't a room member with a role
+			repository.
+				On("FindMember", 1, 3).
+				Return(nil, entity.ErrMemberNotFound).
+				Maybe()
+
+			r, err := svc.UpdateRoom(tc.userID, 1, tc.input)
+			assert.EqualError(t, err, tc.expected)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Nil(t, r)
+		})
+	}
+}
+
+func TestService_ArchiveRoom(t *testing.T) {
+	var now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
+
+	// bypass time.Now function to set a static date for the archived time
+	timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+		return now
+	})
+	assert.NoError(t, err)
+	defer timePatch.Unpatch()
+
+	repository.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, Name: "general", CreatorID: 2}, nil).
+		Once()
+
+	repository.
+		On("UpdateRoom", &entity.Room{ID: 1, Name: "general", CreatorID: 2, ArchivedAt: &now}).
+		Return(nil).
+		Once()
+
+	publisher.
+		On("WriteMessage", context.Background(), []byte(`{"type":"roomUpdated","roomID":1}`)).
+		Return(nil).
+		Once()
+
+	r, err := svc.ArchiveRoom(2, 1)
+	assert.NoError(t, err)
+	assert.True(t, r.IsArchived())
+}
+
+func TestService_DeleteRoom(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
+
+	repository.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, Name: "general", CreatorID: 2}, nil).
+		Once()
+
+	repository.
+		On("DeleteRoom", 1).
+		Return(nil).
+		Once()
+
+	publisher.
+		On("WriteMessage", context.Background(), []byte(`{"type":"roomDeleted","roomID":1}`)).
+		Return(nil).
+		Once()
+
+	err := svc.DeleteRoom(2, 1)
+	assert.NoError(t, err)
+}
+
+func TestService_DeleteRoomErrors(t *testing.T) {
+	var tt = []struct {
+		name        string
+		userID      int
+		mockErr     error
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:        "When user role doesn't allow to change the room; should return error",
+			userID:      3,
+			expected:    "could not change room: forbidden",
+			expectedErr: entity.ErrForbidden,
+		},
+		{
+			name:        "When could not delete room on DB; should return error",
+			userID:      2,
+			mockErr:     errDB,
+			expected:    "could not delete room on DB: db error",
+			expectedErr: errDB,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			publisher := mocks.NewPublisher(t)
+			svc := room.NewService(repository, publisher)
+
+			repository.
+				On("FindRoom", 1).
+				Return(&entity.Room{ID: 1, Name: "general", CreatorID: 2}, nil).
+				Once()
+
+			repository.
+				On("DeleteRoom", 1).
+				Return(tc.mockErr).
+				Maybe()
+
+			// the user 3 isn't a room member with a role
+			repository.
+				On("FindMember", 1, 3).
+				Return(nil, entity.ErrMemberNotFound).
+				Maybe()
+
+			err := svc.DeleteRoom(tc.userID, 1)
+			assert.EqualError(t, err, tc.expected)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestService_FindRoom(t *testing.T) {
+	var expected = &entity.Room{
+		ID: 1,
+	}
+
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
+
+	repository.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1}, nil).
+		Once()
+
+	r, err := svc.FindRoom(1)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, r)
+}
+
+func TestService_FindRoomErrors(t *testing.T) {
+	var tt = []struct {
+		name     string
+		mockErr  error
+		expected string
+	}{
+		{
+			name:     "When room doesn't exist; should return not found error",
+			mockErr:  entity.ErrRoomNotFound,
+			expected: "could not retrieve room: room not found",
+		},
+		{
+			name:     "When could not retrieve room; should return error",
+			mockErr:  errDB,
+			expected: "could not retrieve room: db error",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			publisher := mocks.NewPublisher(t)
+			svc := room.NewService(repository, publisher)
+
+			repository.
+				On("FindRoom", 1).
+				Return(nil, tc.mockErr).
+				Once()
+
+			r, err := svc.FindRoom(1)
+			assert.EqualError(t, err, tc.expected)
+			assert.ErrorIs(t, err, tc.mockErr)
+			assert.Nil(t, r)
+		})
+	}
 }
 
 func TestService_ListMessages(t *testing.T) {
@@ -123,14 +544,20 @@ func TestService_ListMessages(t *testing.T) {
 	)
 
 	repository := mocks.NewRepository(t)
-	svc := room.NewService(repository)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
+
+	repository.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1}, nil).
+		Once()
 
 	repository.
-		On("ListMessages", 1).
+		On("ListMessages", 1, 2).
 		Return(messagesList, nil).
 		Once()
 
-	messages, err := svc.ListMessages(1)
+	messages, err := svc.ListMessages(2, 1)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, messages)
 }
@@ -139,15 +566,44 @@ func TestService_ListMessagesError(t *testing.T) {
 	var expected = "could not retrieve messages list: db error"
 
 	repository := mocks.NewRepository(t)
-	svc := room.NewService(repository)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
 
 	repository.
-		On("ListMessages", 1).
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1}, nil).
+		Once()
+
+	repository.
+		On("ListMessages", 1, 2).
 		Return(nil, errDB).
 		Once()
 
-	messages, err := svc.ListMessages(1)
+	messages, err := svc.ListMessages(2, 1)
+	assert.EqualError(t, err, expected)
+	assert.Empty(t, messages)
+}
+
+func TestService_ListMessagesPrivateRoom(t *testing.T) {
+	var expected = "could not access room: forbidden"
+
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
+
+	repository.
+		On("FindRoom", 1).
+		Return(&entity.Room{ID: 1, Private: true}, nil).
+		Once()
+
+	repository.
+		On("FindMember", 1, 2).
+		Return(nil, entity.ErrMemberNotFound).
+		Once()
+
+	messages, err := svc.ListMessages(2, 1)
 	assert.EqualError(t, err, expected)
+	assert.ErrorIs(t, err, entity.ErrForbidden)
 	assert.Empty(t, messages)
 }
 
@@ -173,7 +629,8 @@ func TestService_ListRooms(t *testing.T) {
 	)
 
 	repository := mocks.NewRepository(t)
-	svc := room.NewService(repository)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
 
 	repository.
 		On("ListRooms").
@@ -189,7 +646,8 @@ func TestService_ListRoomsError(t *testing.T) {
 	var expected = "could not retrieve rooms list: db error"
 
 	repository := mocks.NewRepository(t)
-	svc := room.NewService(repository)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
 
 	repository.
 		On("ListRooms").
@@ -200,3 +658,40 @@ func TestService_ListRoomsError(t *testing.T) {
 	assert.EqualError(t, err, expected)
 	assert.Empty(t, rooms)
 }
+
+func TestService_ListVisibleRooms(t *testing.T) {
+	var (
+		roomsList = []*entity.Room{
+			{
+				ID: 1,
+			},
+			{
+				ID:      2,
+				Private: true,
+			},
+		}
+
+		expected = []*entity.Room{
+			{
+				ID: 1,
+			},
+			{
+				ID:      2,
+				Private: true,
+			},
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	svc := room.NewService(repository, publisher)
+
+	repository.
+		On("ListVisibleRooms", 2).
+		Return(roomsList, nil).
+		Once()
+
+	rooms, err := svc.ListVisibleRooms(2)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, rooms)
+}
@@ -0,0 +1,18 @@
+package session
+
+const (
+	// EventSessionRevoked a user session was revoked, the chat-api instance holding its connection closes it.
+	EventSessionRevoked = "sessionRevoked"
+)
+
+// Event notifies a session change to every chat-api instance.
+type Event struct {
+	Type      string `json:"type"`
+	UserID    int    `json:"userID"`
+	SessionID int    `json:"sessionID"`
+}
+
+// IsEvent checks if the event type is a session event.
+func IsEvent(eventType string) bool {
+	return eventType == EventSessionRevoked
+}
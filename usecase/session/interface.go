@@ -0,0 +1,41 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
+
+// Reader handle the required methods to read the user sessions DB.
+type Reader interface {
+	FindSession(id int) (*entity.UserSession, error)
+	ListActiveSessions(userID int, seenAfter time.Time) ([]*entity.UserSession, error)
+}
+
+// Writer handle the required methods to write the user sessions DB.
+type Writer interface {
+	CreateSession(e *entity.UserSession) (int, error)
+	EndSession(id int, now time.Time, revoked bool) error
+	TouchSessions(ids []int, now time.Time) error
+}
+
+// Repository interface to bind Reader and Writer methods.
+type Repository interface {
+	Reader
+	Writer
+}
+
+// Publisher interface to notify the session events to every chat-api instance.
+type Publisher interface {
+	WriteMessage(ctx context.Context, payload []byte) error
+}
+
+// UseCase service to handle the business rules for session context.
+type UseCase interface {
+	Start(userID int, loginID, deviceLabel, ip, userAgent string) (*entity.UserSession, error)
+	End(id int) error
+	Touch(ids []int) error
+	ListSessions(userID int) ([]*entity.UserSession, error)
+	Revoke(userID, sessionID int) error
+}
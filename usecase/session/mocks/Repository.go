@@ -0,0 +1,125 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	entity "github.com/vsantosalmeida/browser-chat/entity"
+
+	time "time"
+)
+
+// Repository is an autogenerated mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// CreateSession provides a mock function with given fields: e
+func (_m *Repository) CreateSession(e *entity.UserSession) (int, error) {
+	ret := _m.Called(e)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(*entity.UserSession) int); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*entity.UserSession) error); ok {
+		r1 = rf(e)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EndSession provides a mock function with given fields: id, now, revoked
+func (_m *Repository) EndSession(id int, now time.Time, revoked bool) error {
+	ret := _m.Called(id, now, revoked)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, time.Time, bool) error); ok {
+		r0 = rf(id, now, revoked)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindSession provides a mock function with given fields: id
+func (_m *Repository) FindSession(id int) (*entity.UserSession, error) {
+	ret := _m.Called(id)
+
+	var r0 *entity.UserSession
+	if rf, ok := ret.Get(0).(func(int) *entity.UserSession); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.UserSession)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListActiveSessions provides a mock function with given fields: userID, seenAfter
+func (_m *Repository) ListActiveSessions(userID int, seenAfter time.Time) ([]*entity.UserSession, error) {
+	ret := _m.Called(userID, seenAfter)
+
+	var r0 []*entity.UserSession
+	if rf, ok := ret.Get(0).(func(int, time.Time) []*entity.UserSession); ok {
+		r0 = rf(userID, seenAfter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.UserSession)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, time.Time) error); ok {
+		r1 = rf(userID, seenAfter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TouchSessions provides a mock function with given fields: ids, now
+func (_m *Repository) TouchSessions(ids []int, now time.Time) error {
+	ret := _m.Called(ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]int, time.Time) error); ok {
+		r0 = rf(ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewRepository(t mockConstructorTestingTNewRepository) *Repository {
+	mock := &Repository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
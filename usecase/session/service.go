@@ -0,0 +1,156 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/token"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TouchInterval interval to refresh the last seen time of the open sessions.
+	TouchInterval = time.Minute
+	// staleAfter a session not seen for this long is not listed, its chat-api instance stopped
+	// without ending it.
+	staleAfter = 3 * TouchInterval
+)
+
+// Service implements UseCase interface.
+type Service struct {
+	repo   Repository
+	tokens token.UseCase
+	events Publisher
+}
+
+// NewService Service builder.
+// the login that opened a revoked session is revoked by the token.UseCase.
+func NewService(r Repository, tokens token.UseCase, events Publisher) *Service {
+	return &Service{
+		repo:   r,
+		tokens: tokens,
+		events: events,
+	}
+}
+
+// Start creates the session of a new user connection opened with the tokens of the login.
+func (s *Service) Start(userID int, loginID, deviceLabel, ip, userAgent string) (*entity.UserSession, error) {
+	e, err := entity.NewUserSession(userID, loginID, deviceLabel, ip, userAgent, time.Now())
+	if err != nil {
+		log.WithError(err).Error("could not create a session object")
+		return nil, errors.Wrap(err, "could not create a session object")
+	}
+
+	id, err := s.repo.CreateSession(e)
+	if err != nil {
+		log.WithError(err).WithField("UserID", userID).Error("could not create session on DB")
+		return nil, errors.Wrap(err, "could not create session on DB")
+	}
+
+	e.ID = id
+
+	return e, nil
+}
+
+// End ends the session of a closed user connection, a revoked session keeps its revoked state.
+func (s *Service) End(id int) error {
+	if err := s.repo.EndSession(id, time.Now(), false); err != nil {
+		log.WithError(err).WithField("SessionID", id).Error("could not end session on DB")
+		return errors.Wrap(err, "could not end session on DB")
+	}
+
+	return nil
+}
+
+// Touch refreshes the last seen time of the open sessions.
+func (s *Service) Touch(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := s.repo.TouchSessions(ids, time.Now()); err != nil {
+		log.WithError(err).Error("could not touch sessions on DB")
+		return errors.Wrap(err, "could not touch sessions on DB")
+	}
+
+	return nil
+}
+
+// ListSessions retrieve the user open sessions, the most recent first.
+func (s *Service) ListSessions(userID int) ([]*entity.UserSession, error) {
+	sessions, err := s.repo.ListActiveSessions(userID, time.Now().Add(-staleAfter))
+	if err != nil {
+		log.WithError(err).WithField("UserID", userID).Error("could not retrieve sessions list")
+		return nil, errors.Wrap(err, "could not retrieve sessions list")
+	}
+
+	return sessions, nil
+}
+
+// Revoke ends one of the user sessions, the chat-api instance holding the connection closes it.
+// the login that opened the session is revoked, the device must sign in again to reconnect.
+// returns entity.ErrSessionNotFound if the session belongs to another user.
+func (s *Service) Revoke(userID, sessionID int) error {
+	e, err := s.repo.FindSession(sessionID)
+	if err != nil {
+		if !errors.Is(err, entity.ErrSessionNotFound) {
+			log.WithError(err).WithField("SessionID", sessionID).Error("could not retrieve session")
+		}
+		return errors.Wrap(err, "could not retrieve session")
+	}
+
+	if e.UserID != userID {
+		return errors.Wrap(entity.ErrSessionNotFound, "could not retrieve session")
+	}
+
+	if e.EndedAt != nil {
+		return nil
+	}
+
+	if err = s.repo.EndSession(e.ID, time.Now(), true); err != nil {
+		log.WithError(err).WithField("SessionID", e.ID).Error("could not revoke session on DB")
+		return errors.Wrap(err, "could not revoke session on DB")
+	}
+
+	log.WithFields(log.Fields{
+		"UserID":    userID,
+		"SessionID": e.ID,
+	}).Info("session revoked")
+
+	s.publish(Event{
+		Type:      EventSessionRevoked,
+		UserID:    userID,
+		SessionID: e.ID,
+	})
+
+	// the login is revoked too, its access token can't open a new session
+	if e.LoginID == "" {
+		return nil
+	}
+
+	return s.tokens.Logout(userID, e.LoginID)
+}
+
+// publish notifies a session event to every chat-api instance.
+// errors are only logged, the event is a best effort notification.
+func (s *Service) publish(e Event) {
+	logger := log.WithFields(log.Fields{
+		"UserID":    e.UserID,
+		"SessionID": e.SessionID,
+		"Event":     e.Type,
+	})
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		logger.WithError(err).Error("could not encode session event")
+		return
+	}
+
+	if err = s.events.WriteMessage(context.Background(), b); err != nil {
+		logger.WithError(err).Error("could not publish session event")
+	}
+}
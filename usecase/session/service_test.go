@@ -0,0 +1,214 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/session"
+	"github.com/vsantosalmeida/browser-chat/usecase/session/mocks"
+	"github.com/vsantosalmeida/browser-chat/usecase/token"
+	tokenMock "github.com/vsantosalmeida/browser-chat/usecase/token/mocks"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/undefinedlabs/go-mpatch"
+)
+
+var errDB = errors.New("db error")
+
+func TestService_Start(t *testing.T) {
+	var (
+		now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		ua  = "Mozilla/5.0 (X11; Linux x86_64)"
+	)
+
+	var tt = []struct {
+		name        string
+		deviceLabel string
+		expected    *entity.UserSession
+	}{
+		{
+			name:        "When the client names its device; should use the device label",
+			deviceLabel: " work laptop ",
+			expected: &entity.UserSession{
+				ID:          1,
+				UserID:      10,
+				LoginID:     "login",
+				DeviceLabel: "work laptop",
+				IP:          "10.0.0.1",
+				UserAgent:   ua,
+				ConnectedAt: now,
+				LastSeenAt:  now,
+			},
+		},
+		{
+			name: "When the client doesn't name its device; should use the user agent as device label",
+			expected: &entity.UserSession{
+				ID:          1,
+				UserID:      10,
+				LoginID:     "login",
+				DeviceLabel: ua,
+				IP:          "10.0.0.1",
+				UserAgent:   ua,
+				ConnectedAt: now,
+				LastSeenAt:  now,
+			},
+		},
+	}
+
+	// bypass time.Now function to set a static connection time
+	timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+		return now
+	})
+	assert.NoError(t, err)
+	defer timePatch.Unpatch()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			svc := session.NewService(repository, nil, nil)
+
+			repository.
+				On("CreateSession", mock.AnythingOfType("*entity.UserSession")).
+				Return(1, nil).
+				Once()
+
+			result, err := svc.Start(10, "login", tc.deviceLabel, "10.0.0.1", ua)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestService_ListSessions(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	repository := mocks.NewRepository(t)
+	svc := session.NewService(repository, nil, nil)
+
+	// bypass time.Now function to set a static stale time
+	timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+		return now
+	})
+	assert.NoError(t, err)
+	defer timePatch.Unpatch()
+
+	sessions := []*entity.UserSession{{ID: 1, UserID: 10}, {ID: 2, UserID: 10}}
+
+	repository.
+		On("ListActiveSessions", 10, now.Add(-3*time.Minute)).
+		Return(sessions, nil).
+		Once()
+
+	result, err := svc.ListSessions(10)
+	assert.NoError(t, err)
+	assert.Equal(t, sessions, result)
+}
+
+func TestService_Revoke(t *testing.T) {
+	var (
+		now     = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		endedAt = now.Add(-time.Hour)
+	)
+
+	var tt = []struct {
+		name        string
+		userID      int
+		found       *entity.UserSession
+		findErr     error
+		endErr      error
+		revoked     bool
+		expectedErr error
+	}{
+		{
+			name:    "When the session is open; should end it and publish the revoked session",
+			userID:  10,
+			found:   &entity.UserSession{ID: 1, UserID: 10},
+			revoked: true,
+		},
+		{
+			name:    "When the session was opened by a login; should revoke the login",
+			userID:  10,
+			found:   &entity.UserSession{ID: 1, UserID: 10, LoginID: "login"},
+			revoked: true,
+		},
+		{
+			name:   "When the session was already ended; should do nothing",
+			userID: 10,
+			found:  &entity.UserSession{ID: 1, UserID: 10, EndedAt: &endedAt},
+		},
+		{
+			name:        "When the session belongs to another user; should return session not found",
+			userID:      20,
+			found:       &entity.UserSession{ID: 1, UserID: 10},
+			expectedErr: entity.ErrSessionNotFound,
+		},
+		{
+			name:        "When the session doesn't exist; should return session not found",
+			userID:      10,
+			findErr:     entity.ErrSessionNotFound,
+			expectedErr: entity.ErrSessionNotFound,
+		},
+		{
+			name:        "When the session can't be ended; should return the DB error",
+			userID:      10,
+			found:       &entity.UserSession{ID: 1, UserID: 10},
+			endErr:      errDB,
+			expectedErr: errDB,
+		},
+	}
+
+	// bypass time.Now function to set a static end time
+	timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+		return now
+	})
+	assert.NoError(t, err)
+	defer timePatch.Unpatch()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			publisher := mocks.NewPublisher(t)
+			tokenRepo := tokenMock.NewRepository(t)
+			tokenPublisher := tokenMock.NewPublisher(t)
+			svc := session.NewService(repository, token.NewService(tokenRepo, nil, nil, tokenPublisher, token.DefaultConfig), publisher)
+
+			repository.
+				On("FindSession", 1).
+				Return(tc.found, tc.findErr).
+				Once()
+
+			if tc.found != nil && tc.found.UserID == tc.userID && tc.found.EndedAt == nil {
+				repository.
+					On("EndSession", 1, now, true).
+					Return(tc.endErr).
+					Once()
+			}
+
+			if tc.revoked {
+				publisher.
+					On("WriteMessage", context.Background(), []byte(`{"type":"sessionRevoked","userID":10,"sessionID":1}`)).
+					Return(nil).
+					Once()
+			}
+
+			if tc.revoked && tc.found.LoginID != "" {
+				tokenRepo.
+					On("RevokeLogin", "login", now).
+					Return(nil).
+					Once()
+
+				tokenPublisher.
+					On("WriteMessage", context.Background(), []byte(`{"type":"loginRevoked","userID":10,"loginID":"login"}`)).
+					Return(nil).
+					Once()
+			}
+
+			err := svc.Revoke(tc.userID, 1)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package token
+
+const (
+	// EventLoginRevoked a user logged out or reused a refresh token, the sessions opened with its tokens are closed.
+	EventLoginRevoked = "loginRevoked"
+)
+
+// Event notifies a login change to every chat-api instance.
+type Event struct {
+	Type    string `json:"type"`
+	UserID  int    `json:"userID"`
+	LoginID string `json:"loginID"`
+}
+
+// IsEvent checks if the event type is a login event.
+func IsEvent(eventType string) bool {
+	return eventType == EventLoginRevoked
+}
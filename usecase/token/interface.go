@@ -0,0 +1,60 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+)
+
+// Reader handle the required methods to read the refresh tokens DB.
+type Reader interface {
+	FindRefreshToken(hash string) (*entity.RefreshToken, error)
+	IsLoginRevoked(loginID string) (bool, error)
+	ListUserLogins(userID int, now time.Time) ([]string, error)
+}
+
+// Writer handle the required methods to write the refresh tokens DB.
+type Writer interface {
+	CreateRefreshToken(e *entity.RefreshToken) (int, error)
+	RotateRefreshToken(used, next *entity.RefreshToken, now time.Time) error
+	RevokeLogin(loginID string, now time.Time) error
+}
+
+// Repository interface to bind Reader and Writer methods.
+type Repository interface {
+	Reader
+	Writer
+}
+
+// UserReader handle the required method to read the user of a refresh token.
+type UserReader interface {
+	FindByID(id int) (*entity.User, error)
+}
+
+// Signer signs the access token claims.
+type Signer interface {
+	CreateJWTToken(claims *auth.Claims) (string, error)
+}
+
+// Publisher interface to notify the login events to every chat-api instance.
+type Publisher interface {
+	WriteMessage(ctx context.Context, payload []byte) error
+}
+
+// Tokens the access token and the refresh token to replace it once expired, ExpiresIn is the access token lifetime.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
+// UseCase service to handle the business rules for token context.
+type UseCase interface {
+	Issue(user *entity.User) (*Tokens, error)
+	Refresh(refreshToken string) (*Tokens, error)
+	Logout(userID int, loginID string) error
+	LogoutOthers(userID int, loginID string) error
+	CheckRevoked(loginID string) error
+}
@@ -0,0 +1,146 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	entity "github.com/vsantosalmeida/browser-chat/entity"
+
+	time "time"
+)
+
+// Repository is an autogenerated mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// CreateRefreshToken provides a mock function with given fields: e
+func (_m *Repository) CreateRefreshToken(e *entity.RefreshToken) (int, error) {
+	ret := _m.Called(e)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(*entity.RefreshToken) int); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*entity.RefreshToken) error); ok {
+		r1 = rf(e)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindRefreshToken provides a mock function with given fields: hash
+func (_m *Repository) FindRefreshToken(hash string) (*entity.RefreshToken, error) {
+	ret := _m.Called(hash)
+
+	var r0 *entity.RefreshToken
+	if rf, ok := ret.Get(0).(func(string) *entity.RefreshToken); ok {
+		r0 = rf(hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.RefreshToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsLoginRevoked provides a mock function with given fields: loginID
+func (_m *Repository) IsLoginRevoked(loginID string) (bool, error) {
+	ret := _m.Called(loginID)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(loginID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(loginID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUserLogins provides a mock function with given fields: userID, now
+func (_m *Repository) ListUserLogins(userID int, now time.Time) ([]string, error) {
+	ret := _m.Called(userID, now)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(int, time.Time) []string); ok {
+		r0 = rf(userID, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, time.Time) error); ok {
+		r1 = rf(userID, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeLogin provides a mock function with given fields: loginID, now
+func (_m *Repository) RevokeLogin(loginID string, now time.Time) error {
+	ret := _m.Called(loginID, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) error); ok {
+		r0 = rf(loginID, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RotateRefreshToken provides a mock function with given fields: used, next, now
+func (_m *Repository) RotateRefreshToken(used *entity.RefreshToken, next *entity.RefreshToken, now time.Time) error {
+	ret := _m.Called(used, next, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*entity.RefreshToken, *entity.RefreshToken, time.Time) error); ok {
+		r0 = rf(used, next, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewRepository(t mockConstructorTestingTNewRepository) *Repository {
+	mock := &Repository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
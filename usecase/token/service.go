@@ -0,0 +1,226 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// Config lifetime of the issued tokens.
+type Config struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// DefaultConfig short-lived access tokens refreshed for up to 30 days.
+var DefaultConfig = Config{
+	AccessTTL:  15 * time.Minute,
+	RefreshTTL: 30 * 24 * time.Hour,
+}
+
+// Service implements UseCase interface.
+type Service struct {
+	repo   Repository
+	users  UserReader
+	signer Signer
+	events Publisher
+	cfg    Config
+}
+
+// NewService Service builder.
+func NewService(r Repository, users UserReader, signer Signer, events Publisher, cfg Config) *Service {
+	return &Service{
+		repo:   r,
+		users:  users,
+		signer: signer,
+		events: events,
+		cfg:    cfg,
+	}
+}
+
+// Issue starts a new login of the authenticated user and issues its first tokens.
+func (s *Service) Issue(user *entity.User) (*Tokens, error) {
+	loginID, err := entity.NewLoginID()
+	if err != nil {
+		log.WithError(err).Error("could not generate login id")
+		return nil, errors.Wrap(err, "could not generate login id")
+	}
+
+	refresh, raw, err := entity.NewRefreshToken(user.ID, loginID, s.cfg.RefreshTTL, time.Now())
+	if err != nil {
+		log.WithError(err).Error("could not create a refresh token object")
+		return nil, errors.Wrap(err, "could not create a refresh token object")
+	}
+
+	if _, err = s.repo.CreateRefreshToken(refresh); err != nil {
+		log.WithError(err).WithField("UserID", user.ID).Error("could not create refresh token on DB")
+		return nil, errors.Wrap(err, "could not create refresh token on DB")
+	}
+
+	return s.tokens(user, loginID, raw)
+}
+
+// Refresh replaces a refresh token by a new one and issues a new access token of the same login.
+//
+// a refresh token is used only once, reusing it revokes the whole login as the token may have been stolen.
+// returns entity.ErrInvalidToken if the refresh token is unknown, expired or revoked.
+func (s *Service) Refresh(refreshToken string) (*Tokens, error) {
+	used, err := s.repo.FindRefreshToken(entity.HashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidToken) {
+			return nil, errors.Wrap(err, "could not refresh token")
+		}
+
+		log.WithError(err).Error("could not retrieve refresh token")
+		return nil, errors.Wrap(err, "could not retrieve refresh token")
+	}
+
+	now := time.Now()
+
+	logger := log.WithFields(log.Fields{
+		"UserID":  used.UserID,
+		"LoginID": used.LoginID,
+	})
+
+	if used.UsedAt != nil && used.RevokedAt == nil {
+		logger.Warn("refresh token reused, revoking login")
+
+		if err = s.Logout(used.UserID, used.LoginID); err != nil {
+			return nil, err
+		}
+	}
+
+	if !used.IsUsable(now) {
+		return nil, errors.Wrap(entity.ErrInvalidToken, "could not refresh token")
+	}
+
+	user, err := s.users.FindByID(used.UserID)
+	if err != nil {
+		logger.WithError(err).Error("could not retrieve refresh token user")
+		return nil, errors.Wrap(err, "could not retrieve refresh token user")
+	}
+
+	next, raw, err := entity.NewRefreshToken(used.UserID, used.LoginID, s.cfg.RefreshTTL, now)
+	if err != nil {
+		logger.WithError(err).Error("could not create a refresh token object")
+		return nil, errors.Wrap(err, "could not create a refresh token object")
+	}
+
+	// the rotation fails with entity.ErrInvalidToken if the token was used meanwhile
+	if err = s.repo.RotateRefreshToken(used, next, now); err != nil {
+		if !errors.Is(err, entity.ErrInvalidToken) {
+			logger.WithError(err).Error("could not rotate refresh token on DB")
+		}
+		return nil, errors.Wrap(err, "could not rotate refresh token")
+	}
+
+	return s.tokens(user, used.LoginID, raw)
+}
+
+// Logout revokes the refresh tokens of the login, its access tokens are rejected and the sessions opened with them closed.
+func (s *Service) Logout(userID int, loginID string) error {
+	if err := s.repo.RevokeLogin(loginID, time.Now()); err != nil {
+		log.WithError(err).WithField("LoginID", loginID).Error("could not revoke login on DB")
+		return errors.Wrap(err, "could not revoke login on DB")
+	}
+
+	log.WithFields(log.Fields{
+		"UserID":  userID,
+		"LoginID": loginID,
+	}).Info("login revoked")
+
+	s.publish(Event{
+		Type:    EventLoginRevoked,
+		UserID:  userID,
+		LoginID: loginID,
+	})
+
+	return nil
+}
+
+// LogoutOthers revokes every login of the user except the given one, e.g. after a password change.
+func (s *Service) LogoutOthers(userID int, loginID string) error {
+	logins, err := s.repo.ListUserLogins(userID, time.Now())
+	if err != nil {
+		log.WithError(err).WithField("UserID", userID).Error("could not retrieve user logins")
+		return errors.Wrap(err, "could not retrieve user logins")
+	}
+
+	for _, login := range logins {
+		if login == loginID {
+			continue
+		}
+
+		if err = s.Logout(userID, login); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckRevoked returns entity.ErrInvalidToken if the login that issued an access token was revoked.
+func (s *Service) CheckRevoked(loginID string) error {
+	if loginID == "" {
+		return errors.Wrap(entity.ErrInvalidToken, "token without login")
+	}
+
+	revoked, err := s.repo.IsLoginRevoked(loginID)
+	if err != nil {
+		log.WithError(err).WithField("LoginID", loginID).Error("could not check login revocation")
+		return errors.Wrap(err, "could not check login revocation")
+	}
+
+	if revoked {
+		return errors.Wrap(entity.ErrInvalidToken, "login revoked")
+	}
+
+	return nil
+}
+
+// tokens signs a new access token of the login.
+func (s *Service) tokens(user *entity.User, loginID, refreshToken string) (*Tokens, error) {
+	claims, err := auth.NewClaims(user, loginID, s.cfg.AccessTTL, time.Now())
+	if err != nil {
+		log.WithError(err).Error("could not create token claims")
+		return nil, errors.Wrap(err, "could not create token claims")
+	}
+
+	accessToken, err := s.signer.CreateJWTToken(claims)
+	if err != nil {
+		log.WithError(err).Error("could not generate user token")
+		return nil, errors.Wrap(err, "could not generate user token")
+	}
+
+	return &Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    s.cfg.AccessTTL,
+	}, nil
+}
+
+// publish notifies a login event to every chat-api instance.
+// errors are only logged, the event is a best effort notification.
+func (s *Service) publish(e Event) {
+	logger := log.WithFields(log.Fields{
+		"UserID":  e.UserID,
+		"LoginID": e.LoginID,
+		"Event":   e.Type,
+	})
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		logger.WithError(err).Error("could not encode login event")
+		return
+	}
+
+	if err = s.events.WriteMessage(context.Background(), b); err != nil {
+		logger.WithError(err).Error("could not publish login event")
+	}
+}
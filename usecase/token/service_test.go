@@ -0,0 +1,263 @@
+package token_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+	"github.com/vsantosalmeida/browser-chat/usecase/token"
+	"github.com/vsantosalmeida/browser-chat/usecase/token/mocks"
+	userMock "github.com/vsantosalmeida/browser-chat/usecase/user/mocks"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/undefinedlabs/go-mpatch"
+)
+
+var errDB = errors.New("db error")
+
+func newKeySet(t *testing.T) *auth.KeySet {
+	key, err := auth.NewHMACKey("test", []byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+
+	keys, err := auth.NewKeySet(key)
+	assert.NoError(t, err)
+
+	return keys
+}
+
+func TestService_Refresh(t *testing.T) {
+	var (
+		now  = time.Now()
+		raw  = "refresh-token"
+		used = &entity.RefreshToken{
+			ID:        1,
+			UserID:    3,
+			LoginID:   "login",
+			TokenHash: entity.HashRefreshToken(raw),
+			ExpiresAt: now.Add(time.Hour),
+		}
+	)
+
+	repository := mocks.NewRepository(t)
+	users := userMock.NewRepository(t)
+	keys := newKeySet(t)
+	svc := token.NewService(repository, users, keys, nil, token.DefaultConfig)
+
+	repository.
+		On("FindRefreshToken", entity.HashRefreshToken(raw)).
+		Return(used, nil).
+		Once()
+
+	users.
+		On("FindByID", 3).
+		Return(&entity.User{ID: 3, Username: "test"}, nil).
+		Once()
+
+	var next *entity.RefreshToken
+	repository.
+		On("RotateRefreshToken", used, mock.AnythingOfType("*entity.RefreshToken"), mock.AnythingOfType("time.Time")).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			next = args.Get(1).(*entity.RefreshToken)
+		}).
+		Once()
+
+	tokens, err := svc.Refresh(raw)
+	assert.NoError(t, err)
+
+	// the next refresh token belongs to the same login
+	assert.Equal(t, "login", next.LoginID)
+	assert.Equal(t, entity.HashRefreshToken(tokens.RefreshToken), next.TokenHash)
+	assert.NotEqual(t, raw, tokens.RefreshToken)
+
+	user, err := keys.ValidateJWTToken(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, user.GetId())
+	assert.Equal(t, "login", user.GetLoginID())
+}
+
+func TestService_RefreshErrors(t *testing.T) {
+	var (
+		now     = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		usedAt  = now.Add(-time.Minute)
+		revoked = now.Add(-time.Minute)
+	)
+
+	var tt = []struct {
+		name        string
+		found       *entity.RefreshToken
+		findErr     error
+		reused      bool
+		expectedErr error
+	}{
+		{
+			name:        "When the refresh token is unknown; should return invalid token",
+			findErr:     entity.ErrInvalidToken,
+			expectedErr: entity.ErrInvalidToken,
+		},
+		{
+			name:        "When the refresh token expired; should return invalid token",
+			found:       &entity.RefreshToken{ID: 1, UserID: 3, LoginID: "login", ExpiresAt: now.Add(-time.Second)},
+			expectedErr: entity.ErrInvalidToken,
+		},
+		{
+			name:        "When the login was revoked; should return invalid token",
+			found:       &entity.RefreshToken{ID: 1, UserID: 3, LoginID: "login", ExpiresAt: now.Add(time.Hour), UsedAt: &usedAt, RevokedAt: &revoked},
+			expectedErr: entity.ErrInvalidToken,
+		},
+		{
+			name:        "When the refresh token was already used; should revoke the login and return invalid token",
+			found:       &entity.RefreshToken{ID: 1, UserID: 3, LoginID: "login", ExpiresAt: now.Add(time.Hour), UsedAt: &usedAt},
+			reused:      true,
+			expectedErr: entity.ErrInvalidToken,
+		},
+		{
+			name:        "When the refresh token can't be retrieved; should return the DB error",
+			findErr:     errDB,
+			expectedErr: errDB,
+		},
+	}
+
+	// bypass time.Now function to set a static refresh time
+	timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+		return now
+	})
+	assert.NoError(t, err)
+	defer timePatch.Unpatch()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			publisher := mocks.NewPublisher(t)
+			svc := token.NewService(repository, nil, nil, publisher, token.DefaultConfig)
+
+			repository.
+				On("FindRefreshToken", entity.HashRefreshToken("refresh-token")).
+				Return(tc.found, tc.findErr).
+				Once()
+
+			if tc.reused {
+				repository.
+					On("RevokeLogin", "login", now).
+					Return(nil).
+					Once()
+
+				publisher.
+					On("WriteMessage", context.Background(), []byte(`{"type":"loginRevoked","userID":3,"loginID":"login"}`)).
+					Return(nil).
+					Once()
+			}
+
+			tokens, err := svc.Refresh("refresh-token")
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Nil(t, tokens)
+		})
+	}
+}
+
+func TestService_Logout(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	svc := token.NewService(repository, nil, nil, publisher, token.DefaultConfig)
+
+	// bypass time.Now function to set a static revocation time
+	timePatch, err := mpatch.PatchMethod(time.Now, func() time.Time {
+		return now
+	})
+	assert.NoError(t, err)
+	defer timePatch.Unpatch()
+
+	repository.
+		On("RevokeLogin", "login", now).
+		Return(nil).
+		Once()
+
+	publisher.
+		On("WriteMessage", context.Background(), []byte(`{"type":"loginRevoked","userID":3,"loginID":"login"}`)).
+		Return(nil).
+		Once()
+
+	err = svc.Logout(3, "login")
+	assert.NoError(t, err)
+}
+
+func TestService_LogoutOthers(t *testing.T) {
+	repository := mocks.NewRepository(t)
+	publisher := mocks.NewPublisher(t)
+	svc := token.NewService(repository, nil, nil, publisher, token.DefaultConfig)
+
+	repository.
+		On("ListUserLogins", 3, mock.AnythingOfType("time.Time")).
+		Return([]string{"login", "laptop", "phone"}, nil).
+		Once()
+
+	// the current login isn't revoked
+	for _, login := range []string{"laptop", "phone"} {
+		repository.
+			On("RevokeLogin", login, mock.AnythingOfType("time.Time")).
+			Return(nil).
+			Once()
+
+		publisher.
+			On("WriteMessage", context.Background(), []byte(`{"type":"loginRevoked","userID":3,"loginID":"`+login+`"}`)).
+			Return(nil).
+			Once()
+	}
+
+	err := svc.LogoutOthers(3, "login")
+	assert.NoError(t, err)
+}
+
+func TestService_CheckRevoked(t *testing.T) {
+	var tt = []struct {
+		name        string
+		loginID     string
+		revoked     bool
+		repoErr     error
+		expectedErr error
+	}{
+		{
+			name:    "When the login isn't revoked; should return no error",
+			loginID: "login",
+		},
+		{
+			name:        "When the login is revoked; should return invalid token",
+			loginID:     "login",
+			revoked:     true,
+			expectedErr: entity.ErrInvalidToken,
+		},
+		{
+			name:        "When the token has no login; should return invalid token",
+			expectedErr: entity.ErrInvalidToken,
+		},
+		{
+			name:        "When the revocation can't be checked; should return the DB error",
+			loginID:     "login",
+			repoErr:     errDB,
+			expectedErr: errDB,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repository := mocks.NewRepository(t)
+			svc := token.NewService(repository, nil, nil, nil, token.DefaultConfig)
+
+			if tc.loginID != "" {
+				repository.
+					On("IsLoginRevoked", tc.loginID).
+					Return(tc.revoked, tc.repoErr).
+					Once()
+			}
+
+			err := svc.CheckRevoked(tc.loginID)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
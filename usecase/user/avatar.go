@@ -0,0 +1,47 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
+
+// MaxAvatarSize max avatar file bytes.
+const MaxAvatarSize = 1 << 20
+
+// avatarExtensions the supported avatar content types and their file extension.
+var avatarExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// newAvatar validates the avatar image, sniffing its content type, and gives it a unique file name.
+// a new name is used by each upload to not serve a cached previous avatar.
+func newAvatar(userID int, data []byte) (*entity.Avatar, error) {
+	if len(data) == 0 || len(data) > MaxAvatarSize {
+		return nil, entity.ErrInvalidAvatar
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := avatarExtensions[contentType]
+	if !ok {
+		return nil, entity.ErrInvalidAvatar
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return &entity.Avatar{
+		Name:        fmt.Sprintf("%d-%s%s", userID, hex.EncodeToString(b), ext),
+		UserID:      userID,
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
@@ -0,0 +1,26 @@
+package user
+
+const (
+	// EventProfileUpdated a user changed its profile, the chat-api instances push it to the user rooms.
+	EventProfileUpdated = "profileUpdated"
+	// EventStatusUpdated a user set or cleared its status, the chat-api instances push the new status to the user rooms.
+	EventStatusUpdated = "statusUpdated"
+	// EventBlocksUpdated a user blocked or unblocked another user, the chat-api instances reload its block list.
+	EventBlocksUpdated = "blocksUpdated"
+)
+
+// Event notifies a user change to every chat-api instance.
+type Event struct {
+	Type   string `json:"type"`
+	UserID int    `json:"userID"`
+}
+
+// IsEvent checks if the event type is a user event.
+func IsEvent(eventType string) bool {
+	switch eventType {
+	case EventProfileUpdated, EventStatusUpdated, EventBlocksUpdated:
+		return true
+	default:
+		return false
+	}
+}
@@ -1,16 +1,28 @@
 package user
 
-import "github.com/vsantosalmeida/browser-chat/entity"
+import (
+	"context"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+	"github.com/vsantosalmeida/browser-chat/usecase/token"
+)
 
 // Reader handle the required methods to read users DB.
 type Reader interface {
 	FindByUsername(username string) (*entity.User, error)
+	FindByID(id int) (*entity.User, error)
 	List() ([]*entity.User, error)
+	ListBlocks(userID int) ([]*entity.UserBlock, error)
 }
 
 // Writer handle the required methods to write users DB.
 type Writer interface {
 	Create(e *entity.User) (int, error)
+	UpdatePassword(id int, hash string) error
+	UpdateProfile(e *entity.User) error
+	UpdateStatus(id int, status entity.UserStatus) error
+	CreateBlock(e *entity.UserBlock) error
+	DeleteBlock(userID, blockedID int) error
 }
 
 // Repository interface to bind Reader and Writer methods.
@@ -19,9 +31,31 @@ type Repository interface {
 	Writer
 }
 
+// AvatarStore handle the required methods to store the avatar images served under entity.AvatarURLPrefix,
+// the images are shared by every chat-api instance.
+type AvatarStore interface {
+	Save(e *entity.Avatar) error
+	Find(name string) (*entity.Avatar, error)
+	Delete(name string) error
+}
+
+// Publisher interface to notify the profile, status and block events to every chat-api instance.
+type Publisher interface {
+	WriteMessage(ctx context.Context, payload []byte) error
+}
+
 // UseCase service to handle the business rules for user context.
 type UseCase interface {
-	Authenticate(username, password string) (string, error)
+	Authenticate(username, password, ip string) (*token.Tokens, error)
 	ListUsers() ([]*entity.User, error)
 	CreateUser(username, password string) (int, error)
+	ChangePassword(userID int, loginID, oldPassword, newPassword string) error
+	GetProfile(id int) (*entity.User, error)
+	UpdateProfile(userID int, update entity.ProfileUpdate) (*entity.User, error)
+	UpdateAvatar(userID int, data []byte) (*entity.User, error)
+	GetAvatar(name string) (*entity.Avatar, error)
+	SetStatus(userID int, status entity.UserStatus) (*entity.User, error)
+	ListBlocks(userID int) ([]*entity.UserBlock, error)
+	BlockUser(userID, blockedID int) error
+	UnblockUser(userID, blockedID int) error
 }
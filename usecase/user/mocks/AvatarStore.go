@@ -0,0 +1,79 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	entity "github.com/vsantosalmeida/browser-chat/entity"
+)
+
+// AvatarStore is an autogenerated mock type for the AvatarStore type
+type AvatarStore struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: name
+func (_m *AvatarStore) Delete(name string) error {
+	ret := _m.Called(name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Find provides a mock function with given fields: name
+func (_m *AvatarStore) Find(name string) (*entity.Avatar, error) {
+	ret := _m.Called(name)
+
+	var r0 *entity.Avatar
+	if rf, ok := ret.Get(0).(func(string) *entity.Avatar); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Avatar)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: e
+func (_m *AvatarStore) Save(e *entity.Avatar) error {
+	ret := _m.Called(e)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*entity.Avatar) error); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAvatarStore interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAvatarStore creates a new instance of AvatarStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAvatarStore(t mockConstructorTestingTNewAvatarStore) *AvatarStore {
+	mock := &AvatarStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
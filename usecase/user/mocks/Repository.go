@@ -33,6 +33,57 @@ func (_m *Repository) Create(e *entity.User) (int, error) {
 	return r0, r1
 }
 
+// CreateBlock provides a mock function with given fields: e
+func (_m *Repository) CreateBlock(e *entity.UserBlock) error {
+	ret := _m.Called(e)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*entity.UserBlock) error); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteBlock provides a mock function with given fields: userID, blockedID
+func (_m *Repository) DeleteBlock(userID int, blockedID int) error {
+	ret := _m.Called(userID, blockedID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, int) error); ok {
+		r0 = rf(userID, blockedID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindByID provides a mock function with given fields: id
+func (_m *Repository) FindByID(id int) (*entity.User, error) {
+	ret := _m.Called(id)
+
+	var r0 *entity.User
+	if rf, ok := ret.Get(0).(func(int) *entity.User); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByUsername provides a mock function with given fields: username
 func (_m *Repository) FindByUsername(username string) (*entity.User, error) {
 	ret := _m.Called(username)
@@ -79,6 +130,71 @@ func (_m *Repository) List() ([]*entity.User, error) {
 	return r0, r1
 }
 
+// ListBlocks provides a mock function with given fields: userID
+func (_m *Repository) ListBlocks(userID int) ([]*entity.UserBlock, error) {
+	ret := _m.Called(userID)
+
+	var r0 []*entity.UserBlock
+	if rf, ok := ret.Get(0).(func(int) []*entity.UserBlock); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.UserBlock)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdatePassword provides a mock function with given fields: id, hash
+func (_m *Repository) UpdatePassword(id int, hash string) error {
+	ret := _m.Called(id, hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, string) error); ok {
+		r0 = rf(id, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateProfile provides a mock function with given fields: e
+func (_m *Repository) UpdateProfile(e *entity.User) error {
+	ret := _m.Called(e)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*entity.User) error); ok {
+		r0 = rf(e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateStatus provides a mock function with given fields: id, status
+func (_m *Repository) UpdateStatus(id int, status entity.UserStatus) error {
+	ret := _m.Called(id, status)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, entity.UserStatus) error); ok {
+		r0 = rf(id, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewRepository interface {
 	mock.TestingT
 	Cleanup(func())
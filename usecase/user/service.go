@@ -1,49 +1,150 @@
 package user
 
 import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
 	"github.com/vsantosalmeida/browser-chat/entity"
-	"github.com/vsantosalmeida/browser-chat/pkg/auth"
+	"github.com/vsantosalmeida/browser-chat/usecase/token"
 
 	"github.com/apex/log"
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Service implements UseCase interface.
 type Service struct {
-	repo Repository
+	repo     Repository
+	tokens   token.UseCase
+	policy   entity.PasswordPolicy
+	throttle LoginThrottle
+	avatars  AvatarStore
+	events   Publisher
+
+	dummyOnce sync.Once
+	dummyHash string
 }
 
 // NewService Service builder.
-func NewService(r Repository) *Service {
+// the tokens of an authenticated user are issued by the token.UseCase, the new passwords must follow the policy
+// and the failed logins are throttled by the LoginThrottle.
+//
+// the avatar images are kept by the AvatarStore and the profile changes published to every chat-api instance.
+func NewService(r Repository, tokens token.UseCase, policy entity.PasswordPolicy, throttle LoginThrottle, avatars AvatarStore, events Publisher) *Service {
 	return &Service{
-		repo: r,
+		repo:     r,
+		tokens:   tokens,
+		policy:   policy,
+		throttle: throttle,
+		avatars:  avatars,
+		events:   events,
 	}
 }
 
-// Authenticate retrieve user from DB and validate the password, if no error occurs a JWT token will be generated
-// and returned.
-func (s *Service) Authenticate(username, password string) (string, error) {
+// Authenticate retrieve user from DB and validate the password, if no error occurs an access token
+// and a refresh token will be issued and returned.
+// the password is rehashed when its hash cost is lower than the policy cost.
+//
+// the failed logins are throttled by username and by client IP, a throttled login returns a *ThrottleError
+// before the password is checked. An unknown username fails as a wrong password, it's throttled the same way
+// and takes the same time, to not reveal if the username exists.
+func (s *Service) Authenticate(username, password, ip string) (*token.Tokens, error) {
+	now := s.throttle.now()
+	if wait := s.throttleWait(username, ip, now); wait > 0 {
+		log.WithFields(log.Fields{
+			"username":   username,
+			"ip":         ip,
+			"retryAfter": wait.String(),
+		}).Warn("login throttled")
+		return nil, &ThrottleError{RetryAfter: wait}
+	}
+
 	user, err := s.repo.FindByUsername(username)
-	if err != nil {
-		log.WithError(err).Error("user not found")
-		return "", errors.Wrap(err, "could not find user")
+	if errors.Is(err, entity.ErrUserNotFound) {
+		// compares a dummy hash to take the time of a wrong password
+		user = &entity.User{Password: s.dummyPassword()}
+	} else if err != nil {
+		log.WithError(err).Error("could not find user")
+		return nil, errors.Wrap(err, "could not find user")
 	}
 
-	if err = user.ValidatePassword(password); err != nil {
-		log.WithError(err).Error("could not validate user")
-		return "", errors.Wrap(err, "could not validate user")
+	if err = user.ValidatePassword(password); err != nil || user.ID == 0 {
+		s.loginFailed(username, ip, now)
+		return nil, errors.Wrap(entity.ErrInvalidPassword, "could not validate user")
 	}
 
-	token, err := auth.CreateJWTToken(user)
+	s.throttle.Username.Reset(username)
+	s.rehashPassword(user, password)
+
+	tokens, err := s.tokens.Issue(user)
 	if err != nil {
-		log.WithError(err).Error("could not generate user token")
-		return "", errors.Wrap(err, "could not generate user token")
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"username": username,
+		"ip":       ip,
+	}).Info("user authenticated")
+
+	return tokens, nil
+}
+
+// throttleWait returns how long the username or the client IP must wait before the next login.
+// a successful login doesn't reset the IP failures, a user can't unlock its IP to guess other passwords.
+func (s *Service) throttleWait(username, ip string, now time.Time) time.Duration {
+	wait := s.throttle.Username.Wait(username, now)
+	if ip == "" {
+		return wait
+	}
+
+	if ipWait := s.throttle.IP.Wait(ip, now); ipWait > wait {
+		return ipWait
+	}
+
+	return wait
+}
+
+// loginFailed records and logs the failed login, the username and IP lockouts are logged as security events.
+func (s *Service) loginFailed(username, ip string, now time.Time) {
+	failures, locked := s.throttle.Username.Fail(username, now)
+
+	logger := log.WithFields(log.Fields{
+		"username": username,
+		"ip":       ip,
+		"failures": failures,
+	})
+	logger.Warn("login failed")
+
+	if locked {
+		logger.Warn("username locked out")
+	}
+
+	if ip == "" {
+		return
+	}
+
+	if ipFailures, ipLocked := s.throttle.IP.Fail(ip, now); ipLocked {
+		log.WithFields(log.Fields{
+			"ip":       ip,
+			"failures": ipFailures,
+		}).Warn("client IP locked out")
 	}
+}
 
-	log.WithField("username", username).Info("user authenticated")
+// dummyPassword returns a hash of the policy cost compared to the password of an unknown username.
+func (s *Service) dummyPassword() string {
+	s.dummyOnce.Do(func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte("dummy password"), s.policy.Cost)
+		if err != nil {
+			log.WithError(err).Error("could not generate dummy password")
+		}
 
-	return token, nil
+		s.dummyHash = string(hash)
+	})
 
+	return s.dummyHash
 }
 
 // ListUsers retrieve all users from DB.
@@ -59,7 +160,7 @@ func (s *Service) ListUsers() ([]*entity.User, error) {
 
 // CreateUser validate user input and create it in the DB.
 func (s *Service) CreateUser(username, password string) (int, error) {
-	user, err := entity.NewUser(username, password)
+	user, err := entity.NewUser(username, password, s.policy)
 	if err != nil {
 		log.WithError(err).Error("could not create an user object")
 		return 0, errors.Wrap(err, "could not create an user object")
@@ -75,3 +176,259 @@ func (s *Service) CreateUser(username, password string) (int, error) {
 
 	return id, nil
 }
+
+// ChangePassword replaces the user password, the old password must be valid and the new one follow the policy.
+// the other logins of the user are revoked, only the login changing the password stays signed in.
+func (s *Service) ChangePassword(userID int, loginID, oldPassword, newPassword string) error {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return errors.Wrap(err, "could not find user")
+	}
+
+	// the user is authenticated, a wrong password doesn't ask to sign in again
+	if err = user.ValidatePassword(oldPassword); err != nil {
+		log.WithField("userID", userID).Warn("password change with invalid password")
+		return errors.Wrap(entity.ErrWrongPassword, "could not validate user")
+	}
+
+	if err = user.SetPassword(newPassword, s.policy); err != nil {
+		return errors.Wrap(err, "could not change password")
+	}
+
+	if err = s.repo.UpdatePassword(user.ID, user.Password); err != nil {
+		log.WithError(err).Error("could not update user password")
+		return errors.Wrap(err, "could not update user password")
+	}
+
+	log.WithField("userID", userID).Info("user password changed")
+
+	if err = s.tokens.LogoutOthers(userID, loginID); err != nil {
+		return errors.Wrap(err, "could not revoke other logins")
+	}
+
+	return nil
+}
+
+// GetProfile retrieves the user profile.
+func (s *Service) GetProfile(id int) (*entity.User, error) {
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find user")
+	}
+
+	return user, nil
+}
+
+// UpdateProfile validates and saves the profile changes, the change is published to the user rooms.
+func (s *Service) UpdateProfile(userID int, update entity.ProfileUpdate) (*entity.User, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find user")
+	}
+
+	if err = user.UpdateProfile(update); err != nil {
+		return nil, errors.Wrap(err, "could not update profile")
+	}
+
+	if err = s.repo.UpdateProfile(user); err != nil {
+		log.WithError(err).Error("could not update user profile")
+		return nil, errors.Wrap(err, "could not update user profile")
+	}
+
+	log.WithField("userID", userID).Info("user profile updated")
+
+	s.publish(Event{
+		Type:   EventProfileUpdated,
+		UserID: userID,
+	})
+
+	return user, nil
+}
+
+// UpdateAvatar stores the avatar image and replaces the user avatar, the previous avatar image is deleted.
+func (s *Service) UpdateAvatar(userID int, data []byte) (*entity.User, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find user")
+	}
+
+	avatar, err := newAvatar(userID, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not update avatar")
+	}
+
+	if err = s.avatars.Save(avatar); err != nil {
+		log.WithError(err).Error("could not store avatar")
+		return nil, errors.Wrap(err, "could not store avatar")
+	}
+
+	previous := user.Avatar
+	user.Avatar = avatar.Name
+
+	if err = s.repo.UpdateProfile(user); err != nil {
+		log.WithError(err).Error("could not update user avatar")
+		s.deleteAvatar(avatar.Name)
+		return nil, errors.Wrap(err, "could not update user avatar")
+	}
+
+	if previous != "" {
+		s.deleteAvatar(previous)
+	}
+
+	log.WithField("userID", userID).Info("user avatar updated")
+
+	s.publish(Event{
+		Type:   EventProfileUpdated,
+		UserID: userID,
+	})
+
+	return user, nil
+}
+
+// GetAvatar retrieves the avatar image by name.
+func (s *Service) GetAvatar(name string) (*entity.Avatar, error) {
+	avatar, err := s.avatars.Find(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find avatar")
+	}
+
+	return avatar, nil
+}
+
+// SetStatus saves the status chosen by the user, the change is published to the user rooms.
+func (s *Service) SetStatus(userID int, status entity.UserStatus) (*entity.User, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find user")
+	}
+
+	if err = user.SetStatus(status); err != nil {
+		return nil, errors.Wrap(err, "could not set status")
+	}
+
+	if err = s.repo.UpdateStatus(userID, user.Status); err != nil {
+		log.WithError(err).Error("could not update user status")
+		return nil, errors.Wrap(err, "could not update user status")
+	}
+
+	log.WithFields(log.Fields{
+		"userID": userID,
+		"status": status,
+	}).Info("user status updated")
+
+	s.publish(Event{
+		Type:   EventStatusUpdated,
+		UserID: userID,
+	})
+
+	return user, nil
+}
+
+// ListBlocks lists the users blocked by the user.
+func (s *Service) ListBlocks(userID int) ([]*entity.UserBlock, error) {
+	blocks, err := s.repo.ListBlocks(userID)
+	if err != nil {
+		log.WithError(err).Error("could not retrieve blocked users")
+		return nil, errors.Wrap(err, "could not retrieve blocked users")
+	}
+
+	return blocks, nil
+}
+
+// BlockUser hides the messages of the blocked user from the user, blocking a user twice is a no-op.
+// the change is published to the user sessions.
+func (s *Service) BlockUser(userID, blockedID int) error {
+	block, err := entity.NewUserBlock(userID, blockedID)
+	if err != nil {
+		return errors.Wrap(err, "could not block user")
+	}
+
+	if _, err = s.repo.FindByID(blockedID); err != nil {
+		return errors.Wrap(err, "could not find user")
+	}
+
+	if err = s.repo.CreateBlock(block); err != nil {
+		log.WithError(err).Error("could not create user block")
+		return errors.Wrap(err, "could not create user block")
+	}
+
+	log.WithFields(log.Fields{
+		"userID":    userID,
+		"blockedID": blockedID,
+	}).Info("user blocked")
+
+	s.publish(Event{
+		Type:   EventBlocksUpdated,
+		UserID: userID,
+	})
+
+	return nil
+}
+
+// UnblockUser shows the messages of the unblocked user again, unblocking a user not blocked is a no-op.
+// the change is published to the user sessions.
+func (s *Service) UnblockUser(userID, blockedID int) error {
+	if err := s.repo.DeleteBlock(userID, blockedID); err != nil {
+		log.WithError(err).Error("could not delete user block")
+		return errors.Wrap(err, "could not delete user block")
+	}
+
+	log.WithFields(log.Fields{
+		"userID":    userID,
+		"blockedID": blockedID,
+	}).Info("user unblocked")
+
+	s.publish(Event{
+		Type:   EventBlocksUpdated,
+		UserID: userID,
+	})
+
+	return nil
+}
+
+// deleteAvatar deletes an unused avatar image, a failure is only logged.
+func (s *Service) deleteAvatar(name string) {
+	if err := s.avatars.Delete(name); err != nil {
+		log.WithError(err).WithField("avatar", name).Error("could not delete avatar")
+	}
+}
+
+func (s *Service) publish(e Event) {
+	logger := log.WithFields(log.Fields{
+		"UserID": e.UserID,
+		"Event":  e.Type,
+	})
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		logger.WithError(err).Error("could not encode user event")
+		return
+	}
+
+	if err = s.events.WriteMessage(context.Background(), b); err != nil {
+		logger.WithError(err).Error("could not publish user event")
+	}
+}
+
+// rehashPassword upgrades the user password hash to the policy cost, a failure is logged and the hash kept,
+// it doesn't prevent the login.
+func (s *Service) rehashPassword(user *entity.User, password string) {
+	if !user.NeedsRehash(s.policy.Cost) {
+		return
+	}
+
+	if err := user.Rehash(password, s.policy.Cost); err != nil {
+		log.WithError(err).Error("could not rehash user password")
+		return
+	}
+
+	if err := s.repo.UpdatePassword(user.ID, user.Password); err != nil {
+		log.WithError(err).Error("could not update user password hash")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"userID": user.ID,
+		"cost":   s.policy.Cost,
+	}).Info("user password rehashed")
+}
@@ -0,0 +1,194 @@
+package user
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/entity"
+)
+
+// ThrottleConfig limits the failed attempts of a key, e.g. a username or a client IP.
+//
+// after FreeAttempts failures each failure blocks the key for BaseDelay, doubled by each following failure
+// up to MaxDelay. LockoutAttempts failures lock the key out for LockoutDuration. The failures are forgotten
+// ResetAfter the last one.
+type ThrottleConfig struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAttempts int
+	LockoutDuration time.Duration
+	ResetAfter      time.Duration
+}
+
+// DefaultUsernameThrottle throttle of the failed logins of a username.
+var DefaultUsernameThrottle = ThrottleConfig{
+	FreeAttempts:    3,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	LockoutAttempts: 10,
+	LockoutDuration: 15 * time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+// DefaultIPThrottle throttle of the failed logins of a client IP, shared by the users behind a NAT.
+var DefaultIPThrottle = ThrottleConfig{
+	FreeAttempts:    10,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	LockoutAttempts: 50,
+	LockoutDuration: 15 * time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+// ThrottleError the login was refused until RetryAfter.
+type ThrottleError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", entity.ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap allows errors.Is(err, entity.ErrTooManyAttempts).
+func (e *ThrottleError) Unwrap() error {
+	return entity.ErrTooManyAttempts
+}
+
+// attempts failures of a throttled key.
+type attempts struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// Throttle counts the failed attempts by key in memory, the attempts aren't shared between instances.
+type Throttle struct {
+	cfg       ThrottleConfig
+	mu        sync.Mutex
+	attempts  map[string]*attempts
+	lastSweep time.Time
+}
+
+// NewThrottle Throttle builder.
+func NewThrottle(cfg ThrottleConfig) *Throttle {
+	return &Throttle{
+		cfg:      cfg,
+		attempts: make(map[string]*attempts),
+	}
+}
+
+// Wait returns how long the key must wait before the next attempt, zero if the attempt is allowed.
+func (t *Throttle) Wait(key string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a, ok := t.attempts[normalizeKey(key)]
+	if !ok || !now.Before(a.blockedUntil) {
+		return 0
+	}
+
+	return a.blockedUntil.Sub(now)
+}
+
+// Fail records a failed attempt of the key and returns its failures count,
+// locked is true when the failure locked the key out.
+func (t *Throttle) Fail(key string, now time.Time) (failures int, locked bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(now)
+
+	key = normalizeKey(key)
+	a, ok := t.attempts[key]
+	if !ok || t.expired(a, now) {
+		a = &attempts{}
+		t.attempts[key] = a
+	}
+
+	a.failures++
+	a.lastFailure = now
+
+	switch {
+	case t.cfg.LockoutAttempts > 0 && a.failures >= t.cfg.LockoutAttempts:
+		a.blockedUntil = now.Add(t.cfg.LockoutDuration)
+		locked = true
+	case a.failures > t.cfg.FreeAttempts:
+		a.blockedUntil = now.Add(t.delay(a.failures - t.cfg.FreeAttempts))
+	}
+
+	return a.failures, locked
+}
+
+// Reset forgets the failed attempts of the key.
+func (t *Throttle) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.attempts, normalizeKey(key))
+}
+
+// delay doubles the base delay by each throttled failure, up to the max delay.
+func (t *Throttle) delay(throttled int) time.Duration {
+	factor := math.Pow(2, float64(throttled-1))
+	if delay := float64(t.cfg.BaseDelay) * factor; delay < float64(t.cfg.MaxDelay) {
+		return time.Duration(delay)
+	}
+
+	return t.cfg.MaxDelay
+}
+
+func (t *Throttle) expired(a *attempts, now time.Time) bool {
+	return now.Sub(a.lastFailure) >= t.cfg.ResetAfter && !now.Before(a.blockedUntil)
+}
+
+// sweep removes the expired attempts, at most once by ResetAfter, to bound the memory used by the
+// keys of a brute force attack.
+func (t *Throttle) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < t.cfg.ResetAfter {
+		return
+	}
+
+	for key, a := range t.attempts {
+		if t.expired(a, now) {
+			delete(t.attempts, key)
+		}
+	}
+
+	t.lastSweep = now
+}
+
+// normalizeKey the usernames are case-insensitive.
+func normalizeKey(key string) string {
+	return strings.ToLower(key)
+}
+
+// LoginThrottle throttles the failed logins by username and by client IP.
+type LoginThrottle struct {
+	Username *Throttle
+	IP       *Throttle
+
+	// Now clock of the logins, time.Now when nil.
+	Now func() time.Time
+}
+
+// NewLoginThrottle LoginThrottle builder.
+func NewLoginThrottle(username, ip ThrottleConfig) LoginThrottle {
+	return LoginThrottle{
+		Username: NewThrottle(username),
+		IP:       NewThrottle(ip),
+		Now:      time.Now,
+	}
+}
+
+// now current time of the LoginThrottle clock.
+func (t LoginThrottle) now() time.Time {
+	if t.Now == nil {
+		return time.Now()
+	}
+
+	return t.Now()
+}
@@ -0,0 +1,96 @@
+package user_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vsantosalmeida/browser-chat/usecase/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle(t *testing.T) {
+	var (
+		cfg = user.ThrottleConfig{
+			FreeAttempts:    2,
+			BaseDelay:       time.Second,
+			MaxDelay:        4 * time.Second,
+			LockoutAttempts: 6,
+			LockoutDuration: time.Minute,
+			ResetAfter:      time.Hour,
+		}
+		now = time.Date(2023, 9, 5, 16, 0, 0, 0, time.UTC)
+	)
+
+	var tt = []struct {
+		name     string
+		failures int
+		expected time.Duration
+		locked   bool
+	}{
+		{
+			name:     "When the failures don't exceed the free attempts; should allow the next attempt",
+			failures: 2,
+		},
+		{
+			name:     "When the failures exceed the free attempts; should wait the base delay",
+			failures: 3,
+			expected: time.Second,
+		},
+		{
+			name:     "When the key keeps failing; should double the delay",
+			failures: 4,
+			expected: 2 * time.Second,
+		},
+		{
+			name:     "When the delay exceeds the max delay; should wait the max delay",
+			failures: 5,
+			expected: 4 * time.Second,
+		},
+		{
+			name:     "When the failures reach the lockout attempts; should lock the key out",
+			failures: 6,
+			expected: time.Minute,
+			locked:   true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			throttle := user.NewThrottle(cfg)
+
+			var locked bool
+			for i := 0; i < tc.failures; i++ {
+				_, locked = throttle.Fail("Test", now)
+			}
+
+			assert.Equal(t, tc.locked, locked)
+			assert.Equal(t, tc.expected, throttle.Wait("test", now))
+			assert.Zero(t, throttle.Wait("other", now))
+			assert.Zero(t, throttle.Wait("test", now.Add(tc.expected)))
+		})
+	}
+}
+
+func TestThrottleReset(t *testing.T) {
+	var (
+		cfg = user.ThrottleConfig{
+			BaseDelay:  time.Second,
+			MaxDelay:   time.Minute,
+			ResetAfter: time.Hour,
+		}
+		now = time.Date(2023, 9, 5, 16, 0, 0, 0, time.UTC)
+	)
+
+	throttle := user.NewThrottle(cfg)
+	throttle.Fail("test", now)
+	throttle.Fail("test", now)
+	assert.Equal(t, 2*time.Second, throttle.Wait("test", now))
+
+	throttle.Reset("test")
+	assert.Zero(t, throttle.Wait("test", now))
+
+	throttle.Fail("test", now)
+	failures, _ := throttle.Fail("test", now.Add(cfg.ResetAfter))
+	assert.Equal(t, 1, failures, "the failures are forgotten after ResetAfter")
+}